@@ -0,0 +1,329 @@
+package agent
+
+import (
+	"camera/config"
+	"camera/logs"
+	"camera/record"
+	"camera/stepper"
+	"camera/telemetry"
+	"camera/update"
+	"camera/webrtc"
+	"camera/websocket"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	pb "messages/msgspb"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// tokenRefreshMargin is how long before expiry the access token is refreshed
+	tokenRefreshMargin = 5 * time.Minute
+	// tokenRefreshRetry is the delay between failed refresh attempts
+	tokenRefreshRetry = 30 * time.Second
+)
+
+// Agent wires the camera subsystems to the server connection
+type Agent struct {
+	config     *config.Config
+	configPath string
+	configLock sync.Mutex
+
+	cancelLock sync.Mutex
+	cancel     context.CancelCauseFunc
+
+	Websocket  *websocket.WebsocketManager
+	Dispatcher *websocket.Dispatcher
+	Outbox     *websocket.OutboundQueue
+	Recorder   *record.Recorder
+	Retention  *record.Retention
+	Backup     *record.Backup
+	Offloader  *record.Offloader
+	WebRTC     *webrtc.WebRTCManager
+	Updater    *update.Updater
+	Telemetry  *telemetry.Collector
+	Logs       *logs.Streamer
+
+	logRing *logs.Ring
+}
+
+// New creates an agent for the given configuration and registers all message handlers
+func New(cfg *config.Config, configPath string) (*Agent, error) {
+	// Keep recent logs in memory so the owner can tail them remotely
+	ring := logs.Install(slog.LevelInfo)
+
+	serverURL, err := url.Parse(cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse server address: %w", err)
+	}
+
+	ws := websocket.NewWebsocketManager(serverURL, cfg)
+
+	outbox, err := websocket.NewOutboundQueue(ws, websocket.QueueOptions{
+		Path: filepath.Join(cfg.RecordDir, "outbox.json"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create outbound queue: %w", err)
+	}
+
+	recorder := record.NewRecorder(cfg)
+	if recorder == nil {
+		return nil, errors.New("failed to create recorder")
+	}
+	recorder.SetWebsocketManager(ws)
+	recorder.SetRecordingKey(cfg.UserConfig.RecordingKey)
+
+	rtc := webrtc.NewWebRTCManager(ws, stepper.NewMovementManager())
+
+	updater, err := update.NewUpdater(cfg, outbox)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create updater: %w", err)
+	}
+
+	a := &Agent{
+		config:     cfg,
+		configPath: configPath,
+		Websocket:  ws,
+		Dispatcher: websocket.NewDispatcher(ws),
+		Outbox:     outbox,
+		Recorder:   recorder,
+		WebRTC:     rtc,
+		Updater:    updater,
+		Logs:       logs.NewStreamer(ring, ws),
+		logRing:    ring,
+	}
+	a.Retention = record.NewRetention(recorder, a.retentionPolicy)
+	a.Backup = record.NewBackup(recorder, cfg, a.backupMode)
+	a.Offloader = record.NewOffloader(recorder)
+	a.Telemetry = telemetry.NewCollector(cfg, outbox, rtc.ViewerCount, a.Retention.Usage)
+
+	recorder.RegisterHandlers(a.Dispatcher)
+	rtc.RegisterHandlers(a.Dispatcher)
+	updater.RegisterHandlers(a.Dispatcher)
+	a.Logs.RegisterHandlers(a.Dispatcher)
+	a.Dispatcher.Register(&pb.Message_UserConfig{}, a.handleUserConfig)
+	a.Dispatcher.Register(&pb.Message_TriggerRefresh{}, a.handleTriggerRefresh)
+	a.Dispatcher.Register(&pb.Message_CameraCommand{}, a.handleCameraCommand)
+	a.Dispatcher.Register(&pb.Message_Deregister{}, a.handleDeregister)
+
+	// Pick up config changes made while we were offline and report the agent version
+	ws.OnStateChange(func(connected bool) {
+		if connected {
+			go updater.Connected()
+			a.Backup.Trigger()
+			go func() {
+				if err := a.refreshUserConfig(); err != nil {
+					slog.Error("Failed to refresh user config", "error", err)
+				}
+			}()
+		}
+	})
+
+	return a, nil
+}
+
+// Run starts streaming and processes server messages until the context is
+// cancelled. It returns config.ErrReprovision when the server revoked this
+// camera or it was factory reset, in which case the config has been deleted
+// and setup must run again, and ErrRestart when a restart was requested.
+// Everything the agent started is shut down before it returns, so a new
+// agent can take over the recordings.
+func (a *Agent) Run(ctx context.Context) error {
+	// Deferred first to run after cancel, the workers touching recordings
+	// must be done before the recorder closes
+	var workers sync.WaitGroup
+	defer a.shutdown(&workers)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	a.cancelLock.Lock()
+	a.cancel = cancel
+	a.cancelLock.Unlock()
+
+	a.WebRTC.StartCamera(ctx)
+
+	go func() {
+		<-ctx.Done()
+		a.Logs.Stop()
+		a.Websocket.Close()
+	}()
+
+	go a.credentialLoop(ctx, cancel)
+	go a.Updater.Start(ctx)
+	go a.Telemetry.Run(ctx)
+	for _, run := range []func(context.Context){a.Retention.Run, a.Backup.Run, a.Offloader.Run} {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(ctx)
+		}()
+	}
+
+	// The first connection is made before the state handlers are registered
+	if a.Websocket.IsConnected() {
+		go a.Updater.Connected()
+	}
+
+	a.Dispatcher.Run(ctx)
+
+	if err := context.Cause(ctx); !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return nil
+}
+
+// shutdown waits for the recording workers, whose context is already
+// cancelled, and releases the stream, the recorder and its index
+func (a *Agent) shutdown(workers *sync.WaitGroup) {
+	workers.Wait()
+	a.WebRTC.Close()
+	if err := a.Recorder.Close(); err != nil {
+		slog.Error("Failed to close recorder", "error", err)
+	}
+}
+
+// credentialLoop refreshes the access token shortly before it expires
+func (a *Agent) credentialLoop(ctx context.Context, cancel context.CancelCauseFunc) {
+	if a.config.TokenExpiry().IsZero() {
+		slog.Warn("Camera token never expires, skipping credential refresh")
+		return
+	}
+
+	var retry time.Duration
+	for {
+		wait := time.Until(a.config.TokenExpiry()) - tokenRefreshMargin
+		if retry > 0 {
+			wait = retry
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		err := config.RefreshCredentials(a.config)
+		var deregistered *config.DeregisteredError
+		if errors.As(err, &deregistered) {
+			a.deregister(deregistered.Reason, deregistered.DeleteRecordings)
+			return
+		}
+		if errors.Is(err, config.ErrReprovision) {
+			slog.Error("Camera credentials revoked, returning to setup")
+			if err := config.DeleteConfig(a.configPath); err != nil {
+				slog.Error("Failed to delete config", "error", err)
+			}
+			cancel(err)
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to refresh credentials", "error", err)
+			retry = tokenRefreshRetry
+			continue
+		}
+		retry = 0
+
+		if err := a.saveConfig(); err != nil {
+			slog.Error("Failed to save refreshed credentials", "error", err)
+		}
+		slog.Info("Camera credentials refreshed", "expires", a.config.TokenExpiry())
+	}
+}
+
+func (a *Agent) handleDeregister(msg *pb.Message) error {
+	deregister := msg.GetDeregister()
+	a.deregister(deregister.Reason, deregister.Recordings == pb.RecordingRetention_RECORDING_RETENTION_DELETE)
+	return nil
+}
+
+// deregister wipes the camera after it was deleted from the server and stops
+// Run so setup can start again. The pinned server key is kept, so only the
+// same server can provision the camera again.
+func (a *Agent) deregister(reason string, deleteRecordings bool) {
+	slog.Warn("Camera deregistered by server, returning to setup", "reason", reason, "delete_recordings", deleteRecordings)
+
+	if deleteRecordings {
+		if err := a.Recorder.DeleteRecordings(); err != nil {
+			slog.Error("Failed to delete recordings", "error", err)
+		}
+	}
+	// Needs the refresh token, so before the config is gone
+	if err := config.AcknowledgeDeregistration(a.config); err != nil {
+		slog.Error("Failed to acknowledge deregistration", "error", err)
+	}
+	if err := config.DeleteConfig(a.configPath); err != nil {
+		slog.Error("Failed to delete config", "error", err)
+	}
+	a.stop(config.ErrReprovision)
+}
+
+func (a *Agent) handleUserConfig(msg *pb.Message) error {
+	return a.applyUserConfig(msg.GetUserConfig())
+}
+
+func (a *Agent) handleTriggerRefresh(msg *pb.Message) error {
+	return a.refreshUserConfig()
+}
+
+// refreshUserConfig fetches the latest user config from the server and applies it
+func (a *Agent) refreshUserConfig() error {
+	userConfig, err := config.GetUpdatedUserConfig(a.config)
+	if err != nil {
+		return err
+	}
+	return a.applyUserConfig(userConfig)
+}
+
+// applyUserConfig stores the new user config and persists it to disk
+func (a *Agent) applyUserConfig(userConfig *pb.UserConfig) error {
+	if userConfig == nil {
+		return errors.New("empty user config")
+	}
+
+	a.configLock.Lock()
+	defer a.configLock.Unlock()
+
+	proto.Reset(&a.config.UserConfig)
+	proto.Merge(&a.config.UserConfig, userConfig)
+
+	if err := a.config.SaveConfig(a.configPath); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	slog.Info("User config updated", "recording_type", a.config.UserConfig.RecordingType.String())
+	a.Recorder.SetRecordingKey(a.config.UserConfig.RecordingKey)
+	a.Retention.Trigger()
+	a.Backup.Trigger()
+	return nil
+}
+
+// retentionPolicy returns a copy of the user's retention policy
+func (a *Agent) retentionPolicy() *pb.RetentionPolicy {
+	a.configLock.Lock()
+	defer a.configLock.Unlock()
+	if a.config.UserConfig.Retention == nil {
+		return nil
+	}
+	return proto.Clone(a.config.UserConfig.Retention).(*pb.RetentionPolicy)
+}
+
+// backupMode returns which recordings the user backs up to the server
+func (a *Agent) backupMode() pb.BackupMode {
+	a.configLock.Lock()
+	defer a.configLock.Unlock()
+	return a.config.UserConfig.BackupMode
+}
+
+// saveConfig persists the config, serialized with user config updates
+func (a *Agent) saveConfig() error {
+	a.configLock.Lock()
+	defer a.configLock.Unlock()
+	return a.config.SaveConfig(a.configPath)
+}
@@ -0,0 +1,136 @@
+package agent
+
+import (
+	"camera/config"
+	"camera/websocket"
+	"errors"
+	"fmt"
+	"log/slog"
+	pb "messages/msgspb"
+	"os/exec"
+	"time"
+)
+
+// ErrRestart is returned by Run when the server asked the agent to restart,
+// the caller should create a new agent from the config on disk
+var ErrRestart = errors.New("agent restart requested")
+
+const (
+	// commandResultTTL is how long an undelivered command result is kept
+	commandResultTTL = time.Hour
+	// commandExitDelay gives the result time to reach the server before the agent stops
+	commandExitDelay = 2 * time.Second
+)
+
+func (a *Agent) handleCameraCommand(msg *pb.Message) error {
+	command := msg.GetCameraCommand()
+	slog.Info("Command received", "id", command.Id, "type", command.Type.String())
+
+	// Collecting and uploading a bundle takes a while, don't hold up the dispatcher
+	if command.Type == pb.CommandType_COMMAND_TYPE_DIAGNOSTICS {
+		go func() {
+			output, err := a.uploadDiagnostics(command.Id)
+			if err != nil {
+				slog.Error("Diagnostics failed", "id", command.Id, "error", err)
+			}
+			a.sendCommandResult(command, output, err)
+		}()
+		return nil
+	}
+
+	output, after, err := a.runCommand(command.Type)
+	if err != nil {
+		slog.Error("Command failed", "id", command.Id, "type", command.Type.String(), "error", err)
+	}
+	a.sendCommandResult(command, output, err)
+
+	// Disruptive commands run once the result is on its way
+	if after != nil {
+		go func() {
+			time.Sleep(commandExitDelay)
+			after()
+		}()
+	}
+	// The result reports the failure, the dispatcher must not report it again
+	return nil
+}
+
+// sendCommandResult reports the outcome of a command to the server
+func (a *Agent) sendCommandResult(command *pb.CameraCommand, output string, err error) {
+	result := &pb.CommandResult{
+		Id:     command.Id,
+		Type:   command.Type,
+		Status: pb.CommandStatus_COMMAND_STATUS_SUCCEEDED,
+		Output: output,
+	}
+	if err != nil {
+		result.Status = pb.CommandStatus_COMMAND_STATUS_FAILED
+		result.Output = err.Error()
+	}
+
+	sendErr := a.Outbox.Send(&pb.Message{
+		From:     a.config.CameraUuid,
+		To:       "server",
+		DataType: &pb.Message_CommandResult{CommandResult: result},
+	}, websocket.PriorityHigh, commandResultTTL)
+	if sendErr != nil {
+		slog.Error("Failed to send command result", "id", command.Id, "error", sendErr)
+	}
+}
+
+// runCommand executes the command and returns its output and an optional
+// action to run after the result has been reported
+func (a *Agent) runCommand(commandType pb.CommandType) (string, func(), error) {
+	switch commandType {
+	case pb.CommandType_COMMAND_TYPE_REBOOT:
+		return "Rebooting", func() {
+			if output, err := exec.Command("reboot").CombinedOutput(); err != nil {
+				slog.Error("Reboot failed", "error", err, "output", string(output))
+			}
+		}, nil
+
+	case pb.CommandType_COMMAND_TYPE_RESTART_AGENT:
+		return "Restarting agent", func() { a.stop(ErrRestart) }, nil
+
+	case pb.CommandType_COMMAND_TYPE_RESTART_STREAM:
+		if err := a.WebRTC.RestartStream(); err != nil {
+			return "", nil, fmt.Errorf("failed to restart stream: %w", err)
+		}
+		return "Stream restarted", nil, nil
+
+	case pb.CommandType_COMMAND_TYPE_FACTORY_RESET:
+		// The server forgets the camera first, a reset camera must not be
+		// left behind as an offline camera with its backups
+		if err := config.Unregister(a.config); err != nil {
+			return "", nil, err
+		}
+		if err := a.Recorder.DeleteRecordings(); err != nil {
+			slog.Error("Failed to delete recordings", "error", err)
+		}
+		if err := config.DeleteConfig(a.configPath); err != nil {
+			return "", nil, fmt.Errorf("failed to delete config: %w", err)
+		}
+		if err := config.DeleteServerTrust(config.DataPath(config.TrustFile)); err != nil {
+			slog.Error("Failed to delete pinned server key", "error", err)
+		}
+		return "Camera unregistered and wiped, returning to setup", func() { a.stop(config.ErrReprovision) }, nil
+
+	case pb.CommandType_COMMAND_TYPE_RESYNC_CONFIG:
+		if err := a.refreshUserConfig(); err != nil {
+			return "", nil, fmt.Errorf("failed to resync config: %w", err)
+		}
+		return "Config resynced", nil, nil
+
+	default:
+		return "", nil, fmt.Errorf("unsupported command: %s", commandType.String())
+	}
+}
+
+// stop ends Run with the given cause
+func (a *Agent) stop(cause error) {
+	a.cancelLock.Lock()
+	defer a.cancelLock.Unlock()
+	if a.cancel != nil {
+		a.cancel(cause)
+	}
+}
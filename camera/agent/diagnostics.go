@@ -0,0 +1,273 @@
+package agent
+
+import (
+	"archive/tar"
+	"bytes"
+	"camera/setup"
+	"camera/update"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"messages/jwtmsg"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// diagnosticsChunkSize is the size of each uploaded piece of the bundle
+	diagnosticsChunkSize = 256 * 1024
+	// diagnosticsRetries is how often a chunk upload is retried
+	diagnosticsRetries = 3
+)
+
+// redactedNames are parts of config field names whose values are never
+// included in a diagnostic bundle, at any depth
+var redactedNames = []string{"secret", "key", "token", "password"}
+
+// collectDiagnostics writes a tar.gz with the redacted config, recent logs,
+// telemetry, recording listing, network state and versions
+func (a *Agent) collectDiagnostics(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	files := []struct {
+		name    string
+		collect func() ([]byte, error)
+	}{
+		{"config.json", a.redactedConfig},
+		{"logs.txt", a.diagnosticLogs},
+		{"telemetry.json", func() ([]byte, error) { return json.MarshalIndent(a.Telemetry.Collect(), "", "  ") }},
+		{"recordings.txt", a.recordingListing},
+		{"network.txt", networkState},
+		{"versions.json", versions},
+	}
+
+	now := time.Now()
+	for _, file := range files {
+		data, err := file.collect()
+		if err != nil {
+			// A partial bundle is still useful, note what failed
+			data = []byte(fmt.Sprintf("failed to collect: %v\n", err))
+		}
+
+		err = tw.WriteHeader(&tar.Header{
+			Name:    file.name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: now,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func (a *Agent) redactedConfig() ([]byte, error) {
+	data, err := json.Marshal(a.config)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(redact(fields), "", "  ")
+}
+
+// redact replaces the strings under sensitive names in decoded JSON. Numbers
+// and flags like tokenExpiresAt are kept, they help debugging.
+func redact(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for name, field := range value {
+			if text, ok := field.(string); ok && text != "" && isSensitive(name) {
+				value[name] = "REDACTED"
+				continue
+			}
+			value[name] = redact(field)
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = redact(item)
+		}
+	}
+	return value
+}
+
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, part := range redactedNames {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Agent) diagnosticLogs() ([]byte, error) {
+	var b bytes.Buffer
+	for _, record := range a.logRing.Snapshot(time.Time{}, slog.LevelDebug) {
+		fmt.Fprintf(&b, "%s %-5s %s %s\n", record.Time.Format(time.RFC3339Nano), record.Level, record.Message, record.Attrs)
+	}
+	return b.Bytes(), nil
+}
+
+func (a *Agent) recordingListing() ([]byte, error) {
+	var b bytes.Buffer
+	err := filepath.WalkDir(a.config.RecordDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			fmt.Fprintf(&b, "%s: %v\n", path, err)
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		fmt.Fprintf(&b, "%s\t%d\t%s\n", path, info.Size(), info.ModTime().Format(time.RFC3339))
+		return nil
+	})
+	return b.Bytes(), err
+}
+
+func networkState() ([]byte, error) {
+	var b bytes.Buffer
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if status, err := setup.CurrentNetworkStatus(ctx); err != nil {
+		fmt.Fprintf(&b, "wifi: %v\n", err)
+	} else {
+		fmt.Fprintf(&b, "wifi: connected=%t ssid=%q ip=%s\n", status.Connected, status.SSID, status.IPAddress)
+	}
+
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return b.Bytes(), err
+	}
+	for _, iface := range interfaces {
+		var addrs []string
+		if list, err := iface.Addrs(); err == nil {
+			for _, addr := range list {
+				addrs = append(addrs, addr.String())
+			}
+		}
+		fmt.Fprintf(&b, "%s: flags=%s mac=%s addrs=%s\n", iface.Name, iface.Flags, iface.HardwareAddr, strings.Join(addrs, ","))
+	}
+	return b.Bytes(), nil
+}
+
+func versions() ([]byte, error) {
+	kernel, _ := os.ReadFile("/proc/version")
+	return json.MarshalIndent(map[string]string{
+		"agent":  update.Version,
+		"go":     runtime.Version(),
+		"os":     runtime.GOOS,
+		"arch":   runtime.GOARCH,
+		"kernel": strings.TrimSpace(string(kernel)),
+	}, "", "  ")
+}
+
+// uploadDiagnostics collects a bundle and uploads it in chunks, resuming from
+// the offset the server reports if a chunk fails
+func (a *Agent) uploadDiagnostics(bundleID string) (string, error) {
+	f, err := os.CreateTemp("", "sudocam-diagnostics-*.tar.gz")
+	if err != nil {
+		return "", fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := a.collectDiagnostics(f); err != nil {
+		return "", fmt.Errorf("failed to collect diagnostics: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	size := info.Size()
+
+	url := a.config.Addr + jwtmsg.DiagnosticsChunkPath(a.config.CameraUuid, bundleID)
+	chunk := make([]byte, diagnosticsChunkSize)
+	var offset int64
+	failures := 0
+	for {
+		n, err := f.ReadAt(chunk, offset)
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("failed to read bundle: %w", err)
+		}
+
+		final := offset+int64(n) >= size
+		stored, err := a.uploadChunk(url, offset, chunk[:n], final)
+		if err == nil && final {
+			break
+		}
+
+		if err != nil {
+			failures++
+			if failures > diagnosticsRetries {
+				return "", fmt.Errorf("failed to upload bundle: %w", err)
+			}
+			slog.Warn("Diagnostic chunk upload failed, retrying", "offset", offset, "error", err)
+			time.Sleep(time.Duration(failures) * time.Second)
+		} else {
+			failures = 0
+			offset += int64(n)
+		}
+
+		// Continue from whatever the server has, even after a failed chunk
+		if stored >= 0 {
+			offset = stored
+		}
+	}
+
+	slog.Info("Diagnostic bundle uploaded", "id", bundleID, "size", size)
+	return fmt.Sprintf("Uploaded diagnostic bundle (%d bytes)", size), nil
+}
+
+// uploadChunk sends one chunk and returns the number of bytes the server has
+// stored, or -1 if it is unknown
+func (a *Agent) uploadChunk(url string, offset int64, data []byte, final bool) (int64, error) {
+	req, err := http.NewRequest("PUT", fmt.Sprintf("%s?offset=%d&final=%t", url, offset, final), bytes.NewReader(data))
+	if err != nil {
+		return -1, err
+	}
+	req.Header.Set("Authorization", a.config.AuthToken())
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return -1, err
+	}
+	defer resp.Body.Close()
+
+	stored, parseErr := strconv.ParseInt(resp.Header.Get(jwtmsg.DiagnosticsOffsetHeader), 10, 64)
+	if parseErr != nil {
+		stored = -1
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return stored, fmt.Errorf("unexpected status code: %d %s", resp.StatusCode, resp.Status)
+	}
+	return stored, nil
+}
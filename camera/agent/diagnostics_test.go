@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"camera/config"
+	"encoding/json"
+	"messages/s3"
+	"strings"
+	"testing"
+)
+
+func TestRedactedConfig(t *testing.T) {
+	a := &Agent{config: &config.Config{
+		CameraUuid:     "camera",
+		Addr:           "https://cam.example.com",
+		Token:          "Bearer access-token",
+		RefreshToken:   "refresh-token",
+		TokenExpiresAt: 1700000000,
+		Storage: config.StorageConfig{
+			Type: "s3",
+			S3: s3.Config{
+				Endpoint:  "http://minio:9000",
+				Bucket:    "recordings",
+				AccessKey: "access-key-id",
+				SecretKey: "secret-access-key",
+			},
+		},
+	}}
+
+	data, err := a.redactedConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"access-token", "refresh-token", "access-key-id", "secret-access-key"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("bundle config contains %q:\n%s", secret, data)
+		}
+	}
+
+	var fields struct {
+		Addr           string `json:"addr"`
+		TokenExpiresAt int64  `json:"tokenExpiresAt"`
+		Storage        struct {
+			S3 struct {
+				Bucket    string `json:"bucket"`
+				SecretKey string `json:"secretKey"`
+			} `json:"s3"`
+		} `json:"storage"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields.Addr != "https://cam.example.com" || fields.TokenExpiresAt != 1700000000 || fields.Storage.S3.Bucket != "recordings" {
+		t.Errorf("useful fields lost: %s", data)
+	}
+	if fields.Storage.S3.SecretKey != "REDACTED" {
+		t.Errorf("secretKey = %q, want REDACTED", fields.Storage.S3.SecretKey)
+	}
+}
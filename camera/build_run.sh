@@ -11,7 +11,8 @@ REMOTE_PATH=/usr/bin/
 PASSWORD=luckfox
 export CGO_CFLAGS="-Ideps/include"
 export CGO_LDFLAGS="-Ldeps/lib -lrknnmrt"
-env GOOS=linux GOARCH=arm GOARM=7  go build -o sudotest main.go
+VERSION=${VERSION:-$(git describe --tags --always --dirty)}
+env GOOS=linux GOARCH=arm GOARM=7  go build -ldflags "-X camera/update.Version=$VERSION" -o sudotest main.go
 
 # Calculate the local binary hash
 LOCAL_HASH=$(sha256sum $BINARY_PATH | awk '{print $1}')
@@ -32,4 +33,4 @@ else
 fi
 
 # Run the binary on the remote host
-sshpass -p "$PASSWORD" ssh $REMOTE_USER@$REMOTE_HOST "$REMOTE_PATH/sudotest --config dev-config.json"
\ No newline at end of file
+sshpass -p "$PASSWORD" ssh $REMOTE_USER@$REMOTE_HOST "$REMOTE_PATH/sudotest --config dev-config.json --data-dir dev-data"
\ No newline at end of file
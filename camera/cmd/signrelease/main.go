@@ -0,0 +1,117 @@
+// Command signrelease signs camera agent releases with the offline release
+// key. Cameras only install builds signed by the key they were built with,
+// the server just passes the signature on.
+//
+//	signrelease -key release_ed25519.pem -genkey
+//	signrelease -key release_ed25519.pem -pubkey
+//	signrelease -key release_ed25519.pem -version v1.2.0 -arch arm sudocam-arm
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"messages/jwtmsg"
+	"os"
+)
+
+func main() {
+	keyPath := flag.String("key", "release_ed25519.pem", "PEM encoded Ed25519 release key")
+	genKey := flag.Bool("genkey", false, "Create a new release key")
+	pubKey := flag.Bool("pubkey", false, "Print the base64 public key to build into the agent")
+	version := flag.String("version", "", "Version of the release")
+	arch := flag.String("arch", "", "GOARCH of the release")
+	flag.Parse()
+
+	var err error
+	switch {
+	case *genKey:
+		err = generateKey(*keyPath)
+	case *pubKey:
+		var key ed25519.PrivateKey
+		if key, err = loadKey(*keyPath); err == nil {
+			fmt.Println(base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)))
+		}
+	case flag.NArg() == 1 && *version != "" && *arch != "":
+		err = sign(*keyPath, flag.Arg(0), *version, *arch)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "signrelease:", err)
+		os.Exit(1)
+	}
+}
+
+func generateKey(path string) error {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	// Never overwrite a key, cameras in the field trust it
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := pem.Encode(f, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func loadKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("release key is not PEM encoded")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse release key: %w", err)
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("release key is not an Ed25519 key")
+	}
+	return privateKey, nil
+}
+
+// sign writes the signature of the binary to <binary>.sig
+func sign(keyPath, binary, version, arch string) error {
+	key, err := loadKey(keyPath)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(binary)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return err
+	}
+
+	payload := jwtmsg.ReleaseSigningPayload(version, arch, hex.EncodeToString(hash.Sum(nil)), size)
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload))
+	return os.WriteFile(binary+jwtmsg.ReleaseSignatureSuffix, []byte(signature+"\n"), 0644)
+}
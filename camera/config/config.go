@@ -3,19 +3,37 @@ package config
 import (
 	"encoding/json"
 	pb "messages/msgspb"
+	"messages/s3"
 	"os"
+	"sync"
+	"time"
 )
 
 // Config holds the camera configuration
 type Config struct {
-	CameraUuid string                  `json:"cameraUUID"`
-	CameraName string                  `json:"cameraName"`
-	Addr       string                  `json:"addr"`
-	RecordDir  string                  `json:"record_dir"`
-	Token      string                  `json:"token"`
-	UserConfig pb.UserConfig           `json:"userConfig"`
+	CameraUuid string        `json:"cameraUUID"`
+	CameraName string        `json:"cameraName"`
+	Addr       string        `json:"addr"`
+	RecordDir  string        `json:"record_dir"`
+	Storage    StorageConfig `json:"storage"`
+	Token      string        `json:"token"`
+	UserConfig pb.UserConfig `json:"userConfig"`
+
+	// RefreshToken is exchanged for a new Token before it expires
+	RefreshToken   string `json:"refreshToken"`
+	TokenExpiresAt int64  `json:"tokenExpiresAt"`
 
 	// Add any other configuration fields here
+
+	tokenLock sync.RWMutex
+}
+
+// StorageConfig is where finished recordings are kept, RecordDir then only
+// buffers them until they are stored
+type StorageConfig struct {
+	Type string    `json:"type"`           // "local" (the default), "mount" or "s3"
+	Path string    `json:"path,omitempty"` // Directory of local and mount storage, empty keeps recordings in RecordDir
+	S3   s3.Config `json:"s3"`
 }
 
 // LoadConfig loads the configuration from a JSON file
@@ -38,16 +56,48 @@ func LoadConfig(filename string) (*Config, error) {
 	return &config, nil
 }
 
+// AuthToken returns the current access token as an Authorization header value
+func (c *Config) AuthToken() string {
+	c.tokenLock.RLock()
+	defer c.tokenLock.RUnlock()
+	return c.Token
+}
+
+// TokenExpiry returns when the current access token expires, zero if it never does
+func (c *Config) TokenExpiry() time.Time {
+	c.tokenLock.RLock()
+	defer c.tokenLock.RUnlock()
+	if c.TokenExpiresAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(c.TokenExpiresAt, 0)
+}
+
+// SetTokens replaces the access and refresh tokens
+func (c *Config) SetTokens(token, refreshToken string, expiresAt int64) {
+	c.tokenLock.Lock()
+	defer c.tokenLock.Unlock()
+	c.Token = token
+	c.RefreshToken = refreshToken
+	c.TokenExpiresAt = expiresAt
+}
+
 func DeleteConfig(filename string) error {
 	return os.Remove(filename)
 }
 
 // SaveConfig saves the configuration to a JSON file
 func (c *Config) SaveConfig(filename string) error {
+	c.tokenLock.RLock()
 	data, err := json.MarshalIndent(c, "", "  ")
+	c.tokenLock.RUnlock()
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(filename, data, 0644)
+	// Holds the camera's credentials and storage secrets
+	if err := os.WriteFile(filename, data, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(filename, 0600)
 }
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"messages/jwtmsg"
+	"net/http"
+)
+
+// ErrReprovision is returned when the server no longer accepts this camera's
+// credentials, the camera has to run setup again
+var ErrReprovision = errors.New("camera must be provisioned again")
+
+// DeregisteredError is returned when the camera was deleted from the server,
+// it matches ErrReprovision and says whether recordings should be wiped too
+type DeregisteredError struct {
+	Reason           string
+	DeleteRecordings bool
+}
+
+func (e *DeregisteredError) Error() string {
+	return fmt.Sprintf("camera deregistered: %s", e.Reason)
+}
+
+func (e *DeregisteredError) Is(target error) bool {
+	return target == ErrReprovision
+}
+
+// RefreshCredentials exchanges the refresh token for a new access token and
+// refresh token. The caller is responsible for saving the config afterwards.
+func RefreshCredentials(config *Config) error {
+	config.tokenLock.RLock()
+	body, err := json.Marshal(&jwtmsg.RefreshCameraToken{
+		CameraUUID:   config.CameraUuid,
+		RefreshToken: config.RefreshToken,
+	})
+	config.tokenLock.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal refresh request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/cameras/token/refresh", config.Addr), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to refresh credentials: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusGone {
+		var deregistration jwtmsg.Deregistration
+		if resp.Header.Get("Content-Type") == "application/json" && json.NewDecoder(resp.Body).Decode(&deregistration) == nil {
+			return &DeregisteredError{
+				Reason:           deregistration.Reason,
+				DeleteRecordings: deregistration.DeleteRecordings,
+			}
+		}
+		return ErrReprovision
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d %s", resp.StatusCode, resp.Status)
+	}
+
+	var tokens jwtmsg.CameraTokens
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return fmt.Errorf("failed to decode credentials: %w", err)
+	}
+
+	config.SetTokens("Bearer "+tokens.Token, tokens.RefreshToken, tokens.ExpiresAt)
+	return nil
+}
+
+// AcknowledgeDeregistration tells the server the camera wiped itself after it
+// was deleted, until then the server keeps repeating the deregistration
+func AcknowledgeDeregistration(config *Config) error {
+	resp, err := postCredentials(config, jwtmsg.DeregistrationAckPath)
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge deregistration: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Not found means an earlier ack already arrived
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("unexpected status code: %d %s", resp.StatusCode, resp.Status)
+	}
+	return nil
+}
+
+// Unregister deletes the camera from the server, used by factory reset before
+// the credentials are wiped
+func Unregister(config *Config) error {
+	resp, err := postCredentials(config, jwtmsg.UnregisterPath)
+	if err != nil {
+		return fmt.Errorf("failed to unregister: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Gone means the server already dropped the camera
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusGone {
+		return fmt.Errorf("unexpected status code: %d %s", resp.StatusCode, resp.Status)
+	}
+	return nil
+}
+
+// postCredentials sends the camera's refresh token to a server endpoint
+func postCredentials(config *Config, path string) (*http.Response, error) {
+	config.tokenLock.RLock()
+	body, err := json.Marshal(&jwtmsg.RefreshCameraToken{
+		CameraUUID:   config.CameraUuid,
+		RefreshToken: config.RefreshToken,
+	})
+	config.tokenLock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	return http.Post(config.Addr+path, "application/json", bytes.NewReader(body))
+}
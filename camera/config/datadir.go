@@ -0,0 +1,31 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// DefaultDataDir is where the camera keeps the files that outlive its config,
+// like the pinned server key
+const DefaultDataDir = "/var/lib/sudocam"
+
+var dataDir = DefaultDataDir
+
+// SetDataDir changes the data directory and creates it, a relative path is
+// made absolute so later changes of the working directory don't move it
+func SetDataDir(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(abs, 0700); err != nil {
+		return err
+	}
+	dataDir = abs
+	return nil
+}
+
+// DataPath returns the absolute path of a file in the data directory
+func DataPath(name string) string {
+	return filepath.Join(dataDir, name)
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+)
+
+// TrustFile, in the data directory, stores the server key pinned at first
+// registration. It is kept apart from the config so re-provisioning can't swap
+// in another server.
+const TrustFile = "trust.json"
+
+// ServerTrust is the provisioning key of the server this camera was first registered with
+type ServerTrust struct {
+	ServerURL string `json:"serverUrl"`
+	PublicKey string `json:"publicKey"` // base64 encoded Ed25519 public key
+}
+
+// LoadServerTrust loads the pinned server key, returning nil if none is pinned yet
+func LoadServerTrust(filename string) (*ServerTrust, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var trust ServerTrust
+	if err := json.Unmarshal(data, &trust); err != nil {
+		return nil, err
+	}
+	return &trust, nil
+}
+
+// SaveServerTrust pins the server key
+func (t *ServerTrust) SaveServerTrust(filename string) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0600)
+}
+
+// DeleteServerTrust removes the pinned key, used by factory reset
+func DeleteServerTrust(filename string) error {
+	err := os.Remove(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
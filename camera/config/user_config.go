@@ -15,7 +15,7 @@ func GetUpdatedUserConfig(config *Config) (*pb.UserConfig, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Set("Authorization", config.Token)
+	req.Header.Set("Authorization", config.AuthToken())
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
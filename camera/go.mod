@@ -1,6 +1,6 @@
 module camera
 
-go 1.23.0
+go 1.24.0
 
 require github.com/makiuchi-d/gozxing v0.1.1
 
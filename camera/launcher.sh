@@ -0,0 +1,42 @@
+#!/bin/sh
+
+# Runs the camera agent and rolls back an update that keeps crashing. Start
+# this from the init system instead of the agent itself, it runs before the
+# new binary so a build that dies before it gets anywhere is still undone.
+#
+# While an update is unconfirmed the agent keeps the previous binary next to
+# the new one as <binary>.old, and removes it once the new version reached
+# the server. Every start in that state counts as an attempt, after
+# MAX_ATTEMPTS the previous binary is put back.
+
+BINARY=${BINARY:-/usr/bin/sudocam}
+DATA_DIR=${DATA_DIR:-/var/lib/sudocam}
+MAX_ATTEMPTS=${MAX_ATTEMPTS:-3}
+ATTEMPTS_FILE="$DATA_DIR/boot_attempts"
+
+mkdir -p "$DATA_DIR"
+
+# Pass stop requests on to the agent
+trap 'kill "$pid" 2>/dev/null; wait "$pid"; exit 0' TERM INT
+
+while true; do
+    if [ -f "$BINARY.old" ]; then
+        attempts=$(cat "$ATTEMPTS_FILE" 2>/dev/null || echo 0)
+        attempts=$((attempts + 1))
+        if [ "$attempts" -gt "$MAX_ATTEMPTS" ]; then
+            echo "Update failed to start $MAX_ATTEMPTS times, rolling back"
+            mv -f "$BINARY.old" "$BINARY"
+            rm -f "$ATTEMPTS_FILE"
+        else
+            echo "$attempts" > "$ATTEMPTS_FILE"
+        fi
+    else
+        rm -f "$ATTEMPTS_FILE"
+    fi
+
+    "$BINARY" -data-dir "$DATA_DIR" "$@" &
+    pid=$!
+    wait "$pid"
+    echo "Agent exited with status $?, restarting"
+    sleep 2
+done
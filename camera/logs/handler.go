@@ -0,0 +1,198 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCapacity is the number of records kept in the ring buffer
+const DefaultCapacity = 2000
+
+// Record is a formatted log record kept in the ring buffer
+type Record struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   string
+}
+
+// Ring keeps the most recent log records and fans new ones out to subscribers
+type Ring struct {
+	mutex       sync.Mutex
+	records     []Record
+	next        int
+	full        bool
+	subscribers map[chan Record]slog.Level
+}
+
+// NewRing creates a ring buffer holding up to capacity records
+func NewRing(capacity int) *Ring {
+	return &Ring{
+		records:     make([]Record, capacity),
+		subscribers: make(map[chan Record]slog.Level),
+	}
+}
+
+func (r *Ring) add(record Record) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.records[r.next] = record
+	r.next = (r.next + 1) % len(r.records)
+	if r.next == 0 {
+		r.full = true
+	}
+
+	for ch, level := range r.subscribers {
+		if record.Level < level {
+			continue
+		}
+		select {
+		case ch <- record:
+		default:
+			// Slow subscriber, drop rather than block logging
+		}
+	}
+}
+
+// Snapshot returns the buffered records at or above the level since the given time, oldest first
+func (r *Ring) Snapshot(since time.Time, level slog.Level) []Record {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ordered := r.records[:r.next]
+	if r.full {
+		ordered = append(append([]Record(nil), r.records[r.next:]...), r.records[:r.next]...)
+	}
+
+	var records []Record
+	for _, record := range ordered {
+		if record.Level >= level && !record.Time.Before(since) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// Subscribe returns a channel receiving new records at or above the level,
+// call the returned function to unsubscribe
+func (r *Ring) Subscribe(level slog.Level) (<-chan Record, func()) {
+	ch := make(chan Record, 256)
+
+	r.mutex.Lock()
+	r.subscribers[ch] = level
+	r.mutex.Unlock()
+
+	return ch, func() {
+		r.mutex.Lock()
+		delete(r.subscribers, ch)
+		r.mutex.Unlock()
+	}
+}
+
+// Handler is a slog.Handler that tees records into a Ring and passes them on
+type Handler struct {
+	next   slog.Handler
+	ring   *Ring
+	level  slog.Leveler
+	attrs  string
+	prefix string
+}
+
+// NewHandler wraps next, records at or above level are also kept in the ring
+func NewHandler(next slog.Handler, ring *Ring, level slog.Leveler) *Handler {
+	return &Handler{next: next, ring: ring, level: level}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() || h.next.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level >= h.level.Level() {
+		var attrs strings.Builder
+		attrs.WriteString(h.attrs)
+		record.Attrs(func(attr slog.Attr) bool {
+			writeAttr(&attrs, h.prefix, attr)
+			return true
+		})
+
+		h.ring.add(Record{
+			Time:    record.Time,
+			Level:   record.Level,
+			Message: record.Message,
+			Attrs:   strings.TrimSpace(attrs.String()),
+		})
+	}
+
+	if h.next.Enabled(ctx, record.Level) {
+		return h.next.Handle(ctx, record)
+	}
+	return nil
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	b.WriteString(h.attrs)
+	for _, attr := range attrs {
+		writeAttr(&b, h.prefix, attr)
+	}
+
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	clone.attrs = b.String()
+	return &clone
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+func writeAttr(b *strings.Builder, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix += attr.Key + "."
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			writeAttr(b, groupPrefix, groupAttr)
+		}
+		return
+	}
+
+	fmt.Fprintf(b, " %s%s=%q", prefix, attr.Key, attr.Value.String())
+}
+
+var (
+	installOnce sync.Once
+	defaultRing *Ring
+)
+
+// Install makes the default logger keep records at or above level in a ring
+// buffer, while still writing text logs to stderr. It returns the ring and is
+// safe to call more than once.
+func Install(level slog.Leveler) *Ring {
+	installOnce.Do(func() {
+		defaultRing = NewRing(DefaultCapacity)
+		handler := NewHandler(slog.NewTextHandler(os.Stderr, nil), defaultRing, level)
+		slog.SetDefault(slog.New(handler))
+	})
+	return defaultRing
+}
@@ -0,0 +1,161 @@
+package logs
+
+import (
+	"camera/websocket"
+	"context"
+	"log/slog"
+	pb "messages/msgspb"
+	"messages/rpc"
+	"sync"
+	"time"
+)
+
+const (
+	// batchSize is the maximum number of records per LogBatch
+	batchSize = 200
+	// flushInterval is how often followed records are sent
+	flushInterval = time.Second
+	// maxFollow stops a live tail the server forgot to stop
+	maxFollow = 30 * time.Minute
+)
+
+// Streamer answers log requests from the server out of a Ring
+type Streamer struct {
+	ring *Ring
+	ws   *websocket.WebsocketManager
+
+	mutex   sync.Mutex
+	follows map[string]context.CancelFunc
+}
+
+// NewStreamer creates a streamer sending records from the ring over the websocket
+func NewStreamer(ring *Ring, ws *websocket.WebsocketManager) *Streamer {
+	return &Streamer{
+		ring:    ring,
+		ws:      ws,
+		follows: make(map[string]context.CancelFunc),
+	}
+}
+
+// RegisterHandlers registers the log request handler with the dispatcher
+func (s *Streamer) RegisterHandlers(d *websocket.Dispatcher) {
+	d.Register(&pb.Message_LogRequest{}, func(msg *pb.Message) error {
+		s.handleRequest(msg)
+		return nil
+	})
+}
+
+// Stop ends every live tail
+func (s *Streamer) Stop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for id, cancel := range s.follows {
+		cancel()
+		delete(s.follows, id)
+	}
+}
+
+// handleRequest answers a log request with batches replying to it, a live
+// tail is keyed by the request's request_id until stopped
+func (s *Streamer) handleRequest(msg *pb.Message) {
+	req := msg.GetLogRequest()
+	if req.Stop {
+		s.mutex.Lock()
+		if cancel, ok := s.follows[req.Id]; ok {
+			cancel()
+			delete(s.follows, req.Id)
+		}
+		s.mutex.Unlock()
+		return
+	}
+
+	level := slog.Level(req.MinLevel)
+	var since time.Time
+	if req.Since > 0 {
+		since = time.Unix(req.Since, 0)
+	}
+
+	// Subscribe before taking the snapshot so nothing falls in between
+	var updates <-chan Record
+	unsubscribe := func() {}
+	if req.Follow {
+		updates, unsubscribe = s.ring.Subscribe(level)
+	}
+
+	records := s.ring.Snapshot(since, level)
+	for len(records) > batchSize {
+		s.send(msg, records[:batchSize], false)
+		records = records[batchSize:]
+	}
+	s.send(msg, records, !req.Follow)
+
+	if !req.Follow {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), maxFollow)
+	s.mutex.Lock()
+	s.follows[msg.RequestId] = cancel
+	s.mutex.Unlock()
+
+	go func() {
+		defer unsubscribe()
+		defer func() {
+			s.mutex.Lock()
+			delete(s.follows, msg.RequestId)
+			s.mutex.Unlock()
+			cancel()
+		}()
+		s.follow(ctx, msg, updates)
+	}()
+}
+
+// follow batches new records until the context ends
+func (s *Streamer) follow(ctx context.Context, req *pb.Message, updates <-chan Record) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var pending []Record
+	for {
+		select {
+		case <-ctx.Done():
+			s.send(req, pending, true)
+			return
+		case record := <-updates:
+			pending = append(pending, record)
+			if len(pending) >= batchSize {
+				s.send(req, pending, false)
+				pending = nil
+			}
+		case <-ticker.C:
+			if len(pending) > 0 {
+				s.send(req, pending, false)
+				pending = nil
+			}
+			if !s.ws.IsConnected() {
+				// The server side of the tail is gone with the connection
+				return
+			}
+		}
+	}
+}
+
+func (s *Streamer) send(req *pb.Message, records []Record, done bool) {
+	batch := &pb.LogBatch{Done: done}
+	for _, record := range records {
+		batch.Records = append(batch.Records, &pb.LogRecord{
+			Time:    record.Time.UnixMilli(),
+			Level:   int32(record.Level),
+			Message: record.Message,
+			Attrs:   record.Attrs,
+		})
+	}
+
+	reply := rpc.Reply(req, "")
+	reply.DataType = &pb.Message_LogBatch{LogBatch: batch}
+	err := s.ws.SendMessage(reply)
+	if err != nil {
+		// Debug so a failing tail doesn't feed itself
+		slog.Debug("Failed to send log batch", "error", err)
+	}
+}
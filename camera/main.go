@@ -1,17 +1,82 @@
 package main
 
 import (
-	"gocv.io/x/gocv"
+	"camera/agent"
+	"camera/config"
+	"camera/setup"
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+// setupRetryDelay is the pause before setup starts again after it failed
+const setupRetryDelay = 5 * time.Second
+
 func main() {
-	webcam, _ := gocv.VideoCaptureDevice(0)
-	window := gocv.NewWindow("Hello")
-	img := gocv.NewMat()
-
-	for {
-		webcam.Read(&img)
-		window.IMShow(img)
-		window.WaitKey(1)
+	configPath := flag.String("config", "config.json", "Path of the camera config, written by setup")
+	dataDir := flag.String("data-dir", config.DefaultDataDir, "Directory of the pinned server key and other files kept across re-provisioning")
+	debug := flag.Bool("debug", false, "Read the setup code from stdin instead of scanning a QR code")
+	flag.Parse()
+
+	if err := config.SetDataDir(*dataDir); err != nil {
+		slog.Error("Failed to create data directory", "path", *dataDir, "error", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for ctx.Err() == nil {
+		cfg, err := config.LoadConfig(*configPath)
+		if errors.Is(err, os.ErrNotExist) {
+			provision(ctx, *configPath, *debug)
+			continue
+		}
+		if err != nil {
+			slog.Error("Failed to load config", "path", *configPath, "error", err)
+			os.Exit(1)
+		}
+
+		a, err := agent.New(cfg, *configPath)
+		if err != nil {
+			slog.Error("Failed to start agent", "error", err)
+			os.Exit(1)
+		}
+
+		// Restarts and re-provisioning start over from the config on disk,
+		// which is gone after the camera was revoked or reset
+		err = a.Run(ctx)
+		switch {
+		case errors.Is(err, agent.ErrRestart):
+			slog.Info("Restarting agent")
+		case errors.Is(err, config.ErrReprovision):
+			slog.Info("Camera must be provisioned again, starting setup")
+		case err != nil:
+			slog.Error("Agent stopped", "error", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// provision runs setup until the camera is registered and saves its config
+func provision(ctx context.Context, configPath string, debug bool) {
+	cfg := setup.RunSetup(debug)
+	if cfg == nil {
+		slog.Error("Setup failed, trying again", "delay", setupRetryDelay)
+		select {
+		case <-ctx.Done():
+		case <-time.After(setupRetryDelay):
+		}
+		return
+	}
+	if err := cfg.SaveConfig(configPath); err != nil {
+		slog.Error("Failed to save config", "path", configPath, "error", err)
+		os.Exit(1)
 	}
+	slog.Info("Camera registered", "camera_id", cfg.CameraUuid, "server", cfg.Addr)
 }
@@ -0,0 +1,230 @@
+package record
+
+import (
+	"bytes"
+	"camera/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"messages/jwtmsg"
+	"messages/msgspb"
+	"messages/recordcrypt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// backupInterval is how often finished segments are backed up
+	backupInterval = 30 * time.Second
+	// backupStateFile remembers which files the server already has
+	backupStateFile = "backup.json"
+	// backupTimeout bounds the upload of one file
+	backupTimeout = time.Minute
+)
+
+// Backup copies finished segments to the server so footage survives the
+// camera being stolen or broken. Encrypted sessions are uploaded sealed with
+// their wrapped key, the server never sees them in plain. A failed upload is
+// retried on the next pass, so nothing is lost while the server is away as
+// long as retention hasn't deleted the segment yet.
+type Backup struct {
+	recorder *Recorder
+	config   *config.Config
+	mode     func() msgspb.BackupMode
+	trigger  chan struct{}
+	client   *http.Client
+
+	mutex    sync.Mutex
+	uploaded map[string]map[string]bool // Files the server has, by session
+}
+
+// NewBackup creates a backup uploader, mode returns which recordings to back up
+func NewBackup(recorder *Recorder, cfg *config.Config, mode func() msgspb.BackupMode) *Backup {
+	b := &Backup{
+		recorder: recorder,
+		config:   cfg,
+		mode:     mode,
+		trigger:  make(chan struct{}, 1),
+		client:   &http.Client{Timeout: backupTimeout},
+		uploaded: make(map[string]map[string]bool),
+	}
+	if data, err := os.ReadFile(b.statePath()); err == nil {
+		if err := json.Unmarshal(data, &b.uploaded); err != nil {
+			slog.Warn("Failed to read backup state, uploading everything again", "error", err)
+			b.uploaded = make(map[string]map[string]bool)
+		}
+	}
+	return b
+}
+
+func (b *Backup) statePath() string {
+	return filepath.Join(b.recorder.root(), backupStateFile)
+}
+
+// Run backs up new segments every backupInterval and whenever Trigger is
+// called, until the context is cancelled
+func (b *Backup) Run(ctx context.Context) {
+	ticker := time.NewTicker(backupInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := b.Sync(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("Failed to back up recordings", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-b.trigger:
+		}
+	}
+}
+
+// Trigger asks Run to back up now, e.g. after reconnecting
+func (b *Backup) Trigger() {
+	select {
+	case b.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Sync uploads every indexed segment the mode selects that the server doesn't
+// have yet, oldest first. It stops at the first failure.
+func (b *Backup) Sync(ctx context.Context) error {
+	mode := b.mode()
+	if mode != msgspb.BackupMode_BACKUP_MODE_ALL && mode != msgspb.BackupMode_BACKUP_MODE_EVENTS {
+		return nil
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	index := b.recorder.index
+	if err := index.Sync(); err != nil {
+		return err
+	}
+	segments := index.Segments()
+
+	// Forget sessions retention deleted
+	sessions := make(map[string]bool)
+	for _, segment := range segments {
+		sessions[segment.Session] = true
+	}
+	changed := false
+	for session := range b.uploaded {
+		if !sessions[session] {
+			delete(b.uploaded, session)
+			changed = true
+		}
+	}
+
+	root := b.recorder.root()
+	var err error
+	uploaded := 0
+	for _, segment := range segments {
+		if mode == msgspb.BackupMode_BACKUP_MODE_EVENTS && !isEvent(segment.Trigger) {
+			continue
+		}
+		done := b.uploaded[segment.Session]
+		if done[segment.File] {
+			continue
+		}
+		if done == nil {
+			done = make(map[string]bool)
+			b.uploaded[segment.Session] = done
+		}
+
+		// The server needs the session key to play an encrypted segment
+		dir := filepath.Join(root, segment.Session)
+		if !done[recordcrypt.KeyFile] {
+			if _, statErr := os.Stat(filepath.Join(dir, recordcrypt.KeyFile)); statErr == nil {
+				if err = b.upload(ctx, segment.Session, recordcrypt.KeyFile, nil); err != nil {
+					break
+				}
+				done[recordcrypt.KeyFile] = true
+				changed = true
+			}
+		}
+
+		if err = b.upload(ctx, segment.Session, segment.File, &segment); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				// Deleted by retention since the index was read
+				err = nil
+				continue
+			}
+			break
+		}
+		done[segment.File] = true
+		changed = true
+		uploaded++
+	}
+
+	if uploaded > 0 {
+		slog.Info("Backed up recording segments", "count", uploaded)
+	}
+	if changed {
+		if saveErr := b.save(); saveErr != nil {
+			slog.Warn("Failed to save backup state", "error", saveErr)
+		}
+	}
+	return err
+}
+
+// isEvent reports whether a session was started by something happening
+// rather than a schedule
+func isEvent(trigger msgspb.RecordingTrigger) bool {
+	return trigger == msgspb.RecordingTrigger_RECORDING_TRIGGER_MOTION ||
+		trigger == msgspb.RecordingTrigger_RECORDING_TRIGGER_MANUAL
+}
+
+// upload sends one file of a session, segments carry their index entry
+func (b *Backup) upload(ctx context.Context, session, file string, segment *Segment) error {
+	data, err := b.recorder.readFile(ctx, session+"/"+file)
+	if err != nil {
+		return err
+	}
+
+	target := b.config.Addr + jwtmsg.BackupPath(b.config.CameraUuid, session+"/"+file)
+	if segment != nil {
+		query := url.Values{}
+		query.Set(jwtmsg.BackupStartParam, strconv.FormatInt(segment.Start, 10))
+		query.Set(jwtmsg.BackupDurationParam, strconv.FormatFloat(segment.Duration, 'f', -1, 64))
+		query.Set(jwtmsg.BackupTriggerParam, strconv.Itoa(int(segment.Trigger)))
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", b.config.AuthToken())
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Conflict: backed up before, by an upload whose answer was lost
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusConflict {
+		return fmt.Errorf("failed to upload %s/%s: unexpected status code: %d %s", session, file, resp.StatusCode, resp.Status)
+	}
+	return nil
+}
+
+func (b *Backup) save() error {
+	data, err := json.Marshal(b.uploaded)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(b.statePath(), data)
+}
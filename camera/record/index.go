@@ -0,0 +1,469 @@
+package record
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"messages/msgspb"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// IndexFile is the append-only recording index kept next to the sessions
+	IndexFile = "index.jsonl"
+	// playlistFile is the HLS playlist ffmpeg writes in every session directory
+	playlistFile = "index.m3u8"
+	// sessionMetaFile records what started a session
+	sessionMetaFile = "session.json"
+	// sessionLayout names session directories after their local start time
+	sessionLayout = "2006-01-02_15-04-05"
+)
+
+// programDateTimeLayouts are the EXT-X-PROGRAM-DATE-TIME formats we accept,
+// ffmpeg writes the first one
+var programDateTimeLayouts = []string{
+	"2006-01-02T15:04:05.000-0700",
+	time.RFC3339Nano,
+}
+
+// Segment is one finished HLS segment in the index
+type Segment struct {
+	Session  string                  `json:"session"`
+	File     string                  `json:"file"`
+	Start    int64                   `json:"start"`    // Unix milliseconds
+	Duration float64                 `json:"duration"` // Seconds
+	Size     int64                   `json:"size"`
+	Trigger  msgspb.RecordingTrigger `json:"trigger"`
+}
+
+// End returns the Unix milliseconds where the segment ends
+func (s Segment) End() int64 {
+	return s.Start + int64(s.Duration*1000)
+}
+
+// Recording summarises the indexed segments of one session
+type Recording struct {
+	Session  string
+	Start    int64
+	End      int64
+	Duration float64
+	Size     int64
+	Segments int
+	Trigger  msgspb.RecordingTrigger
+}
+
+// VideoRange converts the recording for a RecordResponse
+func (r Recording) VideoRange() *msgspb.VideoRange {
+	return &msgspb.VideoRange{
+		FileName:  r.Session,
+		StartTime: r.Start,
+		EndTime:   r.End,
+		Duration:  r.Duration,
+		Size:      r.Size,
+		Segments:  int32(r.Segments),
+		Trigger:   r.Trigger,
+	}
+}
+
+type sessionMeta struct {
+	Trigger msgspb.RecordingTrigger `json:"trigger"`
+	Started int64                   `json:"started"` // Unix milliseconds
+}
+
+// Index is a persistent index of recorded segments. Segments are appended to
+// a JSON lines file once ffmpeg lists them in a session playlist, so queries
+// get real start and end times without parsing every playlist, and the index
+// survives restarts. Sessions recorded before the index existed are picked up
+// by the first Sync.
+type Index struct {
+	root     string
+	mutex    sync.Mutex
+	file     *os.File
+	segments map[string][]Segment // Keyed by session, in playlist order
+	scanned  map[string]time.Time // Playlist modification time at the last scan
+}
+
+// OpenIndex loads the index of the sessions under root
+func OpenIndex(root string) (*Index, error) {
+	x := &Index{
+		root:     root,
+		segments: make(map[string][]Segment),
+		scanned:  make(map[string]time.Time),
+	}
+	if err := x.load(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// load reads the index file and opens it for appending. A torn last line from
+// a power cut is dropped and the file rewritten without it.
+func (x *Index) load() error {
+	path := filepath.Join(x.root, IndexFile)
+	corrupt := false
+
+	f, err := os.Open(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to open recording index: %w", err)
+	}
+	if err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var segment Segment
+			if err := json.Unmarshal(scanner.Bytes(), &segment); err != nil || segment.Session == "" {
+				corrupt = true
+				continue
+			}
+			x.segments[segment.Session] = append(x.segments[segment.Session], segment)
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read recording index: %w", err)
+		}
+	}
+
+	if corrupt {
+		slog.Warn("Dropping corrupt recording index entries", "path", path)
+		return x.rewrite()
+	}
+
+	x.file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open recording index: %w", err)
+	}
+	return nil
+}
+
+// rewrite replaces the index file with the in-memory segments
+func (x *Index) rewrite() error {
+	if x.file != nil {
+		x.file.Close()
+		x.file = nil
+	}
+
+	path := filepath.Join(x.root, IndexFile)
+	tmp, err := os.CreateTemp(x.root, IndexFile+".*")
+	if err != nil {
+		return fmt.Errorf("failed to rewrite recording index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, segments := range x.segments {
+		for _, segment := range segments {
+			if err := encoder.Encode(segment); err != nil {
+				tmp.Close()
+				return fmt.Errorf("failed to rewrite recording index: %w", err)
+			}
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to rewrite recording index: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to rewrite recording index: %w", err)
+	}
+	tmp.Close()
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to rewrite recording index: %w", err)
+	}
+
+	x.file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open recording index: %w", err)
+	}
+	return nil
+}
+
+// append writes new segments to the end of the index file
+func (x *Index) append(segments []Segment) error {
+	if len(segments) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	for _, segment := range segments {
+		if err := encoder.Encode(segment); err != nil {
+			return fmt.Errorf("failed to encode index entry: %w", err)
+		}
+	}
+	if _, err := x.file.WriteString(b.String()); err != nil {
+		return fmt.Errorf("failed to append to recording index: %w", err)
+	}
+	return x.file.Sync()
+}
+
+// Close closes the index file, the index can't be used afterwards
+func (x *Index) Close() error {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	if x.file == nil {
+		return nil
+	}
+	err := x.file.Close()
+	x.file = nil
+	return err
+}
+
+// Sync indexes segments ffmpeg finished since the last call and forgets
+// sessions that were removed from disk
+func (x *Index) Sync() error {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	entries, err := os.ReadDir(x.root)
+	if err != nil {
+		return fmt.Errorf("failed to list recordings: %w", err)
+	}
+
+	onDisk := make(map[string]bool)
+	var added []Segment
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		session := entry.Name()
+		onDisk[session] = true
+
+		info, err := os.Stat(filepath.Join(x.root, session, playlistFile))
+		if err != nil {
+			continue // No segment finished yet
+		}
+		if scanned, ok := x.scanned[session]; ok && scanned.Equal(info.ModTime()) {
+			continue
+		}
+
+		segments, err := x.scanSession(session)
+		if err != nil {
+			slog.Warn("Failed to index recording session", "session", session, "error", err)
+			continue
+		}
+		x.scanned[session] = info.ModTime()
+
+		known := make(map[string]bool, len(x.segments[session]))
+		for _, segment := range x.segments[session] {
+			known[segment.File] = true
+		}
+		for _, segment := range segments {
+			if !known[segment.File] {
+				added = append(added, segment)
+				x.segments[session] = append(x.segments[session], segment)
+			}
+		}
+	}
+
+	removed := false
+	for session := range x.segments {
+		if !onDisk[session] {
+			delete(x.segments, session)
+			delete(x.scanned, session)
+			removed = true
+		}
+	}
+	if removed {
+		return x.rewrite()
+	}
+
+	if len(added) > 0 {
+		slog.Debug("Indexed recording segments", "count", len(added))
+	}
+	return x.append(added)
+}
+
+// scanSession parses a session playlist into segments. Start times come from
+// EXT-X-PROGRAM-DATE-TIME tags when ffmpeg wrote them, otherwise from the
+// session start plus the durations of the segments before.
+func (x *Index) scanSession(session string) ([]Segment, error) {
+	dir := filepath.Join(x.root, session)
+	meta := readSessionMeta(dir, session)
+
+	f, err := os.Open(filepath.Join(dir, playlistFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var segments []Segment
+	clock := meta.Started
+	duration := 0.0
+	pending := false
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-PROGRAM-DATE-TIME:"):
+			if t, ok := parseProgramDateTime(strings.TrimPrefix(line, "#EXT-X-PROGRAM-DATE-TIME:")); ok {
+				clock = t.UnixMilli()
+			}
+		case strings.HasPrefix(line, "#EXTINF:"):
+			value, _, _ := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+			duration, err = strconv.ParseFloat(value, 64)
+			pending = err == nil
+		case line == "" || strings.HasPrefix(line, "#"):
+		default:
+			if !pending {
+				continue
+			}
+			var size int64
+			if info, err := os.Stat(filepath.Join(dir, line)); err == nil {
+				size = info.Size()
+			}
+			segment := Segment{
+				Session:  session,
+				File:     line,
+				Start:    clock,
+				Duration: duration,
+				Size:     size,
+				Trigger:  meta.Trigger,
+			}
+			segments = append(segments, segment)
+			clock = segment.End()
+			pending = false
+		}
+	}
+	return segments, scanner.Err()
+}
+
+// readSessionMeta reads what started a session, falling back to the start
+// time in the directory name for sessions recorded without one
+func readSessionMeta(dir string, session string) sessionMeta {
+	var meta sessionMeta
+	if data, err := os.ReadFile(filepath.Join(dir, sessionMetaFile)); err == nil {
+		json.Unmarshal(data, &meta)
+	}
+	if meta.Started == 0 {
+		if t, err := time.ParseInLocation(sessionLayout, session, time.Local); err == nil {
+			meta.Started = t.UnixMilli()
+		}
+	}
+	return meta
+}
+
+// writeSessionMeta records what started a new session
+func writeSessionMeta(dir string, meta sessionMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, sessionMetaFile), data, 0644)
+}
+
+func parseProgramDateTime(value string) (time.Time, bool) {
+	for _, layout := range programDateTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Query returns the recordings overlapping [start, end], newest first. Times
+// are Unix milliseconds and 0 leaves that end of the range open.
+func (x *Index) Query(start, end int64) []Recording {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	var recordings []Recording
+	for session, segments := range x.segments {
+		if len(segments) == 0 {
+			continue
+		}
+		recording := Recording{
+			Session:  session,
+			Start:    segments[0].Start,
+			Trigger:  segments[0].Trigger,
+			Segments: len(segments),
+		}
+		for _, segment := range segments {
+			recording.End = max(recording.End, segment.End())
+			recording.Duration += segment.Duration
+			recording.Size += segment.Size
+		}
+
+		if start > 0 && recording.End < start {
+			continue
+		}
+		if end > 0 && recording.Start > end {
+			continue
+		}
+		recordings = append(recordings, recording)
+	}
+
+	sort.Slice(recordings, func(i, j int) bool {
+		if recordings[i].Start != recordings[j].Start {
+			return recordings[i].Start > recordings[j].Start
+		}
+		return recordings[i].Session > recordings[j].Session
+	})
+	return recordings
+}
+
+// Segments returns every indexed segment, oldest first
+func (x *Index) Segments() []Segment {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	var segments []Segment
+	for _, session := range x.segments {
+		segments = append(segments, session...)
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].Start < segments[j].Start
+	})
+	return segments
+}
+
+// Remove drops segments of a session from the index, nil files drops the
+// whole session
+func (x *Index) Remove(session string, files []string) error {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	if files == nil {
+		delete(x.segments, session)
+		delete(x.scanned, session)
+		return x.rewrite()
+	}
+
+	removed := make(map[string]bool, len(files))
+	for _, file := range files {
+		removed[file] = true
+	}
+	kept := x.segments[session][:0]
+	for _, segment := range x.segments[session] {
+		if !removed[segment.File] {
+			kept = append(kept, segment)
+		}
+	}
+	x.segments[session] = kept
+	// The trimmed playlist has a new modification time, scan it again
+	delete(x.scanned, session)
+	return x.rewrite()
+}
+
+// Reset forgets every segment, used after the recordings were wiped
+func (x *Index) Reset() error {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	if err := os.MkdirAll(x.root, 0755); err != nil {
+		return fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	x.segments = make(map[string][]Segment)
+	x.scanned = make(map[string]time.Time)
+	return x.rewrite()
+}
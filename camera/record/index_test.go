@@ -0,0 +1,176 @@
+package record
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeSession writes a session playlist the way ffmpeg does, stamping the
+// first segment with start, and a segment file of 100 bytes per second
+func writeSession(t *testing.T, root, session string, start time.Time, durations ...float64) {
+	t.Helper()
+	dir := filepath.Join(root, session)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var playlist strings.Builder
+	playlist.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:4\n")
+	for i, duration := range durations {
+		if i == 0 {
+			fmt.Fprintf(&playlist, "#EXT-X-PROGRAM-DATE-TIME:%s\n", start.Format(programDateTimeLayouts[0]))
+		}
+		name := fmt.Sprintf("segment_%05d.ts", i)
+		fmt.Fprintf(&playlist, "#EXTINF:%f,\n%s\n", duration, name)
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, int(duration*100)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(dir, playlistFile)
+	if err := os.WriteFile(path, []byte(playlist.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Rewrites within the file system's timestamp granularity must be noticed
+	stamp := time.Now().Add(time.Duration(len(durations)) * time.Second)
+	if err := os.Chtimes(path, stamp, stamp); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func openTestIndex(t *testing.T, root string) *Index {
+	t.Helper()
+	x, err := OpenIndex(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { x.Close() })
+	return x
+}
+
+func indexLines(t *testing.T, root string) []string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(root, IndexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.SplitAfter(string(data), "\n")
+}
+
+func segmentFiles(segments []Segment) []string {
+	var files []string
+	for _, segment := range segments {
+		files = append(files, segment.Session+"/"+segment.File)
+	}
+	return files
+}
+
+func TestIndexAppend(t *testing.T) {
+	root := t.TempDir()
+	start := time.UnixMilli(1700000000000)
+	writeSession(t, root, "s1", start, 4, 4)
+
+	x := openTestIndex(t, root)
+	if err := x.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	segments := x.Segments()
+	if files := segmentFiles(segments); !slices.Equal(files, []string{"s1/segment_00000.ts", "s1/segment_00001.ts"}) {
+		t.Fatalf("indexed %v", files)
+	}
+	// Starts follow the program date time and the durations before
+	if segments[0].Start != start.UnixMilli() || segments[1].Start != start.UnixMilli()+4000 || segments[1].Size != 400 {
+		t.Fatalf("segments %+v", segments)
+	}
+	before := indexLines(t, root)
+
+	// ffmpeg finishes another segment, only it is appended
+	writeSession(t, root, "s1", start, 4, 4, 2)
+	if err := x.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	after := indexLines(t, root)
+	if len(after) != len(before)+1 || !slices.Equal(after[:len(before)-1], before[:len(before)-1]) {
+		t.Fatalf("index file went from %q to %q", before, after)
+	}
+
+	recordings := x.Query(0, 0)
+	if len(recordings) != 1 || recordings[0].Segments != 3 || recordings[0].End != start.UnixMilli()+10000 || recordings[0].Size != 1000 {
+		t.Fatalf("recordings %+v", recordings)
+	}
+}
+
+func TestIndexReload(t *testing.T) {
+	root := t.TempDir()
+	writeSession(t, root, "s1", time.UnixMilli(1700000000000), 4, 4)
+	writeSession(t, root, "s2", time.UnixMilli(1700000100000), 4)
+
+	x := openTestIndex(t, root)
+	if err := x.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	want := x.Segments()
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The reloaded index knows the segments without scanning playlists
+	reloaded := openTestIndex(t, root)
+	if got := reloaded.Segments(); !slices.Equal(got, want) {
+		t.Fatalf("reloaded %+v, want %+v", got, want)
+	}
+
+	// Sessions gone from disk are forgotten on the next sync
+	if err := os.RemoveAll(filepath.Join(root, "s1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloaded.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	reloaded.Close()
+	if files := segmentFiles(openTestIndex(t, root).Segments()); !slices.Equal(files, []string{"s2/segment_00000.ts"}) {
+		t.Fatalf("after removing s1: %v", files)
+	}
+}
+
+func TestIndexTruncatedLine(t *testing.T) {
+	root := t.TempDir()
+	writeSession(t, root, "s1", time.UnixMilli(1700000000000), 4, 4)
+
+	x := openTestIndex(t, root)
+	if err := x.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	x.Close()
+
+	// A power cut tore the last entry
+	f, err := os.OpenFile(filepath.Join(root, IndexFile), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"session":"s1","file":"segment_0`)
+	f.Close()
+
+	reloaded := openTestIndex(t, root)
+	if files := segmentFiles(reloaded.Segments()); len(files) != 2 {
+		t.Fatalf("reloaded %v, want the two whole entries", files)
+	}
+	// The file was rewritten without it, so appending starts on a fresh line
+	if lines := indexLines(t, root); len(lines) != 3 || lines[2] != "" {
+		t.Fatalf("index file %q after dropping the torn entry", lines)
+	}
+
+	writeSession(t, root, "s1", time.UnixMilli(1700000000000), 4, 4, 4)
+	if err := reloaded.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	reloaded.Close()
+	if files := segmentFiles(openTestIndex(t, root).Segments()); len(files) != 3 {
+		t.Fatalf("after appending: %v", files)
+	}
+}
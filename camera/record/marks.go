@@ -0,0 +1,449 @@
+package record
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"messages/msgspb"
+	"messages/rpc"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// marksFile keeps the locked ranges and bookmarks of a camera's recordings
+	marksFile = "marks.json"
+	// defaultBookmarkLimit and maxBookmarkLimit bound a bookmark search
+	defaultBookmarkLimit = 50
+	maxBookmarkLimit     = 500
+)
+
+// TimeRange is a span of Unix milliseconds, both ends included
+type TimeRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// overlaps reports whether the range overlaps [start, end)
+func (t TimeRange) overlaps(start, end int64) bool {
+	return t.Start < end && t.End >= start
+}
+
+// Bookmark is a named moment or span of the recordings
+type Bookmark struct {
+	ID      string `json:"id"`
+	Start   int64  `json:"start"`         // Unix milliseconds
+	End     int64  `json:"end,omitempty"` // 0 for a moment
+	Name    string `json:"name"`
+	Note    string `json:"note,omitempty"`
+	Created int64  `json:"created"` // Unix milliseconds
+}
+
+// span returns the time the bookmark covers
+func (b Bookmark) span() TimeRange {
+	return TimeRange{Start: b.Start, End: max(b.End, b.Start)}
+}
+
+// Proto converts the bookmark for a BookmarkList
+func (b Bookmark) Proto() *msgspb.Bookmark {
+	return &msgspb.Bookmark{
+		Id:        b.ID,
+		StartTime: b.Start,
+		EndTime:   b.End,
+		Name:      b.Name,
+		Note:      b.Note,
+		CreatedAt: b.Created,
+	}
+}
+
+func bookmarkFromProto(b *msgspb.Bookmark) Bookmark {
+	return Bookmark{
+		ID:      b.Id,
+		Start:   b.StartTime,
+		End:     b.EndTime,
+		Name:    b.Name,
+		Note:    b.Note,
+		Created: b.CreatedAt,
+	}
+}
+
+// Marks are the locked ranges and bookmarks the user put on the recordings.
+// Retention keeps every segment overlapping either, and locked segments can't
+// be deleted. They are kept by time rather than by segment so they also cover
+// footage recorded after they were made.
+type Marks struct {
+	path string
+
+	mutex     sync.Mutex
+	locks     []TimeRange // Sorted and merged
+	bookmarks []Bookmark
+}
+
+type marksState struct {
+	Locks     []TimeRange `json:"locks"`
+	Bookmarks []Bookmark  `json:"bookmarks"`
+}
+
+// OpenMarks loads the marks of the recordings under root
+func OpenMarks(root string) (*Marks, error) {
+	m := &Marks{path: filepath.Join(root, marksFile)}
+	data, err := os.ReadFile(m.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state marksState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to read recording marks: %w", err)
+	}
+	m.locks = mergeRanges(state.Locks)
+	m.bookmarks = state.Bookmarks
+	return m, nil
+}
+
+func (m *Marks) save() error {
+	data, err := json.Marshal(marksState{Locks: m.locks, Bookmarks: m.bookmarks})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(m.path, data)
+}
+
+// Lock adds a locked range
+func (m *Marks) Lock(lock TimeRange) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.locks = mergeRanges(append(m.locks, lock))
+	return m.save()
+}
+
+// Unlock removes a range from the locked ones, splitting those it cuts
+func (m *Marks) Unlock(unlock TimeRange) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var locks []TimeRange
+	for _, lock := range m.locks {
+		if lock.End < unlock.Start || lock.Start > unlock.End {
+			locks = append(locks, lock)
+			continue
+		}
+		if lock.Start < unlock.Start {
+			locks = append(locks, TimeRange{Start: lock.Start, End: unlock.Start - 1})
+		}
+		if lock.End > unlock.End {
+			locks = append(locks, TimeRange{Start: unlock.End + 1, End: lock.End})
+		}
+	}
+	m.locks = locks
+	return m.save()
+}
+
+// Locked reports whether a locked range overlaps [start, end)
+func (m *Marks) Locked(start, end int64) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.locked(start, end)
+}
+
+func (m *Marks) locked(start, end int64) bool {
+	for _, lock := range m.locks {
+		if lock.overlaps(start, end) {
+			return true
+		}
+	}
+	return false
+}
+
+// Protects reports whether retention must keep [start, end), it overlaps a
+// locked range or a bookmark
+func (m *Marks) Protects(start, end int64) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.locked(start, end) {
+		return true
+	}
+	for _, bookmark := range m.bookmarks {
+		if bookmark.span().overlaps(start, end) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetBookmark adds a bookmark or replaces the one with the same ID, keeping
+// its creation time when the new one has none. It returns the bookmark stored.
+func (m *Marks) SetBookmark(bookmark Bookmark) (Bookmark, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i := range m.bookmarks {
+		if m.bookmarks[i].ID == bookmark.ID {
+			if bookmark.Created == 0 {
+				bookmark.Created = m.bookmarks[i].Created
+			}
+			m.bookmarks[i] = bookmark
+			return bookmark, m.save()
+		}
+	}
+	if bookmark.Created == 0 {
+		bookmark.Created = time.Now().UnixMilli()
+	}
+	m.bookmarks = append(m.bookmarks, bookmark)
+	return bookmark, m.save()
+}
+
+// DeleteBookmark removes a bookmark, false when there is none with the ID
+func (m *Marks) DeleteBookmark(id string) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i := range m.bookmarks {
+		if m.bookmarks[i].ID == id {
+			m.bookmarks = append(m.bookmarks[:i], m.bookmarks[i+1:]...)
+			return true, m.save()
+		}
+	}
+	return false, nil
+}
+
+// DropBookmarks removes the bookmarks lying entirely within a range whose
+// recordings were deleted, except those on locked footage which was kept
+func (m *Marks) DropBookmarks(deleted TimeRange) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	kept := m.bookmarks[:0]
+	for _, bookmark := range m.bookmarks {
+		span := bookmark.span()
+		if span.Start < deleted.Start || span.End > deleted.End || m.locked(span.Start, span.End+1) {
+			kept = append(kept, bookmark)
+		}
+	}
+	if len(kept) == len(m.bookmarks) {
+		return nil
+	}
+	m.bookmarks = kept
+	return m.save()
+}
+
+// Search returns the bookmarks matching a query, newest first. Every word of
+// the text must appear in the name or note, ignoring case.
+func (m *Marks) Search(query *msgspb.BookmarkQuery) []Bookmark {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	words := strings.Fields(strings.ToLower(query.Text))
+	var found []Bookmark
+	for _, bookmark := range m.bookmarks {
+		span := bookmark.span()
+		if query.StartTime > 0 && span.End < query.StartTime {
+			continue
+		}
+		if query.EndTime > 0 && span.Start > query.EndTime {
+			continue
+		}
+		text := strings.ToLower(bookmark.Name + "\n" + bookmark.Note)
+		matches := true
+		for _, word := range words {
+			if !strings.Contains(text, word) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			found = append(found, bookmark)
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Start != found[j].Start {
+			return found[i].Start > found[j].Start
+		}
+		return found[i].ID < found[j].ID
+	})
+	limit := int(query.Limit)
+	if limit <= 0 || limit > maxBookmarkLimit {
+		limit = defaultBookmarkLimit
+	}
+	return found[:min(limit, len(found))]
+}
+
+// Reset forgets every mark, used after the recordings were wiped
+func (m *Marks) Reset() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.locks = nil
+	m.bookmarks = nil
+	if err := os.Remove(m.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// mergeRanges sorts ranges and joins the overlapping or adjacent ones
+func mergeRanges(ranges []TimeRange) []TimeRange {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start < ranges[j].Start
+	})
+	var merged []TimeRange
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r.Start <= merged[n-1].End+1 {
+			merged[n-1].End = max(merged[n-1].End, r.End)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// requestRange validates the time range of a lock or delete request
+func requestRange(start, end int64) (TimeRange, error) {
+	if start <= 0 || end <= 0 {
+		return TimeRange{}, rpc.Errorf(msgspb.ErrorCode_ERROR_CODE_INVALID_ARGUMENT, "start and end are required")
+	}
+	if end < start {
+		return TimeRange{}, rpc.Errorf(msgspb.ErrorCode_ERROR_CODE_INVALID_ARGUMENT, "end before start")
+	}
+	return TimeRange{Start: start, End: end}, nil
+}
+
+// segmentsIn returns the indexed segments overlapping a range, oldest first
+func (r *Recorder) segmentsIn(span TimeRange) []Segment {
+	if err := r.index.Sync(); err != nil {
+		slog.Warn("Failed to sync recording index", "error", err)
+	}
+	var segments []Segment
+	for _, segment := range r.index.Segments() {
+		if span.overlaps(segment.Start, segment.End()) {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// rangeResult lists segments for a RangeResult
+func rangeResult(segments []Segment, skipped int) *msgspb.RangeResult {
+	result := &msgspb.RangeResult{Skipped: int32(skipped)}
+	for _, segment := range segments {
+		result.Files = append(result.Files, segment.Session+"/"+segment.File)
+		result.Size += segment.Size
+	}
+	return result
+}
+
+// HandleLockRange locks or unlocks the recordings overlapping a time range
+func (r *Recorder) HandleLockRange(req *msgspb.Message) error {
+	msg := req.GetLockRangeRequest()
+	span, err := requestRange(msg.StartTime, msg.EndTime)
+	if err != nil {
+		return err
+	}
+	segments := r.segmentsIn(span)
+
+	if msg.Locked {
+		err = r.marks.Lock(span)
+	} else {
+		err = r.marks.Unlock(span)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save recording marks: %w", err)
+	}
+	slog.Info("Recordings lock changed", "start", span.Start, "end", span.End, "locked", msg.Locked, "segments", len(segments))
+
+	reply := rpc.Reply(req, r.cameraID)
+	reply.DataType = &msgspb.Message_RangeResult{RangeResult: rangeResult(segments, 0)}
+	return r.websocket.SendMessage(reply)
+}
+
+// HandleDeleteRange deletes the recordings overlapping a time range. Locked
+// segments and the session being recorded are kept and counted as skipped,
+// bookmarks within the range go with the footage.
+func (r *Recorder) HandleDeleteRange(req *msgspb.Message) error {
+	msg := req.GetDeleteRangeRequest()
+	span, err := requestRange(msg.StartTime, msg.EndTime)
+	if err != nil {
+		return err
+	}
+
+	active := r.ActiveSession()
+	var expired []Segment
+	skipped := 0
+	for _, segment := range r.segmentsIn(span) {
+		if segment.Session == active || r.marks.Locked(segment.Start, segment.End()) {
+			skipped++
+			continue
+		}
+		expired = append(expired, segment)
+	}
+
+	deleted, err := r.deleteSegments(expired)
+	if err != nil && len(deleted) == 0 {
+		return rpc.Errorf(msgspb.ErrorCode_ERROR_CODE_INTERNAL, "failed to delete recordings: %v", err)
+	}
+	if err != nil {
+		slog.Warn("Failed to delete some recordings", "error", err)
+	}
+	if err := r.marks.DropBookmarks(span); err != nil {
+		slog.Warn("Failed to drop bookmarks of deleted recordings", "error", err)
+	}
+	slog.Info("Deleted recordings", "start", span.Start, "end", span.End, "segments", len(deleted), "skipped", skipped)
+
+	reply := rpc.Reply(req, r.cameraID)
+	reply.DataType = &msgspb.Message_RangeResult{RangeResult: rangeResult(deleted, skipped+len(expired)-len(deleted))}
+	return r.websocket.SendMessage(reply)
+}
+
+// HandleBookmarkUpdate adds, replaces or deletes a bookmark and answers with
+// the bookmark as stored, nothing after a delete
+func (r *Recorder) HandleBookmarkUpdate(req *msgspb.Message) error {
+	msg := req.GetBookmarkUpdate()
+	if msg.Bookmark == nil || msg.Bookmark.Id == "" {
+		return rpc.Errorf(msgspb.ErrorCode_ERROR_CODE_INVALID_ARGUMENT, "bookmark id is required")
+	}
+
+	list := &msgspb.BookmarkList{}
+	if msg.Delete {
+		found, err := r.marks.DeleteBookmark(msg.Bookmark.Id)
+		if err != nil {
+			return fmt.Errorf("failed to save recording marks: %w", err)
+		}
+		if !found {
+			return rpc.Errorf(msgspb.ErrorCode_ERROR_CODE_NOT_FOUND, "no bookmark %s", msg.Bookmark.Id)
+		}
+	} else {
+		bookmark := bookmarkFromProto(msg.Bookmark)
+		if bookmark.Start <= 0 || bookmark.End != 0 && bookmark.End < bookmark.Start {
+			return rpc.Errorf(msgspb.ErrorCode_ERROR_CODE_INVALID_ARGUMENT, "invalid bookmark time")
+		}
+		bookmark, err := r.marks.SetBookmark(bookmark)
+		if err != nil {
+			return fmt.Errorf("failed to save recording marks: %w", err)
+		}
+		list.Bookmarks = []*msgspb.Bookmark{bookmark.Proto()}
+	}
+
+	reply := rpc.Reply(req, r.cameraID)
+	reply.DataType = &msgspb.Message_BookmarkList{BookmarkList: list}
+	return r.websocket.SendMessage(reply)
+}
+
+// HandleBookmarkQuery answers a bookmark search, newest first
+func (r *Recorder) HandleBookmarkQuery(req *msgspb.Message) error {
+	list := &msgspb.BookmarkList{}
+	for _, bookmark := range r.marks.Search(req.GetBookmarkQuery()) {
+		list.Bookmarks = append(list.Bookmarks, bookmark.Proto())
+	}
+
+	reply := rpc.Reply(req, r.cameraID)
+	reply.DataType = &msgspb.Message_BookmarkList{BookmarkList: list}
+	return r.websocket.SendMessage(reply)
+}
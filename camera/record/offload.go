@@ -0,0 +1,246 @@
+package record
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"messages/recordcrypt"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// offloadInterval is how often finished segments are moved to the store
+const offloadInterval = 15 * time.Second
+
+// Offloader moves finished segments from the record directory to the
+// recorder's segment store. While the store is unavailable recording goes on
+// into the record directory, which buffers the segments until the store is
+// back. The retention free space floor bounds that buffer.
+type Offloader struct {
+	recorder *Recorder
+	trigger  chan struct{}
+
+	mutex     sync.Mutex
+	available bool            // Result of the last health check
+	keys      map[string]bool // Sessions whose key was stored
+}
+
+// NewOffloader creates an offloader for the recorder's store
+func NewOffloader(recorder *Recorder) *Offloader {
+	return &Offloader{
+		recorder:  recorder,
+		trigger:   make(chan struct{}, 1),
+		available: true,
+		keys:      make(map[string]bool),
+	}
+}
+
+// Run moves segments every offloadInterval and whenever Trigger is called,
+// until the context is cancelled. It does nothing without a store.
+func (o *Offloader) Run(ctx context.Context) {
+	if o.recorder.store == nil {
+		return
+	}
+	slog.Info("Storing recordings", "store", o.recorder.store.String())
+
+	ticker := time.NewTicker(offloadInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := o.Sync(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("Failed to store recordings", "store", o.recorder.store.String(), "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-o.trigger:
+		}
+	}
+}
+
+// Trigger asks Run to move segments now
+func (o *Offloader) Trigger() {
+	select {
+	case o.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Available reports whether the store passed its last health check
+func (o *Offloader) Available() bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.recorder.store == nil || o.available
+}
+
+// Sync checks the store is healthy and moves every indexed segment still in
+// the record directory to it, oldest first
+func (o *Offloader) Sync(ctx context.Context) error {
+	store := o.recorder.store
+	if store == nil {
+		return nil
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if err := store.Check(ctx); err != nil {
+		if o.available {
+			slog.Warn("Recording storage unavailable, buffering recordings locally", "store", store.String(), "error", err)
+		}
+		o.available = false
+		return nil
+	}
+	if !o.available {
+		slog.Info("Recording storage available again, storing buffered recordings", "store", store.String())
+		o.available = true
+	}
+
+	index := o.recorder.index
+	if err := index.Sync(); err != nil {
+		return err
+	}
+
+	moved := 0
+	root := o.recorder.root()
+	for _, segment := range index.Segments() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// The key stays in the record directory too, it is tiny and keeps
+		// the session playable from either place
+		if !o.keys[segment.Session] {
+			key, err := os.ReadFile(filepath.Join(root, segment.Session, recordcrypt.KeyFile))
+			if err == nil {
+				err = store.Put(ctx, path.Join(segment.Session, recordcrypt.KeyFile), key)
+			}
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			o.keys[segment.Session] = true
+		}
+
+		ok, err := o.move(ctx, segment)
+		if err != nil {
+			return err
+		}
+		if ok {
+			moved++
+		}
+	}
+
+	if moved > 0 {
+		slog.Info("Stored recording segments", "store", store.String(), "count", moved)
+	}
+	return nil
+}
+
+// move stores one segment and removes it from the record directory, false
+// when it was already stored or deleted
+func (o *Offloader) move(ctx context.Context, segment Segment) (bool, error) {
+	o.recorder.filesLock.Lock()
+	defer o.recorder.filesLock.Unlock()
+
+	name := path.Join(segment.Session, segment.File)
+	local := filepath.Join(o.recorder.root(), segment.Session, segment.File)
+	data, err := os.ReadFile(local)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := o.recorder.store.Put(ctx, name, data); err != nil {
+		return false, err
+	}
+	if err := os.Remove(local); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// root returns the directory of this camera's recordings
+func (r *Recorder) root() string {
+	return filepath.Join(r.recordDir, r.cameraID)
+}
+
+// isLocal reports whether a segment is still in the record directory
+func (r *Recorder) isLocal(segment Segment) bool {
+	if r.store == nil {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(r.root(), segment.Session, segment.File))
+	return err == nil
+}
+
+// openFile opens a recording file from the record directory, or from the
+// store when it was moved there. name is <session>/<file> or a file at the
+// top of the recordings.
+func (r *Recorder) openFile(ctx context.Context, name string) (recordingFile, int64, error) {
+	file, err := os.Open(filepath.Join(r.root(), filepath.FromSlash(name)))
+	if err == nil {
+		info, err := file.Stat()
+		if err != nil || info.IsDir() {
+			file.Close()
+			return nil, 0, os.ErrNotExist
+		}
+		return file, info.Size(), nil
+	}
+	if !errors.Is(err, os.ErrNotExist) || r.store == nil {
+		return nil, 0, err
+	}
+
+	data, err := r.store.Get(ctx, name)
+	if err != nil {
+		return nil, 0, err
+	}
+	return storedFile{bytes.NewReader(data)}, int64(len(data)), nil
+}
+
+// readFile reads a whole recording file wherever it is
+func (r *Recorder) readFile(ctx context.Context, name string) ([]byte, error) {
+	file, size, err := r.openFile(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data := make([]byte, size)
+	if _, err := file.ReadAt(data, 0); err != nil && size > 0 {
+		return nil, err
+	}
+	return data, nil
+}
+
+// deleteStored removes files or whole sessions from the store, failures are
+// only logged as the files are gone from the index either way
+func (r *Recorder) deleteStored(names ...string) {
+	if r.store == nil {
+		return
+	}
+	for _, name := range names {
+		if err := r.store.Delete(context.Background(), name); err != nil {
+			slog.Warn("Failed to delete stored recording", "store", r.store.String(), "name", name, "error", err)
+		}
+	}
+}
+
+// recordingFile is an open recording file, on disk or read from the store
+type recordingFile interface {
+	ReadAt(p []byte, off int64) (int, error)
+	Close() error
+}
+
+// storedFile is a recording file read from the store into memory
+type storedFile struct {
+	*bytes.Reader
+}
+
+func (storedFile) Close() error { return nil }
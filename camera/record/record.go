@@ -4,14 +4,18 @@ import (
 	"camera/config"
 	"camera/websocket"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"messages/msgspb"
+	"messages/rpc"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -20,11 +24,35 @@ type Recorder struct {
 	cameraID  string
 	recordDir string
 	cmd       *exec.Cmd
-	active    bool
 	writer    io.WriteCloser
 	websocket *websocket.WebsocketManager
+	index     *Index
+	marks     *Marks
+	store     SegmentStore // Where finished segments are moved, nil keeps them in recordDir
+
+	stateLock sync.Mutex // Guards active and session
+	active    bool
+	session   string // Directory of the session being recorded
+
+	filesLock sync.Mutex // Held while segment files are moved or deleted
+
+	keyLock      sync.Mutex
+	recordingKey []byte // Owner's public key, new sessions are encrypted to it when set
+
+	transfersLock sync.Mutex
+	transfers     map[string]*transfer // Running file transfers by id
 }
 
+const (
+	// segmentSeconds is the target length of a recorded HLS segment
+	segmentSeconds = 4
+	// indexInterval is how often finished segments are indexed while recording
+	indexInterval = 30 * time.Second
+	// defaultRecordLimit and maxRecordLimit bound one page of a RecordResponse
+	defaultRecordLimit = 50
+	maxRecordLimit     = 500
+)
+
 // NewRecorder creates a new instance of Recorder
 func NewRecorder(cfg *config.Config) *Recorder {
 	// Ensure the record directory exists
@@ -40,10 +68,35 @@ func NewRecorder(cfg *config.Config) *Recorder {
 		return nil
 	}
 
+	index, err := OpenIndex(fullDir)
+	if err != nil {
+		slog.Error("Failed to open recording index", "error", err)
+		return nil
+	}
+	if err := index.Sync(); err != nil {
+		slog.Warn("Failed to sync recording index", "error", err)
+	}
+
+	marks, err := OpenMarks(fullDir)
+	if err != nil {
+		slog.Error("Failed to open recording marks", "error", err)
+		return nil
+	}
+
+	// A broken storage config must not stop recording, keep everything local
+	store, err := NewSegmentStore(cfg.Storage, cameraID)
+	if err != nil {
+		slog.Error("Invalid recording storage, keeping recordings in the record directory", "error", err)
+		store = nil
+	}
+
 	return &Recorder{
 		cameraID:  cameraID,
 		recordDir: recordDir,
-		active:    false,
+		index:     index,
+		marks:     marks,
+		store:     store,
+		transfers: make(map[string]*transfer),
 	}
 }
 
@@ -52,29 +105,76 @@ func (r *Recorder) SetWebsocketManager(ws *websocket.WebsocketManager) {
 	r.websocket = ws
 }
 
-// Start begins the recording process
-func (r *Recorder) Start(ctx context.Context) (io.Writer, error) {
-	if r.active {
+// RegisterHandlers registers the recorder's message handlers with the dispatcher
+func (r *Recorder) RegisterHandlers(d *websocket.Dispatcher) {
+	d.Register(&msgspb.Message_HlsRequest{}, r.HandleRequest)
+	d.Register(&msgspb.Message_HlsAck{}, func(msg *msgspb.Message) error {
+		return r.HandleAck(msg.GetHlsAck())
+	})
+	d.Register(&msgspb.Message_RecordRequest{}, r.HandleRecordRequest)
+	d.Register(&msgspb.Message_SegmentRequest{}, r.HandleSegmentRequest)
+	d.Register(&msgspb.Message_LockRangeRequest{}, r.HandleLockRange)
+	d.Register(&msgspb.Message_DeleteRangeRequest{}, r.HandleDeleteRange)
+	d.Register(&msgspb.Message_BookmarkUpdate{}, r.HandleBookmarkUpdate)
+	d.Register(&msgspb.Message_BookmarkQuery{}, r.HandleBookmarkQuery)
+}
+
+// SetRecordingKey sets the owner's recording public key, sessions started
+// afterwards are encrypted. nil records in plain.
+func (r *Recorder) SetRecordingKey(key []byte) {
+	r.keyLock.Lock()
+	defer r.keyLock.Unlock()
+	r.recordingKey = key
+}
+
+// Start begins the recording process, trigger is stored with the session so
+// the index can report why it was recorded
+func (r *Recorder) Start(ctx context.Context, trigger msgspb.RecordingTrigger) (io.Writer, error) {
+	if r.IsActive() {
 		return r.writer, nil
 	}
 
 	// Create subdirectory for current recording session
-	timestamp := time.Now().Format("2006-01-02_15-04-05")
-	sessionDir := filepath.Join(r.recordDir, r.cameraID, timestamp)
+	now := time.Now()
+	sessionDir := filepath.Join(r.recordDir, r.cameraID, now.Format(sessionLayout))
 	if err := os.MkdirAll(sessionDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create session directory: %w", err)
 	}
+	session := filepath.Base(sessionDir)
+	if err := writeSessionMeta(sessionDir, sessionMeta{Trigger: trigger, Started: now.UnixMilli()}); err != nil {
+		slog.Warn("Failed to write session metadata", "error", err)
+	}
 
-	sessionDir = filepath.Join(sessionDir, "index.m3u8")
+	// Encrypted sessions are written to staging and sealed into the session directory
+	r.keyLock.Lock()
+	recordingKey := r.recordingKey
+	r.keyLock.Unlock()
+
+	outputDir := sessionDir
+	var seal *sealer
+	if len(recordingKey) > 0 {
+		staging := filepath.Join(stagingRoot(), r.cameraID, session)
+		var err error
+		seal, err = newSealer(session, staging, sessionDir, recordingKey)
+		if err != nil {
+			return nil, err
+		}
+		outputDir = staging
+	}
 
-	// err := ffmpeg.Input("pipe:",
-	// 		ffmpeg.KwArgs{"f": "h264",
-	// 		}).
-	// 		Output("recordings/", ffmpeg.KwArgs{"pix_fmt": "yuv420p"}).
-	// 		OverWriteOutput().
-	// 		WithInput(w).
-	// 		Run()
-	// Setup ffmpeg command
+	// Setup ffmpeg command, program_date_time stamps every segment with its
+	// wall clock start for the index
+	r.cmd = exec.CommandContext(ctx, "ffmpeg",
+		"-f", "h264",
+		"-i", "pipe:0",
+		"-c", "copy",
+		"-f", "hls",
+		"-hls_time", strconv.Itoa(segmentSeconds),
+		"-hls_list_size", "0",
+		"-hls_flags", "program_date_time",
+		"-hls_segment_filename", filepath.Join(outputDir, "segment_%05d.ts"),
+		filepath.Join(outputDir, playlistFile),
+	)
 
 	// Log the command for debugging
 	slog.Info("Starting ffmpeg recording", "command", r.cmd.String())
@@ -82,6 +182,9 @@ func (r *Recorder) Start(ctx context.Context) (io.Writer, error) {
 	// Get the stdin pipe for ffmpeg
 	stdin, err := r.cmd.StdinPipe()
 	if err != nil {
+		if seal != nil {
+			seal.close()
+		}
 		return nil, fmt.Errorf("failed to get ffmpeg stdin pipe: %w", err)
 	}
 	r.writer = stdin
@@ -93,10 +196,43 @@ func (r *Recorder) Start(ctx context.Context) (io.Writer, error) {
 	// Start the ffmpeg process
 	if err := r.cmd.Start(); err != nil {
 		stdin.Close()
+		if seal != nil {
+			seal.close()
+		}
 		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
 	}
 
+	r.stateLock.Lock()
 	r.active = true
+	r.session = session
+	r.stateLock.Unlock()
+
+	done := make(chan struct{})
+	sealed := make(chan struct{})
+	if seal != nil {
+		go func() {
+			seal.run(done)
+			close(sealed)
+		}()
+	} else {
+		close(sealed)
+	}
+
+	// Index finished segments while recording so a crash loses little
+	go func() {
+		ticker := time.NewTicker(indexInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := r.index.Sync(); err != nil {
+					slog.Warn("Failed to sync recording index", "error", err)
+				}
+			}
+		}
+	}()
 
 	// Monitor the process in a goroutine
 	go func() {
@@ -105,56 +241,102 @@ func (r *Recorder) Start(ctx context.Context) (io.Writer, error) {
 		} else {
 			slog.Info("ffmpeg process completed successfully")
 		}
+		close(done)
+		<-sealed
+		r.stateLock.Lock()
 		r.active = false
+		r.stateLock.Unlock()
+		if err := r.index.Sync(); err != nil {
+			slog.Warn("Failed to sync recording index", "error", err)
+		}
 	}()
 
 	return r.writer, nil
 }
 
-func (r *Recorder) HandleRecordRequest(msg *msgspb.RecordRequest) error {
-
-	//list the directory under the cameraID
-	files, err := os.ReadDir(filepath.Join(r.recordDir, r.cameraID))
-	if err != nil {
-		return fmt.Errorf("failed to list directory: %w", err)
+// HandleRecordRequest answers a time range query from the recording index,
+// one page at a time, newest recordings first
+func (r *Recorder) HandleRecordRequest(req *msgspb.Message) error {
+	msg := req.GetRecordRequest()
+	if err := r.index.Sync(); err != nil {
+		slog.Warn("Failed to sync recording index", "error", err)
 	}
 
-	// Create a list of video ranges
-	var videoRanges []*msgspb.VideoRange
-	for _, file := range files {
-		if !file.IsDir() {
-			continue
-		}
-		videoRanges = append(videoRanges, &msgspb.VideoRange{
-			FileName:  file.Name(),
-			StartTime: 0,
-			EndTime:   0,
-		})
+	recordings := r.index.Query(msg.StartTime, msg.EndTime)
+	total := len(recordings)
+
+	offset := min(max(int(msg.Offset), 0), total)
+	limit := int(msg.Limit)
+	if limit <= 0 || limit > maxRecordLimit {
+		limit = defaultRecordLimit
 	}
+	page := recordings[offset:min(offset+limit, total)]
 
-	// Send the response back
+	videoRanges := make([]*msgspb.VideoRange, 0, len(page))
+	for _, recording := range page {
+		videoRange := recording.VideoRange()
+		videoRange.Locked = r.marks.Locked(recording.Start, recording.End)
+		videoRanges = append(videoRanges, videoRange)
+	}
 
-	return r.websocket.SendMessage(&msgspb.Message{
-		From: r.cameraID,
-		To:   "server",
-		DataType: &msgspb.Message_RecordResponse{
-			RecordResponse: &msgspb.RecordResponse{
+	nextOffset := 0
+	if offset+len(page) < total {
+		nextOffset = offset + len(page)
+	}
 
-				Id:      msg.Id,
-				Records: videoRanges,
-			},
+	reply := rpc.Reply(req, r.cameraID)
+	reply.DataType = &msgspb.Message_RecordResponse{
+		RecordResponse: &msgspb.RecordResponse{
+			Records:    videoRanges,
+			Total:      int32(total),
+			NextOffset: int32(nextOffset),
 		},
-	})
+	}
+	return r.websocket.SendMessage(reply)
+}
+
+// HandleSegmentRequest lists the indexed segments overlapping a time range,
+// oldest first
+func (r *Recorder) HandleSegmentRequest(req *msgspb.Message) error {
+	msg := req.GetSegmentRequest()
+	if msg.EndTime > 0 && msg.EndTime < msg.StartTime {
+		return rpc.Errorf(msgspb.ErrorCode_ERROR_CODE_INVALID_ARGUMENT, "end before start")
+	}
+	if err := r.index.Sync(); err != nil {
+		slog.Warn("Failed to sync recording index", "error", err)
+	}
 
+	var segments []*msgspb.SegmentInfo
+	for _, segment := range r.index.Segments() {
+		if segment.End() <= msg.StartTime || msg.EndTime > 0 && segment.Start >= msg.EndTime {
+			continue
+		}
+		segments = append(segments, &msgspb.SegmentInfo{
+			FileName:  segment.Session + "/" + segment.File,
+			StartTime: segment.Start,
+			Duration:  segment.Duration,
+			Size:      segment.Size,
+		})
+	}
+
+	reply := rpc.Reply(req, r.cameraID)
+	reply.DataType = &msgspb.Message_SegmentResponse{
+		SegmentResponse: &msgspb.SegmentResponse{Segments: segments},
+	}
+	return r.websocket.SendMessage(reply)
 }
 
-// HandleRequest processes an HLS file request and sends the file data back
-func (r *Recorder) HandleRequest(msg *msgspb.HLSRequest) error {
+// HandleRequest starts streaming the requested byte range of a recording file
+// in chunks, opening the file and the transfer run in the background so the
+// dispatcher isn't held. Requests that can't be served fail with the rpc error
+// code matching why.
+func (r *Recorder) HandleRequest(req *msgspb.Message) error {
+	msg := req.GetHlsRequest()
 	if r.websocket == nil {
 		return fmt.Errorf("websocket manager not set")
 	}
 
-	slog.Info("Handling HLS request", "filename", msg.FileName)
+	slog.Info("Handling HLS request", "filename", msg.FileName, "offset", msg.Offset, "length", msg.Length)
 
 	filename := filepath.Join(r.recordDir, r.cameraID, msg.FileName)
 
@@ -172,46 +354,57 @@ func (r *Recorder) HandleRequest(msg *msgspb.HLSRequest) error {
 	// Security check: ensure the file is inside the recordings directory
 	if !isSubPath(recordingRoot, absPath) {
 		slog.Warn("Security: Attempted path traversal", "requested_path", msg.FileName)
-		return fmt.Errorf("invalid file path - attempted path traversal")
+		return rpc.Errorf(msgspb.ErrorCode_ERROR_CODE_NOT_FOUND, "invalid file path - attempted path traversal")
 	}
 
-	// Check if the file exists
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		return fmt.Errorf("file not found: %s", filename)
-	}
-
-	// Open the file
-	file, err := os.Open(filename)
-	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
-	}
-	defer file.Close()
-
-	// Read all of the bytes
-	fileBytes, err := io.ReadAll(file)
+	name, err := filepath.Rel(recordingRoot, absPath)
 	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+		return fmt.Errorf("failed to resolve file name: %w", err)
 	}
 
-	slog.Info("Sending HLS response", "size_bytes", len(fileBytes))
+	// Registered before returning, a cancel sent while the file is still
+	// opening would otherwise find no transfer and be lost
+	t := r.startTransfer(req.RequestId)
 
-	// Send the response back
-	err = r.websocket.SendMessage(&msgspb.Message{
-		From: r.cameraID,
-		To:   "server",
-		DataType: &msgspb.Message_HlsResponse{
-			HlsResponse: &msgspb.HLSResponse{
-				FileName: msg.FileName,
-				Data:     fileBytes,
-			},
-		},
-	})
+	// Segments moved to the store may take a while to read back
+	go func() {
+		defer r.endTransfer(req.RequestId)
+		file, size, offset, length, err := r.openRange(filepath.ToSlash(name), msg)
+		if err != nil {
+			if err := r.websocket.SendMessage(rpc.ErrorReply(req, r.cameraID, err)); err != nil {
+				slog.Error("Failed to send HLS error", "filename", msg.FileName, "error", err)
+			}
+			return
+		}
+		r.streamFile(req, t, file, size, offset, length)
+	}()
+	return nil
+}
 
+// openRange opens a recording file and resolves the requested range, a
+// negative offset is a suffix
+func (r *Recorder) openRange(name string, msg *msgspb.HLSRequest) (file recordingFile, size, offset, length int64, err error) {
+	file, size, err = r.openFile(context.Background(), name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, 0, 0, rpc.Errorf(msgspb.ErrorCode_ERROR_CODE_NOT_FOUND, "file not found: %s", msg.FileName)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to send HLS response: %w", err)
+		return nil, 0, 0, 0, rpc.Errorf(msgspb.ErrorCode_ERROR_CODE_UNAVAILABLE, "failed to open file: %v", err)
 	}
 
-	return nil
+	offset = msg.Offset
+	if offset < 0 {
+		offset = max(size+offset, 0)
+	}
+	length = msg.Length
+	if length <= 0 || offset+length > size {
+		length = size - offset
+	}
+	if offset > size || offset == size && size > 0 {
+		file.Close()
+		return nil, 0, 0, 0, rpc.Errorf(msgspb.ErrorCode_ERROR_CODE_OUT_OF_RANGE, "range starts at %d beyond size %d", offset, size)
+	}
+	return file, size, offset, length, nil
 }
 
 // isSubPath checks if child is a subdirectory of parent
@@ -225,7 +418,7 @@ func isSubPath(parent, child string) bool {
 
 // Stop ends the current recording
 func (r *Recorder) Stop() error {
-	if !r.active {
+	if !r.IsActive() {
 		return nil
 	}
 
@@ -235,11 +428,57 @@ func (r *Recorder) Stop() error {
 	}
 
 	// Wait for the process to exit
+	r.stateLock.Lock()
 	r.active = false
+	r.stateLock.Unlock()
 	return nil
 }
 
+// Close stops recording, aborts running transfers and closes the index
+func (r *Recorder) Close() error {
+	if err := r.Stop(); err != nil {
+		slog.Error("Failed to stop recording", "error", err)
+	}
+
+	r.transfersLock.Lock()
+	for _, t := range r.transfers {
+		t.stop()
+	}
+	r.transfersLock.Unlock()
+
+	return r.index.Close()
+}
+
+// DeleteRecordings stops recording and removes every recording of this camera
+func (r *Recorder) DeleteRecordings() error {
+	if err := r.Stop(); err != nil {
+		slog.Error("Failed to stop recording", "error", err)
+	}
+	r.filesLock.Lock()
+	defer r.filesLock.Unlock()
+	if err := os.RemoveAll(r.root()); err != nil {
+		return err
+	}
+	r.deleteStored("")
+	if err := r.marks.Reset(); err != nil {
+		slog.Warn("Failed to reset recording marks", "error", err)
+	}
+	return r.index.Reset()
+}
+
+// ActiveSession returns the session being recorded, empty when idle
+func (r *Recorder) ActiveSession() string {
+	r.stateLock.Lock()
+	defer r.stateLock.Unlock()
+	if !r.active {
+		return ""
+	}
+	return r.session
+}
+
 // IsActive returns whether recording is currently active
 func (r *Recorder) IsActive() bool {
+	r.stateLock.Lock()
+	defer r.stateLock.Unlock()
 	return r.active
 }
@@ -0,0 +1,317 @@
+package record
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"messages/msgspb"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultMinFreeBytes is the free space floor when the policy sets none,
+	// below it ffmpeg and the config writes start failing
+	DefaultMinFreeBytes = 512 << 20
+	// retentionInterval is how often the policy is enforced
+	retentionInterval = time.Minute
+)
+
+// Usage is the recording storage as seen by the last retention pass
+type Usage struct {
+	RecordingsBytes int64
+	LockedBytes     int64
+	FreeBytes       int64
+	Oldest          int64 // Unix milliseconds of the oldest segment, 0 without recordings
+	DeletedBytes    int64 // Deleted by the retention policy since start
+}
+
+// Retention enforces the user's retention policy on the recorder's sessions.
+// It deletes the oldest segments first and never touches segments the user
+// locked or bookmarked, or the session being recorded.
+type Retention struct {
+	recorder *Recorder
+	policy   func() *msgspb.RetentionPolicy
+	trigger  chan struct{}
+
+	mutex sync.Mutex
+	usage Usage
+}
+
+// NewRetention creates a retention manager, policy returns the current policy
+func NewRetention(recorder *Recorder, policy func() *msgspb.RetentionPolicy) *Retention {
+	return &Retention{
+		recorder: recorder,
+		policy:   policy,
+		trigger:  make(chan struct{}, 1),
+	}
+}
+
+// Run enforces the policy every retentionInterval and whenever Trigger is
+// called, until the context is cancelled
+func (r *Retention) Run(ctx context.Context) {
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Enforce(); err != nil {
+			slog.Error("Failed to enforce retention policy", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.trigger:
+		}
+	}
+}
+
+// Trigger asks Run to enforce the policy now, e.g. after it changed
+func (r *Retention) Trigger() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Usage returns the storage usage measured by the last pass
+func (r *Retention) Usage() Usage {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.usage
+}
+
+// Enforce deletes segments until the recordings are within the policy
+func (r *Retention) Enforce() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	index := r.recorder.index
+	if err := index.Sync(); err != nil {
+		return err
+	}
+
+	policy := r.policy()
+	if policy == nil {
+		policy = &msgspb.RetentionPolicy{}
+	}
+	minFree := policy.MinFreeBytes
+	if minFree <= 0 {
+		minFree = DefaultMinFreeBytes
+	}
+
+	root := filepath.Join(r.recorder.recordDir, r.recorder.cameraID)
+	active := r.recorder.ActiveSession()
+
+	usage := Usage{DeletedBytes: r.usage.DeletedBytes}
+	var candidates []Segment
+	for _, segment := range index.Segments() {
+		usage.RecordingsBytes += segment.Size
+		if r.recorder.marks.Protects(segment.Start, segment.End()) {
+			usage.LockedBytes += segment.Size
+			continue
+		}
+		if segment.Session != active {
+			candidates = append(candidates, segment)
+		}
+	}
+
+	var fsStats syscall.Statfs_t
+	if err := syscall.Statfs(root, &fsStats); err != nil {
+		return fmt.Errorf("failed to stat recordings filesystem: %w", err)
+	}
+	usage.FreeBytes = int64(fsStats.Bavail) * int64(fsStats.Bsize)
+
+	// Candidates are oldest first, keep taking from the front while a limit is
+	// exceeded. Only segments still in the record directory free space there,
+	// the rest were moved to the segment store.
+	var expired []Segment
+	cutoff := int64(0)
+	if policy.MaxAgeDays > 0 {
+		cutoff = time.Now().AddDate(0, 0, -int(policy.MaxAgeDays)).UnixMilli()
+	}
+	for _, segment := range candidates {
+		local := r.recorder.isLocal(segment)
+		tooOld := cutoff > 0 && segment.End() < cutoff
+		tooBig := policy.MaxBytes > 0 && usage.RecordingsBytes > policy.MaxBytes
+		tooFull := usage.FreeBytes < minFree
+		if !tooOld && !tooBig && (!tooFull || !local) {
+			if tooFull {
+				continue // Look for newer segments still in the record directory
+			}
+			break
+		}
+		expired = append(expired, segment)
+		usage.RecordingsBytes -= segment.Size
+		if local {
+			usage.FreeBytes += segment.Size
+		}
+	}
+
+	deleted, err := r.recorder.deleteSegments(expired)
+	for _, segment := range deleted {
+		usage.DeletedBytes += segment.Size
+	}
+
+	if policy.MaxBytes > 0 && usage.RecordingsBytes > policy.MaxBytes || usage.FreeBytes < minFree {
+		slog.Warn("Recordings exceed the retention policy, the rest is locked or being recorded",
+			"recordings_bytes", usage.RecordingsBytes, "locked_bytes", usage.LockedBytes, "free_bytes", usage.FreeBytes)
+	}
+
+	if segments := index.Segments(); len(segments) > 0 {
+		usage.Oldest = segments[0].Start
+	}
+	r.usage = usage
+	return err
+}
+
+// deleteSegments removes segments, whole sessions when nothing of them is
+// left and otherwise the segment files with their playlist entries. It
+// returns the segments deleted, ones already gone are skipped.
+func (r *Recorder) deleteSegments(segments []Segment) ([]Segment, error) {
+	if len(segments) == 0 {
+		return nil, nil
+	}
+
+	r.filesLock.Lock()
+	defer r.filesLock.Unlock()
+
+	index := r.index
+	indexed := make(map[string]bool)
+	remaining := make(map[string]int)
+	for _, segment := range index.Segments() {
+		indexed[segment.Session+"/"+segment.File] = true
+		remaining[segment.Session]++
+	}
+
+	bySession := make(map[string][]Segment)
+	for _, segment := range segments {
+		if indexed[segment.Session+"/"+segment.File] {
+			bySession[segment.Session] = append(bySession[segment.Session], segment)
+		}
+	}
+
+	root := r.root()
+	var deleted []Segment
+	var errs []error
+	for session, segments := range bySession {
+		dir := filepath.Join(root, session)
+
+		var size int64
+		files := make([]string, 0, len(segments))
+		for _, segment := range segments {
+			size += segment.Size
+			files = append(files, segment.File)
+		}
+
+		if len(segments) >= remaining[session] {
+			if err := os.RemoveAll(dir); err != nil {
+				slog.Error("Failed to delete recording session", "session", session, "error", err)
+				errs = append(errs, err)
+				continue
+			}
+			if err := index.Remove(session, nil); err != nil {
+				errs = append(errs, err)
+			}
+			r.deleteStored(session)
+			slog.Info("Deleted recording session", "session", session, "bytes", size)
+			deleted = append(deleted, segments...)
+			continue
+		}
+
+		// Drop the entries first so a crash never leaves the playlist pointing at missing files
+		if err := trimPlaylist(filepath.Join(dir, playlistFile), files); err != nil {
+			slog.Error("Failed to trim recording playlist", "session", session, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		stored := make([]string, 0, len(files))
+		for _, file := range files {
+			err := os.Remove(filepath.Join(dir, file))
+			if os.IsNotExist(err) {
+				stored = append(stored, session+"/"+file)
+			} else if err != nil {
+				slog.Error("Failed to delete recording segment", "session", session, "file", file, "error", err)
+			}
+		}
+		r.deleteStored(stored...)
+		if err := index.Remove(session, files); err != nil {
+			errs = append(errs, err)
+		}
+		slog.Info("Deleted recording segments", "session", session, "count", len(files), "bytes", size)
+		deleted = append(deleted, segments...)
+	}
+	return deleted, errors.Join(errs...)
+}
+
+// trimPlaylist rewrites an HLS playlist without the given segments, moving
+// the media sequence forward by the number of leading segments removed
+func trimPlaylist(path string, files []string) error {
+	removed := make(map[string]bool, len(files))
+	for _, file := range files {
+		removed[file] = true
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	var header, body []string
+	var pending []string
+	sequence, sequenceLine := 0, -1
+	leading, kept := 0, false
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"):
+			sequence, _ = strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"))
+			sequenceLine = len(header)
+			header = append(header, line)
+		case strings.HasPrefix(line, "#EXTINF:"),
+			strings.HasPrefix(line, "#EXT-X-PROGRAM-DATE-TIME:"),
+			strings.HasPrefix(line, "#EXT-X-DISCONTINUITY"),
+			strings.HasPrefix(line, "#EXT-X-BYTERANGE:"):
+			// Tags that belong to the next segment
+			pending = append(pending, line)
+		case line == "":
+		case strings.HasPrefix(line, "#"):
+			if len(body) == 0 && len(pending) == 0 && !kept {
+				header = append(header, line)
+			} else {
+				body = append(body, line)
+			}
+		default:
+			if removed[line] {
+				if !kept {
+					leading++
+				}
+			} else {
+				kept = true
+				body = append(body, pending...)
+				body = append(body, line)
+			}
+			pending = nil
+		}
+	}
+	f.Close()
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if sequenceLine >= 0 {
+		header[sequenceLine] = fmt.Sprintf("#EXT-X-MEDIA-SEQUENCE:%d", sequence+leading)
+	}
+
+	content := strings.Join(append(header, body...), "\n") + "\n"
+	return writeFileAtomic(path, []byte(content))
+}
@@ -0,0 +1,153 @@
+package record
+
+import (
+	"messages/msgspb"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// newTestRecorder returns an idle recorder over three sessions of two 4 s,
+// 400 byte segments: s1 ten days old, s2 five days old and s3 an hour old
+func newTestRecorder(t *testing.T) (*Recorder, map[string]time.Time) {
+	t.Helper()
+	r := &Recorder{
+		cameraID:  "cam",
+		recordDir: t.TempDir(),
+		transfers: make(map[string]*transfer),
+	}
+
+	now := time.Now()
+	starts := map[string]time.Time{
+		"s1": now.AddDate(0, 0, -10),
+		"s2": now.AddDate(0, 0, -5),
+		"s3": now.Add(-time.Hour),
+	}
+	for session, start := range starts {
+		writeSession(t, r.root(), session, start, 4, 4)
+	}
+
+	var err error
+	if r.index, err = OpenIndex(r.root()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.index.Close() })
+	if r.marks, err = OpenMarks(r.root()); err != nil {
+		t.Fatal(err)
+	}
+	return r, starts
+}
+
+func TestRetention(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		policy   *msgspb.RetentionPolicy
+		lock     string // Session to lock
+		bookmark string // Session to bookmark
+		active   string // Session being recorded
+		kept     []string
+		locked   int64
+	}{
+		{
+			name:   "nothing over the limits",
+			policy: &msgspb.RetentionPolicy{MaxAgeDays: 30, MaxBytes: 2400},
+			kept:   []string{"s1/segment_00000.ts", "s1/segment_00001.ts", "s2/segment_00000.ts", "s2/segment_00001.ts", "s3/segment_00000.ts", "s3/segment_00001.ts"},
+		},
+		{
+			name:   "older than the max age",
+			policy: &msgspb.RetentionPolicy{MaxAgeDays: 7},
+			kept:   []string{"s2/segment_00000.ts", "s2/segment_00001.ts", "s3/segment_00000.ts", "s3/segment_00001.ts"},
+		},
+		{
+			name:   "oldest segments over the max size",
+			policy: &msgspb.RetentionPolicy{MaxBytes: 1500},
+			kept:   []string{"s2/segment_00001.ts", "s3/segment_00000.ts", "s3/segment_00001.ts"},
+		},
+		{
+			name:   "locked segments are kept",
+			policy: &msgspb.RetentionPolicy{MaxAgeDays: 7, MaxBytes: 1500},
+			lock:   "s1",
+			kept:   []string{"s1/segment_00000.ts", "s1/segment_00001.ts", "s3/segment_00001.ts"},
+			locked: 800,
+		},
+		{
+			name:     "bookmarked segments are kept",
+			policy:   &msgspb.RetentionPolicy{MaxAgeDays: 7},
+			bookmark: "s1",
+			kept:     []string{"s1/segment_00000.ts", "s2/segment_00000.ts", "s2/segment_00001.ts", "s3/segment_00000.ts", "s3/segment_00001.ts"},
+			locked:   400,
+		},
+		{
+			name:   "the session being recorded is kept",
+			policy: &msgspb.RetentionPolicy{MaxBytes: 400},
+			active: "s3",
+			kept:   []string{"s3/segment_00000.ts", "s3/segment_00001.ts"},
+		},
+	} {
+		r, starts := newTestRecorder(t)
+		if test.lock != "" {
+			start := starts[test.lock].UnixMilli()
+			if err := r.marks.Lock(TimeRange{Start: start, End: start + 8000}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if test.bookmark != "" {
+			// A moment in the first segment only
+			if _, err := r.marks.SetBookmark(Bookmark{ID: "b", Start: starts[test.bookmark].UnixMilli() + 1000, Name: "door"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if test.active != "" {
+			r.active, r.session = true, test.active
+		}
+
+		// A free space floor of a byte keeps the test machine's disk out of it
+		test.policy.MinFreeBytes = 1
+		retention := NewRetention(r, func() *msgspb.RetentionPolicy { return test.policy })
+		if err := retention.Enforce(); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if kept := segmentFiles(r.index.Segments()); !slices.Equal(kept, test.kept) {
+			t.Errorf("%s: kept %v, want %v", test.name, kept, test.kept)
+		}
+		var onDisk []string
+		for _, session := range []string{"s1", "s2", "s3"} {
+			names, _ := filepath.Glob(filepath.Join(r.root(), session, "*.ts"))
+			for _, name := range names {
+				onDisk = append(onDisk, session+"/"+filepath.Base(name))
+			}
+		}
+		if !slices.Equal(onDisk, test.kept) {
+			t.Errorf("%s: %v on disk, want %v", test.name, onDisk, test.kept)
+		}
+
+		usage := retention.Usage()
+		if deleted := int64(6-len(test.kept)) * 400; usage.DeletedBytes != deleted || usage.RecordingsBytes != 2400-deleted {
+			t.Errorf("%s: deleted %d of %d bytes, want %d of 2400", test.name, usage.DeletedBytes, usage.RecordingsBytes+usage.DeletedBytes, deleted)
+		}
+		if usage.LockedBytes != test.locked {
+			t.Errorf("%s: %d bytes locked, want %d", test.name, usage.LockedBytes, test.locked)
+		}
+	}
+}
+
+func TestRetentionDeletesWholeSessions(t *testing.T) {
+	r, _ := newTestRecorder(t)
+	retention := NewRetention(r, func() *msgspb.RetentionPolicy {
+		return &msgspb.RetentionPolicy{MaxAgeDays: 7, MinFreeBytes: 1}
+	})
+	if err := retention.Enforce(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing of s1 is left, so its directory goes too
+	if _, err := os.Stat(filepath.Join(r.root(), "s1")); !os.IsNotExist(err) {
+		t.Fatalf("s1 left behind: %v", err)
+	}
+	if usage := retention.Usage(); usage.Oldest != r.index.Segments()[0].Start {
+		t.Fatalf("oldest %d, want the start of s2", usage.Oldest)
+	}
+}
@@ -0,0 +1,146 @@
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"messages/recordcrypt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sealInterval is how often finished segments are moved out of staging
+const sealInterval = time.Second
+
+// stagingRoot returns where ffmpeg writes plaintext segments of encrypted
+// sessions, tmpfs when available so they never reach the SD card
+func stagingRoot() string {
+	if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+		return "/dev/shm/sudocam"
+	}
+	return filepath.Join(os.TempDir(), "sudocam")
+}
+
+// sealer moves the segments ffmpeg finished from the staging directory into
+// the session directory, encrypted with the session's data key. The playlist
+// is copied after its segments so it never lists a missing file.
+type sealer struct {
+	session string
+	staging string
+	dir     string
+	dataKey []byte
+	sealed  map[string]bool
+}
+
+// newSealer creates the session's data key and stores it wrapped to the
+// owner's recording key
+func newSealer(session, staging, dir string, recordingKey []byte) (*sealer, error) {
+	dataKey, err := recordcrypt.NewDataKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	wrapped, err := recordcrypt.WrapKey(recordingKey, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	data, err := json.Marshal(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, recordcrypt.KeyFile), data); err != nil {
+		return nil, fmt.Errorf("failed to write session key: %w", err)
+	}
+	if err := os.MkdirAll(staging, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	return &sealer{
+		session: session,
+		staging: staging,
+		dir:     dir,
+		dataKey: dataKey,
+		sealed:  make(map[string]bool),
+	}, nil
+}
+
+// run seals segments every sealInterval until done is closed, then seals
+// what is left and removes the staging directory
+func (s *sealer) run(done <-chan struct{}) {
+	ticker := time.NewTicker(sealInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			if err := s.pass(); err != nil {
+				slog.Error("Failed to seal recording segments", "session", s.session, "error", err)
+			}
+			s.close()
+			return
+		case <-ticker.C:
+			if err := s.pass(); err != nil {
+				slog.Error("Failed to seal recording segments", "session", s.session, "error", err)
+			}
+		}
+	}
+}
+
+// pass seals every segment the staging playlist lists that isn't sealed yet
+func (s *sealer) pass() error {
+	playlist, err := os.ReadFile(filepath.Join(s.staging, playlistFile))
+	if os.IsNotExist(err) {
+		return nil // No segment finished yet
+	}
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(playlist))
+	for scanner.Scan() {
+		file := strings.TrimSpace(scanner.Text())
+		if file == "" || strings.HasPrefix(file, "#") || s.sealed[file] {
+			continue
+		}
+
+		plaintext, err := os.ReadFile(filepath.Join(s.staging, file))
+		if err != nil {
+			return fmt.Errorf("failed to read staged segment: %w", err)
+		}
+		sealed, err := recordcrypt.Seal(s.dataKey, path.Join(s.session, file), plaintext)
+		if err != nil {
+			return fmt.Errorf("failed to seal segment: %w", err)
+		}
+		if err := writeFileAtomic(filepath.Join(s.dir, file), sealed); err != nil {
+			return fmt.Errorf("failed to write sealed segment: %w", err)
+		}
+		os.Remove(filepath.Join(s.staging, file))
+		s.sealed[file] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Join(s.dir, playlistFile), playlist)
+}
+
+// close forgets the data key and removes the staging directory
+func (s *sealer) close() {
+	clear(s.dataKey)
+	if err := os.RemoveAll(s.staging); err != nil {
+		slog.Warn("Failed to remove staging directory", "path", s.staging, "error", err)
+	}
+}
+
+// writeFileAtomic replaces a file so readers never see it half written
+func writeFileAtomic(name string, data []byte) error {
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
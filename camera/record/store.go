@@ -0,0 +1,251 @@
+package record
+
+import (
+	"camera/config"
+	"context"
+	"errors"
+	"fmt"
+	"messages/s3"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// storeTimeout bounds one store operation, hung network mounts block
+	// file operations forever
+	storeTimeout = 30 * time.Second
+	// probeFile is written to check a directory store accepts writes
+	probeFile = ".sudocam-probe"
+)
+
+// Storage types of config.StorageConfig
+const (
+	StorageLocal = "local"
+	StorageMount = "mount"
+	StorageS3    = "s3"
+)
+
+// SegmentStore keeps finished recording files away from the record
+// directory, which then only buffers them until they are stored. Names are
+// <session>/<file> relative to the camera's recordings.
+type SegmentStore interface {
+	// Check reports whether the store can be written to right now
+	Check(ctx context.Context) error
+	Put(ctx context.Context, name string, data []byte) error
+	// Get fails with os.ErrNotExist for a file that isn't stored
+	Get(ctx context.Context, name string) ([]byte, error)
+	// Delete removes a file or a whole session, "" removes everything
+	Delete(ctx context.Context, name string) error
+	String() string
+}
+
+// NewSegmentStore creates the store the config describes for a camera's
+// recordings, nil when recordings stay in the record directory
+func NewSegmentStore(cfg config.StorageConfig, cameraID string) (SegmentStore, error) {
+	switch cfg.Type {
+	case "", StorageLocal:
+		if cfg.Path == "" {
+			return nil, nil
+		}
+		return &dirStore{root: cfg.Path, dir: filepath.Join(cfg.Path, cameraID)}, nil
+	case StorageMount:
+		if cfg.Path == "" {
+			return nil, errors.New("mount storage needs a path")
+		}
+		return &dirStore{root: cfg.Path, dir: filepath.Join(cfg.Path, cameraID), mount: true}, nil
+	case StorageS3:
+		client, err := s3.New(cfg.S3)
+		if err != nil {
+			return nil, err
+		}
+		return &s3Store{client: client, bucket: cfg.S3.Bucket, prefix: cameraID + "/"}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage type %q", cfg.Type)
+	}
+}
+
+// cleanName rejects names that would leave the camera's recordings
+func cleanName(name string) (string, error) {
+	clean := path.Clean("/" + name)[1:]
+	if clean != name {
+		return "", fmt.Errorf("invalid recording file name %q", name)
+	}
+	return clean, nil
+}
+
+// withTimeout runs fn, giving up after storeTimeout. fn keeps running in the
+// background when it hangs.
+func withTimeout(ctx context.Context, fn func() error) error {
+	ctx, cancel := context.WithTimeout(ctx, storeTimeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("storage not responding: %w", ctx.Err())
+	}
+}
+
+// dirStore keeps recordings in a directory, another local disk or the mount
+// point of a network share. A mount is only used while something is mounted
+// there, so an unmounted share never fills the SD card underneath it.
+type dirStore struct {
+	root  string // Directory from the config
+	dir   string // The camera's recordings under root
+	mount bool
+}
+
+func (s *dirStore) String() string {
+	if s.mount {
+		return "mount:" + s.root
+	}
+	return "local:" + s.root
+}
+
+func (s *dirStore) Check(ctx context.Context) error {
+	return withTimeout(ctx, func() error {
+		if s.mount {
+			if err := checkMounted(s.root); err != nil {
+				return err
+			}
+		}
+		if err := os.MkdirAll(s.dir, 0755); err != nil {
+			return err
+		}
+		probe := filepath.Join(s.dir, probeFile)
+		if err := os.WriteFile(probe, []byte("ok"), 0644); err != nil {
+			return fmt.Errorf("storage not writable: %w", err)
+		}
+		return os.Remove(probe)
+	})
+}
+
+// checkMounted reports an error unless dir is on another filesystem than its
+// parent
+func checkMounted(dir string) error {
+	var stat, parent syscall.Stat_t
+	if err := syscall.Stat(dir, &stat); err != nil {
+		return err
+	}
+	if err := syscall.Stat(filepath.Dir(filepath.Clean(dir)), &parent); err != nil {
+		return err
+	}
+	if stat.Dev == parent.Dev {
+		return fmt.Errorf("nothing mounted at %s", dir)
+	}
+	return nil
+}
+
+func (s *dirStore) Put(ctx context.Context, name string, data []byte) error {
+	name, err := cleanName(name)
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(s.dir, filepath.FromSlash(name))
+	return withTimeout(ctx, func() error {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+		return writeFileAtomic(file, data)
+	})
+}
+
+func (s *dirStore) Get(ctx context.Context, name string) ([]byte, error) {
+	name, err := cleanName(name)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	err = withTimeout(ctx, func() error {
+		var err error
+		data, err = os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(name)))
+		return err
+	})
+	return data, err
+}
+
+func (s *dirStore) Delete(ctx context.Context, name string) error {
+	dir := s.dir
+	if name != "" {
+		clean, err := cleanName(name)
+		if err != nil {
+			return err
+		}
+		dir = filepath.Join(s.dir, filepath.FromSlash(clean))
+	}
+	return withTimeout(ctx, func() error {
+		return os.RemoveAll(dir)
+	})
+}
+
+// s3Store keeps recordings in an S3-compatible bucket under the camera's ID
+type s3Store struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+func (s *s3Store) String() string {
+	return "s3:" + s.bucket
+}
+
+func (s *s3Store) Check(ctx context.Context) error {
+	return s.client.Check(ctx)
+}
+
+func (s *s3Store) Put(ctx context.Context, name string, data []byte) error {
+	name, err := cleanName(name)
+	if err != nil {
+		return err
+	}
+	return s.client.Put(ctx, s.prefix+name, data)
+}
+
+func (s *s3Store) Get(ctx context.Context, name string) ([]byte, error) {
+	name, err := cleanName(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.client.Get(ctx, s.prefix+name)
+	if errors.Is(err, s3.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", os.ErrNotExist, name)
+	}
+	return data, err
+}
+
+// Delete removes the object name and every object under it as a directory
+func (s *s3Store) Delete(ctx context.Context, name string) error {
+	prefix := s.prefix
+	if name != "" {
+		clean, err := cleanName(name)
+		if err != nil {
+			return err
+		}
+		if err := s.client.Delete(ctx, s.prefix+clean); err != nil {
+			return err
+		}
+		prefix += clean + "/"
+	}
+
+	objects, err := s.client.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, object := range objects {
+		if !strings.HasPrefix(object.Key, prefix) {
+			continue
+		}
+		if err := s.client.Delete(ctx, object.Key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
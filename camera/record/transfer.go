@@ -0,0 +1,176 @@
+package record
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"messages/msgspb"
+	"messages/recordcrypt"
+	"messages/rpc"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// transferChunkSize is the payload of one HLSResponse chunk
+	transferChunkSize = 64 << 10
+	// transferWindow is how many chunks may be unacknowledged at once
+	transferWindow = 8
+	// transferAckTimeout aborts a transfer whose receiver stopped acknowledging
+	transferAckTimeout = 30 * time.Second
+)
+
+var errTransferCancelled = errors.New("transfer cancelled")
+
+// transfer is a file being streamed to the server
+type transfer struct {
+	acked      atomic.Int64 // File offset acknowledged by the server
+	notify     chan struct{}
+	cancel     chan struct{}
+	cancelOnce sync.Once
+}
+
+func newTransfer(offset int64) *transfer {
+	t := &transfer{
+		notify: make(chan struct{}, 1),
+		cancel: make(chan struct{}),
+	}
+	t.acked.Store(offset)
+	return t
+}
+
+// ack records the server's progress and wakes the sender
+func (t *transfer) ack(offset int64) {
+	for {
+		current := t.acked.Load()
+		if offset <= current || t.acked.CompareAndSwap(current, offset) {
+			break
+		}
+	}
+	select {
+	case t.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (t *transfer) stop() {
+	t.cancelOnce.Do(func() { close(t.cancel) })
+}
+
+// waitForWindow blocks until fewer than transferWindow chunks before sent are
+// unacknowledged
+func (t *transfer) waitForWindow(sent int64) error {
+	timeout := time.NewTimer(transferAckTimeout)
+	defer timeout.Stop()
+
+	for sent-t.acked.Load() >= transferWindow*transferChunkSize {
+		select {
+		case <-t.notify:
+		case <-t.cancel:
+			return errTransferCancelled
+		case <-timeout.C:
+			return fmt.Errorf("no acknowledgement for %s", transferAckTimeout)
+		}
+	}
+
+	select {
+	case <-t.cancel:
+		return errTransferCancelled
+	default:
+		return nil
+	}
+}
+
+// HandleAck advances or cancels a running transfer, keyed by the request_id
+// of the HLSRequest that started it
+func (r *Recorder) HandleAck(msg *msgspb.HLSAck) error {
+	r.transfersLock.Lock()
+	t := r.transfers[msg.Id]
+	r.transfersLock.Unlock()
+
+	if t == nil {
+		return nil // Already finished
+	}
+	if msg.Cancel {
+		t.stop()
+		return nil
+	}
+	t.ack(msg.Offset)
+	return nil
+}
+
+// startTransfer registers a transfer under id so acks and cancels find it
+func (r *Recorder) startTransfer(id string) *transfer {
+	t := newTransfer(0)
+	r.transfersLock.Lock()
+	r.transfers[id] = t
+	r.transfersLock.Unlock()
+	return t
+}
+
+func (r *Recorder) endTransfer(id string) {
+	r.transfersLock.Lock()
+	delete(r.transfers, id)
+	r.transfersLock.Unlock()
+}
+
+// streamFile sends [offset, offset+length) of file in chunks, keeping at most
+// transferWindow of them unacknowledged. Every chunk is a reply to req. It
+// closes the file when done.
+func (r *Recorder) streamFile(req *msgspb.Message, t *transfer, file recordingFile, size, offset, length int64) {
+	defer file.Close()
+	msg := req.GetHlsRequest()
+
+	// Nothing is sent before the range is resolved, so nothing acknowledged
+	t.acked.Store(offset)
+
+	// Tell the server up front if it will need the whole file to decrypt it
+	header := make([]byte, 16)
+	n, _ := file.ReadAt(header, 0)
+	sealed := recordcrypt.IsSealed(header[:n])
+
+	buf := make([]byte, transferChunkSize)
+	position, end := offset, offset+length
+	for {
+		if err := t.waitForWindow(position); err != nil {
+			slog.Warn("HLS transfer aborted", "filename", msg.FileName, "request_id", req.RequestId, "error", err)
+			return
+		}
+
+		n, err := file.ReadAt(buf[:min(int64(len(buf)), end-position)], position)
+		if err != nil && !errors.Is(err, io.EOF) {
+			slog.Error("Failed to read HLS file", "filename", msg.FileName, "error", err)
+			if err := r.websocket.SendMessage(rpc.ErrorReply(req, r.cameraID, err)); err != nil {
+				slog.Error("Failed to send HLS error", "filename", msg.FileName, "error", err)
+			}
+			return
+		}
+		final := position+int64(n) >= end || n == 0
+
+		reply := rpc.Reply(req, r.cameraID)
+		reply.DataType = &msgspb.Message_HlsResponse{
+			HlsResponse: &msgspb.HLSResponse{
+				FileName:  msg.FileName,
+				Data:      buf[:n],
+				Offset:    position,
+				TotalSize: size,
+				Length:    length,
+				Final:     final,
+				Sealed:    sealed,
+			},
+		}
+		err = r.websocket.SendMessage(reply)
+		if err != nil {
+			slog.Error("Failed to send HLS chunk", "filename", msg.FileName, "error", err)
+			return
+		}
+
+		position += int64(n)
+		if final {
+			slog.Debug("HLS transfer complete", "filename", msg.FileName, "bytes", length)
+			return
+		}
+	}
+}
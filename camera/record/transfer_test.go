@@ -0,0 +1,136 @@
+package record
+
+import (
+	"errors"
+	"messages/msgspb"
+	"testing"
+	"time"
+)
+
+// waitResult runs waitForWindow(sent) and returns its result once it's back
+func waitResult(t *transfer, sent int64) chan error {
+	result := make(chan error, 1)
+	go func() { result <- t.waitForWindow(sent) }()
+	return result
+}
+
+func expectBlocked(t *testing.T, result chan error) {
+	t.Helper()
+	select {
+	case err := <-result:
+		t.Fatalf("returned %v with the window full", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func expectResult(t *testing.T, result chan error) error {
+	t.Helper()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("still waiting")
+		return nil
+	}
+}
+
+func TestTransferWindow(t *testing.T) {
+	const offset = 1000
+	tr := newTransfer(offset)
+	window := int64(transferWindow * transferChunkSize)
+
+	// Up to the window unacknowledged, counted from the requested offset
+	if err := tr.waitForWindow(offset + window - 1); err != nil {
+		t.Fatalf("within the window: %v", err)
+	}
+
+	full := offset + window
+	result := waitResult(tr, full)
+	expectBlocked(t, result)
+
+	// Acknowledging less than a chunk is not enough
+	tr.ack(offset)
+	expectBlocked(t, result)
+
+	tr.ack(offset + transferChunkSize)
+	if err := expectResult(t, result); err != nil {
+		t.Fatalf("after an ack: %v", err)
+	}
+}
+
+func TestTransferAckOnlyAdvances(t *testing.T) {
+	tr := newTransfer(0)
+	tr.ack(5 * transferChunkSize)
+	tr.ack(2 * transferChunkSize) // Reordered, older acknowledgement
+	if acked := tr.acked.Load(); acked != 5*transferChunkSize {
+		t.Fatalf("acked = %d, want %d", acked, 5*transferChunkSize)
+	}
+
+	// Acks with nobody waiting don't block and don't get lost
+	for range 3 {
+		tr.ack(6 * transferChunkSize)
+	}
+	if err := tr.waitForWindow(6*transferChunkSize + transferWindow*transferChunkSize - 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransferCancel(t *testing.T) {
+	tr := newTransfer(0)
+	result := waitResult(tr, transferWindow*transferChunkSize)
+	expectBlocked(t, result)
+
+	tr.stop()
+	tr.stop() // Cancelling twice is fine
+	if err := expectResult(t, result); !errors.Is(err, errTransferCancelled) {
+		t.Fatalf("err = %v, want errTransferCancelled", err)
+	}
+
+	// A cancelled transfer sends nothing more, even with room in the window
+	if err := tr.waitForWindow(0); !errors.Is(err, errTransferCancelled) {
+		t.Fatalf("err = %v, want errTransferCancelled", err)
+	}
+}
+
+func TestHandleAck(t *testing.T) {
+	r := &Recorder{transfers: make(map[string]*transfer)}
+	tr := newTransfer(0)
+	r.transfers["request"] = tr
+
+	if err := r.HandleAck(&msgspb.HLSAck{Id: "request", Offset: 3 * transferChunkSize}); err != nil {
+		t.Fatal(err)
+	}
+	if acked := tr.acked.Load(); acked != 3*transferChunkSize {
+		t.Fatalf("acked = %d, want %d", acked, 3*transferChunkSize)
+	}
+
+	// Acks for transfers that already finished are ignored
+	if err := r.HandleAck(&msgspb.HLSAck{Id: "finished", Offset: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.HandleAck(&msgspb.HLSAck{Id: "request", Cancel: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.waitForWindow(0); !errors.Is(err, errTransferCancelled) {
+		t.Fatalf("err = %v, want errTransferCancelled", err)
+	}
+}
+
+func TestCancelBeforeStreaming(t *testing.T) {
+	r := &Recorder{transfers: make(map[string]*transfer)}
+	tr := r.startTransfer("request")
+
+	// The server gives up while the file is still being opened
+	if err := r.HandleAck(&msgspb.HLSAck{Id: "request", Cancel: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.waitForWindow(0); !errors.Is(err, errTransferCancelled) {
+		t.Fatalf("err = %v, want errTransferCancelled", err)
+	}
+
+	r.endTransfer("request")
+	if len(r.transfers) != 0 {
+		t.Fatal("transfer left registered")
+	}
+}
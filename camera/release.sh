@@ -0,0 +1,26 @@
+#!/bin/bash
+
+# Build a camera agent release into the server's release directory and sign
+# it with the offline release key. The server only stores and serves the
+# signature next to the binary, cameras verify it against the release key
+# built into them. Create a key once with:
+#   go run ./cmd/signrelease -key release_ed25519.pem -genkey
+# and keep it off the server.
+
+VERSION=${VERSION:-$(git describe --tags --always --dirty)}
+RELEASE_DIR=${RELEASE_DIR:-../server/data/releases}
+RELEASE_KEY=${RELEASE_KEY:-release_ed25519.pem}
+GOARCH=${GOARCH:-arm}
+
+[ -f "$RELEASE_KEY" ] || { echo "Release key $RELEASE_KEY not found"; exit 1; }
+PUBLIC_KEY=$(go run ./cmd/signrelease -key "$RELEASE_KEY" -pubkey) || { echo "Failed to read release key!"; exit 1; }
+
+export CGO_CFLAGS="-Ideps/include"
+export CGO_LDFLAGS="-Ldeps/lib -lrknnmrt"
+
+BINARY="$RELEASE_DIR/$VERSION/sudocam-$GOARCH"
+mkdir -p "$RELEASE_DIR/$VERSION"
+env GOOS=linux GOARCH=$GOARCH GOARM=7 go build -ldflags "-X camera/update.Version=$VERSION -X camera/update.ReleaseKey=$PUBLIC_KEY" -o "$BINARY" main.go || { echo "Build failed!"; exit 1; }
+go run ./cmd/signrelease -key "$RELEASE_KEY" -version "$VERSION" -arch "$GOARCH" "$BINARY" || { echo "Signing failed!"; exit 1; }
+
+echo "Release $VERSION written to $RELEASE_DIR/$VERSION, restart the server to publish it"
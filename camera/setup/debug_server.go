@@ -1,11 +1,13 @@
 package setup
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"sync"
+	"time"
 )
 
 // MJPEGServer provides a simple HTTP server that streams MJPEG content
@@ -17,6 +19,8 @@ type MJPEGServer struct {
 	clients2 map[chan []byte]bool
 	lock     sync.Mutex
 	boundary string
+	mux      *http.ServeMux
+	server   *http.Server
 }
 
 // NewMJPEGServer creates a new MJPEG streaming server
@@ -26,6 +30,7 @@ func NewMJPEGServer(port int) *MJPEGServer {
 		clients1: make(map[chan []byte]bool),
 		clients2: make(map[chan []byte]bool),
 		boundary: "sudocamboundary",
+		mux:      http.NewServeMux(),
 	}
 
 	// Create a custom writer that broadcasts to all clients for stream 1
@@ -109,27 +114,48 @@ func (s *MJPEGServer) Writer2() io.Writer {
 	return s.writer2
 }
 
+// Handle registers an additional handler on the server, must be called before Start
+func (s *MJPEGServer) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
 // Start launches the HTTP server
 func (s *MJPEGServer) Start() {
 	// Handle the MJPEG stream 1
-	http.HandleFunc("/live-stream1", s.handleStream1)
+	s.mux.HandleFunc("/live-stream1", s.handleStream1)
 
 	// Handle the MJPEG stream 2
-	http.HandleFunc("/live-stream2", s.handleStream2)
+	s.mux.HandleFunc("/live-stream2", s.handleStream2)
 
 	// Serve a simple HTML page with the stream embedded
-	http.HandleFunc("/", s.handleIndex)
+	s.mux.HandleFunc("/", s.handleIndex)
+
+	s.server = &http.Server{
+		Addr:    fmt.Sprintf(":%d", s.port),
+		Handler: s.mux,
+	}
 
 	// Start server in a goroutine
 	go func() {
-		addr := fmt.Sprintf(":%d", s.port)
-		slog.Info("Starting debug MJPEG server", "address", "http://localhost"+addr)
-		if err := http.ListenAndServe(addr, nil); err != nil {
+		slog.Info("Starting debug MJPEG server", "address", "http://localhost"+s.server.Addr)
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			slog.Error("Failed to start debug server", "error", err)
 		}
 	}()
 }
 
+// Stop shuts the HTTP server down
+func (s *MJPEGServer) Stop() {
+	if s.server == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		slog.Error("Failed to stop debug server", "error", err)
+	}
+}
+
 // handleStream1 processes incoming client requests for the MJPEG stream 1
 func (s *MJPEGServer) handleStream1(w http.ResponseWriter, r *http.Request) {
 	// Set headers for MJPEG streaming
@@ -264,6 +290,7 @@ func (s *MJPEGServer) handleIndex(w http.ResponseWriter, r *http.Request) {
 			<div class="container">
 				<h1>SudoCam QR Code Scanner Debug</h1>
 				<p>This page shows the live camera feeds being used for debugging.</p>
+				<p>Can't scan the QR code? <a href="/setup">Set up the camera manually</a>.</p>
 				<div class="stream-container">
 					<img class="stream" src="/live-stream1" alt="Camera Stream 1" />
 					<img class="stream" src="/live-stream2" alt="Camera Stream 2" />
@@ -0,0 +1,141 @@
+package setup
+
+import (
+	"context"
+	"log/slog"
+	"messages/jwtmsg"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AccessPoint is a Wi-Fi network found by a scan
+type AccessPoint struct {
+	SSID     string `json:"ssid"`
+	Signal   int    `json:"signal"` // 0-100
+	Security string `json:"security"`
+}
+
+// NetworkStatus describes the current Wi-Fi connection
+type NetworkStatus struct {
+	Connected bool
+	SSID      string
+	IPAddress string
+}
+
+// NetworkManager configures the camera's Wi-Fi. Implementations must never
+// pass the SSID or password through a shell.
+type NetworkManager interface {
+	// Connect joins the network, password may be empty for open networks
+	Connect(ctx context.Context, ssid, password string) error
+	// Forget removes a network added by Connect
+	Forget(ctx context.Context, ssid string) error
+	// Scan lists the visible networks
+	Scan(ctx context.Context) ([]AccessPoint, error)
+	// Status reports the current connection
+	Status(ctx context.Context) (*NetworkStatus, error)
+}
+
+// networkManager is the backend used during provisioning
+var networkManager NetworkManager = NewNMCLIManager("")
+
+// SetNetworkManager replaces the Wi-Fi backend used during provisioning
+func SetNetworkManager(manager NetworkManager) {
+	networkManager = manager
+}
+
+// CurrentNetworkStatus reports the connection of the configured Wi-Fi backend
+func CurrentNetworkStatus(ctx context.Context) (*NetworkStatus, error) {
+	return networkManager.Status(ctx)
+}
+
+const connectivityTimeout = 30 * time.Second
+
+// setupWifi configures WiFi using the provided network name and password
+func setupWifi(network, password string) error {
+	if network == "" {
+		return nil // No WiFi setup needed
+	}
+
+	slog.Info("Setting up WiFi", "network", network)
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectivityTimeout)
+	defer cancel()
+
+	if err := networkManager.Connect(ctx, network, password); err != nil {
+		slog.Error("Failed to connect to WiFi", "error", err)
+		return err
+	}
+
+	slog.Info("Successfully connected to WiFi network", "network", network)
+	return nil
+}
+
+// forgetWifi removes a network joined during setup that turned out not to be
+// trusted, network may be empty
+func forgetWifi(network string) {
+	if network == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), connectivityTimeout)
+	defer cancel()
+
+	if err := networkManager.Forget(ctx, network); err != nil {
+		slog.Error("Failed to forget WiFi network", "network", network, "error", err)
+	}
+}
+
+// verifyConnectivity waits for the network to come up and checks that the server answers
+func verifyConnectivity(network, serverURL string) *jwtmsg.ConnectivityReport {
+	report := &jwtmsg.ConnectivityReport{Network: network}
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectivityTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+wait:
+	for {
+		status, err := networkManager.Status(ctx)
+		if err == nil && status.Connected {
+			report.Connected = true
+			report.IPAddress = status.IPAddress
+			if report.Network == "" {
+				report.Network = status.SSID
+			}
+			report.Error = ""
+			break
+		}
+		if err != nil {
+			report.Error = err.Error()
+		}
+
+		select {
+		case <-ctx.Done():
+			if report.Error == "" {
+				report.Error = "timed out waiting for network"
+			}
+			// Might still be on ethernet, the server check decides
+			slog.Warn("Network not connected", "error", report.Error)
+			break wait
+		case <-ticker.C:
+		}
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(strings.TrimSuffix(serverURL, "/") + jwtmsg.ProvisioningKeyPath)
+	if err != nil {
+		slog.Error("Server not reachable", "error", err)
+		if report.Error != "" {
+			report.Error += "; "
+		}
+		report.Error += err.Error()
+		return report
+	}
+	resp.Body.Close()
+
+	report.ServerReachable = resp.StatusCode == http.StatusOK
+	slog.Info("Connectivity verified", "connected", report.Connected, "ip", report.IPAddress, "server_reachable", report.ServerReachable)
+	return report
+}
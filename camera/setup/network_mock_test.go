@@ -0,0 +1,111 @@
+package setup
+
+import (
+	"context"
+	"messages/jwtmsg"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// MockNetworkManager is an in-memory NetworkManager for tests
+type MockNetworkManager struct {
+	Networks   []AccessPoint
+	ConnectErr error
+	IPAddress  string
+
+	mutex     sync.Mutex
+	connected string
+	calls     []string
+	forgotten []string
+}
+
+// NewMockNetworkManager creates a mock backend that sees the given networks
+func NewMockNetworkManager(networks ...AccessPoint) *MockNetworkManager {
+	return &MockNetworkManager{
+		Networks:  networks,
+		IPAddress: "127.0.0.1",
+	}
+}
+
+func (m *MockNetworkManager) Connect(ctx context.Context, ssid, password string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.calls = append(m.calls, ssid)
+	if m.ConnectErr != nil {
+		return m.ConnectErr
+	}
+	m.connected = ssid
+	return nil
+}
+
+func (m *MockNetworkManager) Forget(ctx context.Context, ssid string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.forgotten = append(m.forgotten, ssid)
+	if m.connected == ssid {
+		m.connected = ""
+	}
+	return nil
+}
+
+func (m *MockNetworkManager) Scan(ctx context.Context) ([]AccessPoint, error) {
+	return m.Networks, nil
+}
+
+func (m *MockNetworkManager) Status(ctx context.Context) (*NetworkStatus, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.connected == "" {
+		return &NetworkStatus{}, nil
+	}
+	return &NetworkStatus{
+		Connected: true,
+		SSID:      m.connected,
+		IPAddress: m.IPAddress,
+	}, nil
+}
+
+// ConnectCalls returns the SSIDs Connect was called with, in order
+func (m *MockNetworkManager) ConnectCalls() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]string(nil), m.calls...)
+}
+
+// Forgotten returns the SSIDs Forget was called with, in order
+func (m *MockNetworkManager) Forgotten() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]string(nil), m.forgotten...)
+}
+
+func TestSetupWifiConnectivity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != jwtmsg.ProvisioningKeyPath {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	mock := NewMockNetworkManager(AccessPoint{SSID: "home", Signal: 80, Security: "WPA2"})
+	previous := networkManager
+	SetNetworkManager(mock)
+	defer SetNetworkManager(previous)
+
+	if err := setupWifi("home", "password123"); err != nil {
+		t.Fatalf("setupWifi: %v", err)
+	}
+	if calls := mock.ConnectCalls(); len(calls) != 1 || calls[0] != "home" {
+		t.Fatalf("Connect calls = %v, want [home]", calls)
+	}
+
+	report := verifyConnectivity("home", server.URL)
+	if !report.Connected || !report.ServerReachable || report.IPAddress != "127.0.0.1" {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
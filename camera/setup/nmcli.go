@@ -0,0 +1,148 @@
+package setup
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// NMCLIManager configures Wi-Fi through NetworkManager's nmcli. Every value is
+// passed as its own argument, so quotes or shell syntax in an SSID or password
+// are never interpreted.
+type NMCLIManager struct {
+	iface string
+}
+
+// NewNMCLIManager creates an nmcli backend, iface may be empty to let NetworkManager pick
+func NewNMCLIManager(iface string) *NMCLIManager {
+	return &NMCLIManager{iface: iface}
+}
+
+func (m *NMCLIManager) Connect(ctx context.Context, ssid, password string) error {
+	args := []string{"device", "wifi", "connect", ssid}
+	if password != "" {
+		args = append(args, "password", password)
+	}
+	if m.iface != "" {
+		args = append(args, "ifname", m.iface)
+	}
+
+	output, err := exec.CommandContext(ctx, "nmcli", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("nmcli connect failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Forget deletes the connection profile nmcli created for the network, which
+// is named after the SSID
+func (m *NMCLIManager) Forget(ctx context.Context, ssid string) error {
+	output, err := exec.CommandContext(ctx, "nmcli", "connection", "delete", "id", ssid).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("nmcli delete failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (m *NMCLIManager) Scan(ctx context.Context) ([]AccessPoint, error) {
+	args := []string{"--terse", "--fields", "SSID,SIGNAL,SECURITY", "device", "wifi", "list", "--rescan", "yes"}
+	if m.iface != "" {
+		args = append(args, "ifname", m.iface)
+	}
+
+	output, err := exec.CommandContext(ctx, "nmcli", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("nmcli scan failed: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var networks []AccessPoint
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := splitTerse(line)
+		if len(fields) != 3 || fields[0] == "" || seen[fields[0]] {
+			continue
+		}
+		seen[fields[0]] = true
+
+		signal, _ := strconv.Atoi(fields[1])
+		networks = append(networks, AccessPoint{
+			SSID:     fields[0],
+			Signal:   signal,
+			Security: fields[2],
+		})
+	}
+	return networks, nil
+}
+
+func (m *NMCLIManager) Status(ctx context.Context) (*NetworkStatus, error) {
+	output, err := exec.CommandContext(ctx, "nmcli", "--terse", "--fields", "DEVICE,TYPE,STATE,CONNECTION", "device", "status").Output()
+	if err != nil {
+		return nil, fmt.Errorf("nmcli status failed: %w", err)
+	}
+
+	status := &NetworkStatus{}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := splitTerse(line)
+		if len(fields) != 4 || fields[1] != "wifi" {
+			continue
+		}
+		if m.iface != "" && fields[0] != m.iface {
+			continue
+		}
+		if fields[2] == "connected" {
+			status.Connected = true
+			status.SSID = fields[3]
+			status.IPAddress = interfaceAddress(fields[0])
+			break
+		}
+	}
+	return status, nil
+}
+
+// splitTerse splits a line of nmcli --terse output, where ':' separates fields
+// and literal colons are escaped as '\:'
+func splitTerse(line string) []string {
+	if line == "" {
+		return nil
+	}
+
+	var fields []string
+	var current strings.Builder
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ':':
+			fields = append(fields, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	return append(fields, current.String())
+}
+
+// interfaceAddress returns the first IPv4 address of the interface
+func interfaceAddress(name string) string {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return ""
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return ""
+	}
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
+			return ipnet.IP.String()
+		}
+	}
+	return ""
+}
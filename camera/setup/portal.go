@@ -0,0 +1,192 @@
+package setup
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"log/slog"
+	"messages/jwtmsg"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// portalSubmission is a token and Wi-Fi choice entered in the portal
+type portalSubmission struct {
+	token     string
+	serverURL string // Server of a bare claim code
+	wifi      wifiInput
+}
+
+// Portal is a local web page where a user on the same LAN or on the camera's
+// hotspot can paste the CameraAdd token instead of showing it to the lens
+type Portal struct {
+	submissions chan portalSubmission
+	statusLock  sync.Mutex
+	status      string
+}
+
+// NewPortal creates a provisioning portal
+func NewPortal() *Portal {
+	return &Portal{
+		submissions: make(chan portalSubmission, 1),
+		status:      "Waiting for setup code",
+	}
+}
+
+// Register adds the portal routes to the server
+func (p *Portal) Register(server *MJPEGServer) {
+	server.Handle("/setup", http.HandlerFunc(p.handleSetup))
+	server.Handle("/setup/networks", http.HandlerFunc(p.handleNetworks))
+	server.Handle("/setup/status", http.HandlerFunc(p.handleStatus))
+}
+
+// setStatus updates the message shown to the user
+func (p *Portal) setStatus(status string) {
+	p.statusLock.Lock()
+	defer p.statusLock.Unlock()
+	p.status = status
+}
+
+func (p *Portal) getStatus() string {
+	p.statusLock.Lock()
+	defer p.statusLock.Unlock()
+	return p.status
+}
+
+var portalPage = template.Must(template.New("portal").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<title>SudoCam Setup</title>
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<style>
+		body { font-family: Arial, sans-serif; margin: 20px; }
+		.container { max-width: 480px; margin: 0 auto; }
+		label { display: block; margin-top: 12px; }
+		input, select, textarea { width: 100%; box-sizing: border-box; padding: 6px; }
+		button { margin-top: 16px; padding: 8px 16px; }
+	</style>
+</head>
+<body>
+	<div class="container">
+		<h1>SudoCam Setup</h1>
+		<p>Status: {{.Status}}</p>
+		<form method="POST" action="/setup">
+			<label for="token">Setup code or claim link</label>
+			<textarea id="token" name="token" rows="6" required></textarea>
+			<label for="server_url">Server address (only for a typed claim code)</label>
+			<input id="server_url" name="server_url" type="url" placeholder="https://cam.example.com">
+			<label for="wifi_code">Wi-Fi code (shown with the QR code)</label>
+			<input id="wifi_code" name="wifi_code" placeholder="XXXX-XXXX">
+			<label for="ssid">Wi-Fi network</label>
+			<select id="ssid" name="ssid">
+				<option value="">Use the network from the setup code</option>
+				{{range .Networks}}<option value="{{.SSID}}">{{.SSID}} ({{.Signal}}%)</option>{{end}}
+			</select>
+			<label for="password">Wi-Fi password</label>
+			<input id="password" name="password" type="password">
+			<button type="submit">Set up camera</button>
+		</form>
+	</div>
+</body>
+</html>
+`))
+
+func (p *Portal) handleSetup(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		networks, err := networkManager.Scan(ctx)
+		if err != nil {
+			slog.Error("Failed to scan networks", "error", err)
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		portalPage.Execute(w, map[string]any{
+			"Status":   p.getStatus(),
+			"Networks": networks,
+		})
+
+	case http.MethodPost:
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid form", http.StatusBadRequest)
+			return
+		}
+
+		submission := portalSubmission{
+			token:     strings.TrimSpace(r.PostFormValue("token")),
+			serverURL: strings.TrimSuffix(strings.TrimSpace(r.PostFormValue("server_url")), "/"),
+			wifi: wifiInput{
+				Network:  r.PostFormValue("ssid"),
+				Password: r.PostFormValue("password"),
+				Code:     strings.TrimSpace(r.PostFormValue("wifi_code")),
+			},
+		}
+
+		if submission.serverURL != "" {
+			if u, err := url.Parse(submission.serverURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				http.Error(w, "Invalid server address", http.StatusBadRequest)
+				return
+			}
+		}
+
+		// Catch typos right away, the full verification needs the network
+		if jwtmsg.IsShortCode(submission.token) {
+			if submission.serverURL == "" && !hasPinnedServer() {
+				http.Error(w, "A claim code needs the server address", http.StatusBadRequest)
+				return
+			}
+		} else if _, _, ok := jwtmsg.ParseClaimURL(submission.token); !ok {
+			claims := &jwtmsg.CameraAdd{}
+			if _, _, err := jwt.NewParser().ParseUnverified(submission.token, claims); err != nil {
+				http.Error(w, "Invalid setup code", http.StatusBadRequest)
+				return
+			}
+			if err := checkExpiry(claims); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		select {
+		case p.submissions <- submission:
+		default:
+			http.Error(w, "Setup already in progress", http.StatusConflict)
+			return
+		}
+
+		p.setStatus("Setting up, the camera may leave this network")
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("Setup started. The camera will now join the selected network and register with the server.\n"))
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (p *Portal) handleNetworks(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	networks, err := networkManager.Scan(ctx)
+	if err != nil {
+		slog.Error("Failed to scan networks", "error", err)
+		http.Error(w, "Failed to scan networks", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(networks)
+}
+
+func (p *Portal) handleStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": p.getStatus()})
+}
@@ -6,6 +6,8 @@ import (
 	"camera/config"
 	"camera/stream"
 	"context"
+	"crypto/ed25519"
+	"encoding/base64"
 	"time"
 
 	// "camera/stream"
@@ -19,8 +21,8 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
 	"strings"
+	"sync"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
@@ -28,42 +30,57 @@ import (
 	"github.com/makiuchi-d/gozxing/qrcode"
 )
 
-// setupWifi configures WiFi using the provided network name and password
-func setupWifi(network, password string) error {
-	if network == "" {
-		return nil // No WiFi setup needed
-	}
-
-	slog.Info("Setting up WiFi", "network", network)
-
-	// This is a simplified example - actual implementation will depend on your OS/platform
-	cmd := exec.Command("sh", "-c", fmt.Sprintf(`nmcli device wifi connect "%s" password "%s"`, network, password))
-	output, err := cmd.CombinedOutput()
-
-	if err != nil {
-		slog.Error("Failed to connect to WiFi", "error", err, "output", string(output))
-		return err
-	}
-
-	slog.Info("Successfully connected to WiFi network", "network", network)
-	return nil
-}
-
 // RunSetupWithQRCode runs the setup process by scanning a QR code from the camera
 func RunSetupWithQRCode(debugMode bool) *config.Config {
-	var mjpegServer *MJPEGServer = nil
-	if debugMode {
-		mjpegServer = NewMJPEGServer(8080)
-		mjpegServer.Start()
-	}
+	// The server always runs for the setup portal, the debug streams are only fed in debug mode
+	mjpegServer := NewMJPEGServer(8080)
+	portal := NewPortal()
+	portal.Register(mjpegServer)
+	mjpegServer.Start()
+	defer mjpegServer.Stop()
 
 	// Create a context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel() // Ensure we clean up resources
 
-	// Channel to receive the config once QR code is processed
+	// Channel to receive the config once QR code or portal submission is processed
 	configCh := make(chan *config.Config, 1)
 
+	// Only one token is processed at a time, whichever source wins stops the other
+	var processing sync.Mutex
+	provision := func(token, serverURL string, wifi wifiInput) bool {
+		processing.Lock()
+		defer processing.Unlock()
+		if ctx.Err() != nil {
+			return false
+		}
+
+		config := processSetupCode(token, serverURL, wifi)
+		if config == nil {
+			return false
+		}
+
+		configCh <- config
+		cancel() // Stop the video stream
+		return true
+	}
+
+	// Handle tokens pasted into the setup portal
+	go func() {
+		for {
+			select {
+			case submission := <-portal.submissions:
+				if provision(submission.token, submission.serverURL, submission.wifi) {
+					portal.setStatus("Camera registered")
+					return
+				}
+				portal.setStatus("Setup failed, check the setup code and Wi-Fi password and try again")
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
 	// Channel to hold the latest JPEG frame
 	latestFrame := make(chan []byte, 1) // Buffered channel to hold the latest frame
 
@@ -89,6 +106,10 @@ func RunSetupWithQRCode(debugMode bool) *config.Config {
 	go func() {
 		qrReader := qrcode.NewQRCodeReader()
 		hints := map[gozxing.DecodeHintType]interface{}{}
+
+		// A token with sealed Wi-Fi credentials waits for its Wi-Fi code, which
+		// is shown as a second QR code
+		var pendingToken, wifiCode string
 		for {
 			select {
 			case frameData := <-latestFrame:
@@ -119,14 +140,33 @@ func RunSetupWithQRCode(debugMode bool) *config.Config {
 				}
 
 				slog.Info("QR Code found", "text_length", len(result.GetText()))
-				jwtToken := result.GetText()
+				text := strings.TrimSpace(result.GetText())
+
+				if jwtmsg.IsShortCode(text) {
+					if text == wifiCode {
+						continue
+					}
+					wifiCode = text
+					if pendingToken == "" {
+						slog.Info("Wi-Fi code scanned, waiting for setup code")
+						continue
+					}
+				} else {
+					if text == pendingToken {
+						continue
+					}
+					pendingToken = text
+					if needsWifiCode(pendingToken) && wifiCode == "" {
+						slog.Info("Setup code scanned, waiting for Wi-Fi code")
+						continue
+					}
+				}
 
 				// Process the JWT and send the resulting config
-				if config := processJWT(jwtToken); config != nil {
-					configCh <- config
-					cancel() // Stop the video stream
-					return   // Exit goroutine
+				if provision(pendingToken, "", wifiInput{Code: wifiCode}) {
+					return // Exit goroutine
 				}
+				pendingToken, wifiCode = "", ""
 
 			case <-ctx.Done():
 				slog.Info("Stopping frame processing due to context cancellation")
@@ -147,10 +187,10 @@ func RunSetupWithQRCode(debugMode bool) *config.Config {
 	}
 }
 
-// RunSetupWithManualInput runs the setup process by accepting a JWT token from stdin
+// RunSetupWithManualInput runs the setup process by accepting a JWT token or claim code from stdin
 func RunSetupWithManualInput() *config.Config {
 	fmt.Println("\n==== Debug Setup Mode ====")
-	fmt.Println("Paste your JWT token (then press Enter):")
+	fmt.Println("Paste your JWT token or claim code (then press Enter):")
 
 	reader := bufio.NewReader(os.Stdin)
 	jwtToken, err := reader.ReadString('\n')
@@ -167,12 +207,48 @@ func RunSetupWithManualInput() *config.Config {
 		return nil
 	}
 
-	return processJWT(jwtToken)
+	wifi := wifiInput{}
+	if jwtmsg.IsShortCode(jwtToken) {
+		fmt.Println("Enter the server URL:")
+		serverURL, err := reader.ReadString('\n')
+		if err != nil {
+			slog.Error("Error reading from stdin", "error", err)
+			return nil
+		}
+		return processClaimCode(strings.TrimSpace(serverURL), jwtToken, wifi)
+	}
+	if needsWifiCode(jwtToken) {
+		fmt.Println("Enter the Wi-Fi code shown with the QR code (leave empty to skip Wi-Fi setup):")
+		code, err := reader.ReadString('\n')
+		if err != nil {
+			slog.Error("Error reading from stdin", "error", err)
+			return nil
+		}
+		wifi.Code = strings.TrimSpace(code)
+	}
+
+	return processSetupCode(jwtToken, "", wifi)
 }
 
-// processJWT handles the common JWT processing logic for both setup methods
-func processJWT(jwtToken string) *config.Config {
-	// Parse the claims from the JWT
+// wifiInput is Wi-Fi information entered on the camera side during setup
+type wifiInput struct {
+	Network  string // Overrides the network in the token when set
+	Password string
+	Code     string // Opens the sealed Wi-Fi credentials in the token
+}
+
+// needsWifiCode reports whether the token carries sealed Wi-Fi credentials
+func needsWifiCode(jwtToken string) bool {
+	claims := &jwtmsg.CameraAdd{}
+	if _, _, err := jwt.NewParser().ParseUnverified(jwtToken, claims); err != nil {
+		return false
+	}
+	return claims.WifiSealed != ""
+}
+
+// processJWT handles the common JWT processing logic for all setup methods
+func processJWT(jwtToken string, wifi wifiInput) *config.Config {
+	// Read the claims without trusting them yet, the server URL is needed to find the key
 	claims := &jwtmsg.CameraAdd{}
 	_, _, err := jwt.NewParser().ParseUnverified(jwtToken, claims)
 	if err != nil {
@@ -180,27 +256,196 @@ func processJWT(jwtToken string) *config.Config {
 		return nil
 	}
 
-	slog.Info("JWT processed", slog.Any("claims", claims))
+	if err := checkExpiry(claims); err != nil {
+		slog.Error("Rejected provisioning token", "error", err)
+		return nil
+	}
+
+	// The token is verified before its Wi-Fi settings are applied. Once
+	// registered, only tokens for the pinned server and signed by its key are
+	// accepted. The one exception is the first registration of a camera with
+	// no pinned key whose setup network can't reach the server: it has no
+	// key to verify against, so it joins the token's Wi-Fi unverified to
+	// fetch one. That is trust on first use, anyone able to hand the camera
+	// a token can point it at a network. The network is forgotten again
+	// unless the server there signed the token.
+	trust, err := config.LoadServerTrust(config.DataPath(config.TrustFile))
+	if err != nil {
+		slog.Error("Failed to load pinned server key", "error", err)
+		return nil
+	}
+
+	var publicKey ed25519.PublicKey
+	var joined string // Network joined before the token was verified
+	if trust != nil {
+		if claims.ServerURL != trust.ServerURL {
+			slog.Error("Provisioning token is for a different server than the pinned one", "server_url", claims.ServerURL, "pinned_url", trust.ServerURL)
+			return nil
+		}
+		publicKey, err = decodePublicKey(trust.PublicKey)
+		if err != nil {
+			slog.Error("Invalid pinned server key", "error", err)
+			return nil
+		}
+	} else {
+		// First registration, trust the key served by the server in the token and pin it
+		publicKey, err = fetchProvisioningKey(claims.ServerURL)
+		if err != nil {
+			// The server may only be reachable through the token's network.
+			// Join it unverified, trust on first use as above, and leave it
+			// again unless the token is signed by that server.
+			slog.Info("Server not reachable, joining the token's network to verify it", "error", err)
+			network, password, err := tokenWifi(claims, wifi)
+			if err != nil || network == "" {
+				slog.Error("Failed to get provisioning key", "error", err)
+				return nil
+			}
+			if err := setupWifi(network, password); err != nil {
+				slog.Error("WiFi setup failed", "error", err)
+				return nil
+			}
+			joined = network
+			verifyConnectivity(network, claims.ServerURL)
+			publicKey, err = fetchProvisioningKey(claims.ServerURL)
+			if err != nil {
+				slog.Error("Failed to get provisioning key", "error", err)
+				forgetWifi(joined)
+				return nil
+			}
+		}
+	}
+
+	claims, err = verifyProvisioningToken(jwtToken, publicKey)
+	if err != nil {
+		slog.Error("Provisioning token verification failed", "error", err)
+		forgetWifi(joined)
+		return nil
+	}
+
+	slog.Info("JWT processed", "server_url", claims.ServerURL, "friendly_name", claims.FriendlyName)
 
-	// Setup WiFi if provided
-	if claims.WifiNetwork != "" {
-		if err := setupWifi(claims.WifiNetwork, claims.WifiPassword); err != nil {
+	network, password, err := tokenWifi(claims, wifi)
+	if err != nil {
+		slog.Error("Failed to decrypt Wi-Fi credentials", "error", err)
+		return nil
+	}
+	if network != "" && network != joined {
+		if err := setupWifi(network, password); err != nil {
 			slog.Error("WiFi setup failed", "error", err)
 			// Continue anyway - might be already connected or using ethernet
 		}
 	}
 
-	// Generate a UUID for this camera
-	cameraUUID := uuid.New()
+	connectivity := verifyConnectivity(network, claims.ServerURL)
 
-	// Register with the server
 	register := &jwtmsg.RegisterCamera{
-		CameraUUID:   cameraUUID.String(),
 		Token:        jwtToken,
 		FriendlyName: claims.FriendlyName,
+		Connectivity: connectivity,
+	}
+	return registerCamera(claims.ServerURL, register, trust, publicKey)
+}
+
+// tokenWifi returns the Wi-Fi network to join, entered on the camera or
+// carried in the token, empty when there is none
+func tokenWifi(claims *jwtmsg.CameraAdd, wifi wifiInput) (string, string, error) {
+	if wifi.Network != "" {
+		return wifi.Network, wifi.Password, nil
+	}
+	if claims.WifiSealed == "" {
+		return claims.WifiNetwork, claims.WifiPassword, nil
+	}
+	if wifi.Code == "" {
+		slog.Warn("Wi-Fi credentials are encrypted but no Wi-Fi code was given, skipping Wi-Fi setup")
+		return "", "", nil
+	}
+	creds, err := jwtmsg.OpenWifiCredentials(claims.WifiSealed, wifi.Code)
+	if err != nil {
+		return "", "", err
+	}
+	return creds.Network, creds.Password, nil
+}
+
+// processClaimCode registers the camera with a short claim code created on the server
+func processClaimCode(serverURL, code string, wifi wifiInput) *config.Config {
+	trust, err := config.LoadServerTrust(config.DataPath(config.TrustFile))
+	if err != nil {
+		slog.Error("Failed to load pinned server key", "error", err)
+		return nil
+	}
+
+	// A claim code isn't signed, so a camera that already trusts a server only
+	// accepts codes for that same server
+	if trust != nil && trust.ServerURL != serverURL {
+		slog.Error("Claim code is for a different server than the pinned one", "server_url", serverURL, "pinned_url", trust.ServerURL)
+		return nil
+	}
+
+	slog.Info("Claim code processed", "server_url", serverURL)
+
+	if wifi.Network != "" {
+		if err := setupWifi(wifi.Network, wifi.Password); err != nil {
+			slog.Error("WiFi setup failed", "error", err)
+			// Continue anyway - might be already connected or using ethernet
+		}
+	}
+
+	connectivity := verifyConnectivity(wifi.Network, serverURL)
+
+	publicKey, err := fetchProvisioningKey(serverURL)
+	if err != nil {
+		slog.Error("Failed to get provisioning key", "error", err)
+		return nil
+	}
+	if trust != nil && trust.PublicKey != base64.StdEncoding.EncodeToString(publicKey) {
+		slog.Error("Server provisioning key doesn't match the pinned key")
+		return nil
+	}
+
+	register := &jwtmsg.RegisterCamera{
+		ClaimCode:    jwtmsg.NormalizeShortCode(code),
+		Connectivity: connectivity,
 	}
+	return registerCamera(serverURL, register, trust, publicKey)
+}
+
+// processSetupCode accepts either a CameraAdd token or a claim code, as a
+// <server>/claim/<code> URL or bare. A bare code is for serverURL, or for the
+// pinned server when serverURL is empty.
+func processSetupCode(setupCode, serverURL string, wifi wifiInput) *config.Config {
+	if claimServer, code, ok := jwtmsg.ParseClaimURL(setupCode); ok {
+		return processClaimCode(claimServer, code, wifi)
+	}
+
+	if jwtmsg.IsShortCode(setupCode) {
+		if serverURL == "" {
+			trust, err := config.LoadServerTrust(config.DataPath(config.TrustFile))
+			if err != nil || trust == nil {
+				slog.Error("A bare claim code needs a server URL, enter it with the code or scan the claim QR code instead")
+				return nil
+			}
+			serverURL = trust.ServerURL
+		}
+		return processClaimCode(serverURL, setupCode, wifi)
+	}
+
+	return processJWT(setupCode, wifi)
+}
 
-	url, err := url.Parse(claims.ServerURL)
+// hasPinnedServer reports whether the camera already trusts a server
+func hasPinnedServer() bool {
+	trust, err := config.LoadServerTrust(config.DataPath(config.TrustFile))
+	return err == nil && trust != nil
+}
+
+// registerCamera registers a new camera UUID with the server and pins the
+// server key if this is the first registration
+func registerCamera(serverURL string, register *jwtmsg.RegisterCamera, trust *config.ServerTrust, publicKey ed25519.PublicKey) *config.Config {
+	// Generate a UUID for this camera
+	cameraUUID := uuid.New()
+	register.CameraUUID = cameraUUID.String()
+
+	url, err := url.Parse(serverURL)
 	if err != nil {
 		slog.Error("Failed to parse URL", "error", err)
 		return nil
@@ -231,17 +476,42 @@ func processJWT(jwtToken string) *config.Config {
 		return nil
 	}
 
+	defer resp.Body.Close()
+
 	if resp.StatusCode != http.StatusCreated {
 		slog.Error("Unexpected status code", "status", resp.StatusCode)
 		return nil
 	}
-	jwtToken = resp.Header.Get("Authorization")
+	authToken := resp.Header.Get("Authorization")
+
+	var tokens jwtmsg.CameraTokens
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		slog.Error("Failed to decode camera credentials", "error", err)
+		return nil
+	}
+
+	if trust == nil {
+		trust = &config.ServerTrust{
+			ServerURL: serverURL,
+			PublicKey: base64.StdEncoding.EncodeToString(publicKey),
+		}
+		if err := trust.SaveServerTrust(config.DataPath(config.TrustFile)); err != nil {
+			slog.Error("Failed to pin server key", "error", err)
+		}
+	}
+
+	name := register.FriendlyName
+	if tokens.FriendlyName != "" {
+		name = tokens.FriendlyName
+	}
 
 	return &config.Config{
-		Addr:       claims.ServerURL,
-		CameraUuid: cameraUUID.String(),
-		CameraName: claims.FriendlyName,
-		Token:      jwtToken,
+		Addr:           serverURL,
+		CameraUuid:     register.CameraUUID,
+		CameraName:     name,
+		Token:          authToken,
+		RefreshToken:   tokens.RefreshToken,
+		TokenExpiresAt: tokens.ExpiresAt,
 	}
 }
 
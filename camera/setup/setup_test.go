@@ -0,0 +1,117 @@
+package setup
+
+import (
+	"camera/config"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"messages/jwtmsg"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// keyServer serves publicKey as provisioning key, failing the first
+// unavailable requests
+func keyServer(t *testing.T, publicKey ed25519.PublicKey, unavailable int32) *httptest.Server {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= unavailable {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(jwtmsg.ProvisioningKey{
+			Algorithm: jwt.SigningMethodEdDSA.Alg(),
+			PublicKey: base64.StdEncoding.EncodeToString(publicKey),
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func signCameraAdd(t *testing.T, key ed25519.PrivateKey, serverURL string) string {
+	claims := jwtmsg.CameraAdd{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		ServerURL:        serverURL,
+		WifiNetwork:      "home",
+		WifiPassword:     "password123",
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func useMockNetwork(t *testing.T) *MockNetworkManager {
+	mock := NewMockNetworkManager()
+	previous := networkManager
+	SetNetworkManager(mock)
+	t.Cleanup(func() { SetNetworkManager(previous) })
+
+	if err := config.SetDataDir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	return mock
+}
+
+func TestProcessJWTRejectsOtherServer(t *testing.T) {
+	mock := useMockNetwork(t)
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	trust := &config.ServerTrust{
+		ServerURL: "https://pinned.example",
+		PublicKey: base64.StdEncoding.EncodeToString(publicKey),
+	}
+	if err := trust.SaveServerTrust(config.DataPath(config.TrustFile)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Signed by the pinned key, but naming another server
+	token := signCameraAdd(t, privateKey, "https://other.example")
+	if cfg := processJWT(token, wifiInput{}); cfg != nil {
+		t.Fatal("token for another server accepted")
+	}
+	if calls := mock.ConnectCalls(); len(calls) != 0 {
+		t.Fatalf("Wi-Fi changed for a rejected token: %v", calls)
+	}
+}
+
+func TestProcessJWTVerifiesBeforeWifi(t *testing.T) {
+	mock := useMockNetwork(t)
+	serverKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	server := keyServer(t, serverKey, 0)
+	token := signCameraAdd(t, otherKey, server.URL)
+	if cfg := processJWT(token, wifiInput{}); cfg != nil {
+		t.Fatal("token with a bad signature accepted")
+	}
+	if calls := mock.ConnectCalls(); len(calls) != 0 {
+		t.Fatalf("Wi-Fi changed before the token was verified: %v", calls)
+	}
+}
+
+func TestProcessJWTForgetsUnverifiedNetwork(t *testing.T) {
+	mock := useMockNetwork(t)
+	serverKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	// The server is only reachable after joining the token's network
+	server := keyServer(t, serverKey, 1)
+	token := signCameraAdd(t, otherKey, server.URL)
+	if cfg := processJWT(token, wifiInput{}); cfg != nil {
+		t.Fatal("token with a bad signature accepted")
+	}
+	if calls := mock.ConnectCalls(); len(calls) != 1 || calls[0] != "home" {
+		t.Fatalf("Connect calls = %v, want [home]", calls)
+	}
+	if forgotten := mock.Forgotten(); len(forgotten) != 1 || forgotten[0] != "home" {
+		t.Fatalf("Forget calls = %v, want [home]", forgotten)
+	}
+}
@@ -0,0 +1,73 @@
+package setup
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"messages/jwtmsg"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// verifyProvisioningToken checks the CameraAdd token's signature and expiry against the server key
+func verifyProvisioningToken(jwtToken string, publicKey ed25519.PublicKey) (*jwtmsg.CameraAdd, error) {
+	claims := &jwtmsg.CameraAdd{}
+	_, err := jwt.ParseWithClaims(jwtToken, claims, func(token *jwt.Token) (interface{}, error) {
+		return publicKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("invalid provisioning token: %w", err)
+	}
+	return claims, nil
+}
+
+// checkExpiry rejects tokens without an expiry or past it, before the signature can be checked
+func checkExpiry(claims *jwtmsg.CameraAdd) error {
+	if claims.ExpiresAt == nil {
+		return errors.New("provisioning token has no expiry")
+	}
+	if time.Now().After(claims.ExpiresAt.Time) {
+		return errors.New("provisioning token expired")
+	}
+	return nil
+}
+
+// fetchProvisioningKey downloads the server's provisioning key from its well-known endpoint
+func fetchProvisioningKey(serverURL string) (ed25519.PublicKey, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(strings.TrimSuffix(serverURL, "/") + jwtmsg.ProvisioningKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch provisioning key: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var key jwtmsg.ProvisioningKey
+	if err := json.NewDecoder(resp.Body).Decode(&key); err != nil {
+		return nil, fmt.Errorf("failed to decode provisioning key: %w", err)
+	}
+
+	if key.Algorithm != jwt.SigningMethodEdDSA.Alg() {
+		return nil, fmt.Errorf("unsupported provisioning key algorithm: %s", key.Algorithm)
+	}
+	return decodePublicKey(key.PublicKey)
+}
+
+func decodePublicKey(encoded string) (ed25519.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key size: %d", len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
@@ -0,0 +1,243 @@
+package setup
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// wpaConfigHeader starts a wpa_supplicant config written from scratch
+const wpaConfigHeader = "ctrl_interface=/var/run/wpa_supplicant\nupdate_config=1\n"
+
+// WPASupplicantManager configures Wi-Fi by adding a network block to the
+// wpa_supplicant config file and asking the running daemon to reload it
+// through wpa_cli. Everything else in the file, ctrl_interface, country and
+// the other networks, is kept. The SSID is written hex encoded and the
+// passphrase is turned into a raw PSK, so no user supplied string ever ends
+// up quoted in the file.
+type WPASupplicantManager struct {
+	iface      string
+	configPath string
+}
+
+// NewWPASupplicantManager creates a wpa_supplicant backend for the given interface and config file
+func NewWPASupplicantManager(iface, configPath string) *WPASupplicantManager {
+	if iface == "" {
+		iface = "wlan0"
+	}
+	if configPath == "" {
+		configPath = "/etc/wpa_supplicant.conf"
+	}
+	return &WPASupplicantManager{iface: iface, configPath: configPath}
+}
+
+// Connect adds the network, or replaces the block of a network with the same
+// SSID, preferring it over the others
+func (m *WPASupplicantManager) Connect(ctx context.Context, ssid, password string) error {
+	current, err := os.ReadFile(m.configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		current = []byte(wpaConfigHeader)
+	} else if err != nil {
+		return fmt.Errorf("failed to read wpa_supplicant config: %w", err)
+	}
+
+	config, priority := removeNetwork(string(current), ssid)
+	block, err := wpaNetworkBlock(ssid, password, priority+1)
+	if err != nil {
+		return err
+	}
+	if !strings.HasSuffix(config, "\n") {
+		config += "\n"
+	}
+	return m.apply(ctx, config+"\n"+block)
+}
+
+// Forget removes the network block of the SSID
+func (m *WPASupplicantManager) Forget(ctx context.Context, ssid string) error {
+	current, err := os.ReadFile(m.configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read wpa_supplicant config: %w", err)
+	}
+
+	config, _ := removeNetwork(string(current), ssid)
+	if config == string(current) {
+		return nil
+	}
+	return m.apply(ctx, config)
+}
+
+// apply replaces the config file and makes the daemon reload it
+func (m *WPASupplicantManager) apply(ctx context.Context, config string) error {
+	tmp := m.configPath + ".tmp"
+	if err := os.WriteFile(tmp, []byte(config), 0600); err != nil {
+		return fmt.Errorf("failed to write wpa_supplicant config: %w", err)
+	}
+	if err := os.Rename(tmp, m.configPath); err != nil {
+		return fmt.Errorf("failed to replace wpa_supplicant config: %w", err)
+	}
+
+	output, err := m.cli(ctx, "reconfigure")
+	if err != nil {
+		return fmt.Errorf("wpa_cli reconfigure failed: %w: %s", err, output)
+	}
+	return nil
+}
+
+func (m *WPASupplicantManager) Scan(ctx context.Context) ([]AccessPoint, error) {
+	if output, err := m.cli(ctx, "scan"); err != nil {
+		return nil, fmt.Errorf("wpa_cli scan failed: %w: %s", err, output)
+	}
+
+	output, err := m.cli(ctx, "scan_results")
+	if err != nil {
+		return nil, fmt.Errorf("wpa_cli scan_results failed: %w: %s", err, output)
+	}
+
+	// bssid / frequency / signal level / flags / ssid
+	seen := make(map[string]bool)
+	var networks []AccessPoint
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(line, "\t", 5)
+		if len(fields) != 5 || fields[4] == "" || seen[fields[4]] {
+			continue
+		}
+		seen[fields[4]] = true
+
+		dbm, _ := strconv.Atoi(fields[2])
+		networks = append(networks, AccessPoint{
+			SSID:     fields[4],
+			Signal:   dbmToQuality(dbm),
+			Security: fields[3],
+		})
+	}
+	return networks, nil
+}
+
+func (m *WPASupplicantManager) Status(ctx context.Context) (*NetworkStatus, error) {
+	output, err := m.cli(ctx, "status")
+	if err != nil {
+		return nil, fmt.Errorf("wpa_cli status failed: %w: %s", err, output)
+	}
+
+	values := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			values[key] = value
+		}
+	}
+
+	status := &NetworkStatus{
+		Connected: values["wpa_state"] == "COMPLETED",
+		SSID:      values["ssid"],
+		IPAddress: values["ip_address"],
+	}
+	if status.Connected && status.IPAddress == "" {
+		status.IPAddress = interfaceAddress(m.iface)
+	}
+	return status, nil
+}
+
+func (m *WPASupplicantManager) cli(ctx context.Context, args ...string) (string, error) {
+	args = append([]string{"-i", m.iface}, args...)
+	output, err := exec.CommandContext(ctx, "wpa_cli", args...).CombinedOutput()
+	return strings.TrimSpace(string(output)), err
+}
+
+// wpaNetworkBlock renders the network block of one network
+func wpaNetworkBlock(ssid, password string, priority int) (string, error) {
+	if len(ssid) == 0 || len(ssid) > 32 {
+		return "", fmt.Errorf("invalid SSID length: %d", len(ssid))
+	}
+
+	var b strings.Builder
+	b.WriteString("network={\n")
+	fmt.Fprintf(&b, "\tssid=%s\n", hex.EncodeToString([]byte(ssid)))
+	if password == "" {
+		b.WriteString("\tkey_mgmt=NONE\n")
+	} else {
+		if len(password) < 8 || len(password) > 63 {
+			return "", fmt.Errorf("WPA passphrase must be 8-63 characters, got %d", len(password))
+		}
+		psk, err := pbkdf2.Key(sha1.New, password, []byte(ssid), 4096, 32)
+		if err != nil {
+			return "", fmt.Errorf("failed to derive PSK: %w", err)
+		}
+		fmt.Fprintf(&b, "\tpsk=%s\n", hex.EncodeToString(psk))
+	}
+	fmt.Fprintf(&b, "\tpriority=%d\n", priority)
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+// removeNetwork drops the network blocks of an SSID from a wpa_supplicant
+// config, keeping every other line as it was. It also returns the highest
+// priority of the networks left.
+func removeNetwork(config, ssid string) (string, int) {
+	hexSSID := hex.EncodeToString([]byte(ssid))
+	var kept, block []string
+	inBlock, matches, priority := false, false, 0
+	blockPriority := 0
+
+	for _, line := range strings.SplitAfter(config, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !inBlock {
+			if strings.HasPrefix(trimmed, "network=") && strings.HasSuffix(trimmed, "{") {
+				inBlock, matches, blockPriority = true, false, 0
+				block = []string{line}
+				continue
+			}
+			kept = append(kept, line)
+			continue
+		}
+
+		block = append(block, line)
+		if key, value, ok := strings.Cut(trimmed, "="); ok {
+			switch key {
+			case "ssid":
+				matches = value == hexSSID || value == `"`+ssid+`"`
+			case "priority":
+				blockPriority, _ = strconv.Atoi(value)
+			}
+		}
+		if trimmed == "}" {
+			inBlock = false
+			if !matches {
+				kept = append(kept, block...)
+				priority = max(priority, blockPriority)
+			}
+		}
+	}
+	if inBlock {
+		// Unterminated block, leave it alone
+		kept = append(kept, block...)
+	}
+
+	config = strings.Join(kept, "")
+	// Don't leave the blank line that separated a removed block behind
+	for strings.HasSuffix(config, "\n\n") {
+		config = strings.TrimSuffix(config, "\n")
+	}
+	return config, priority
+}
+
+// dbmToQuality maps a signal level in dBm to 0-100
+func dbmToQuality(dbm int) int {
+	switch {
+	case dbm <= -100:
+		return 0
+	case dbm >= -50:
+		return 100
+	default:
+		return 2 * (dbm + 100)
+	}
+}
@@ -0,0 +1,130 @@
+package setup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const existingWPAConfig = `ctrl_interface=/var/run/wpa_supplicant
+update_config=1
+country=DE
+
+network={
+	ssid="office"
+	psk="officepassword"
+	priority=3
+}
+
+network={
+	ssid=686f6d65
+	psk=0000000000000000000000000000000000000000000000000000000000000000
+	priority=1
+}
+`
+
+func TestWPANetworkBlockPSK(t *testing.T) {
+	// Test vector from IEEE 802.11i, annex H.4
+	block, err := wpaNetworkBlock("IEEE", "password", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "psk=f42c6fc52df0ebef9ebb4b90b38a5f902e83fe1b135a70e23aed762e9710a12e"
+	if !strings.Contains(block, want) {
+		t.Fatalf("block %q does not contain %s", block, want)
+	}
+	if !strings.Contains(block, "ssid=49454545") {
+		t.Fatalf("block %q does not contain the hex SSID", block)
+	}
+
+	if _, err := wpaNetworkBlock("IEEE", "short", 1); err == nil {
+		t.Fatal("expected an error for a short passphrase")
+	}
+	if _, err := wpaNetworkBlock("", "password", 1); err == nil {
+		t.Fatal("expected an error for an empty SSID")
+	}
+}
+
+func TestRemoveNetwork(t *testing.T) {
+	// Hex encoded SSID
+	config, priority := removeNetwork(existingWPAConfig, "home")
+	if strings.Contains(config, "686f6d65") {
+		t.Fatalf("home network not removed:\n%s", config)
+	}
+	if !strings.Contains(config, "country=DE") || !strings.Contains(config, `ssid="office"`) {
+		t.Fatalf("other lines not kept:\n%s", config)
+	}
+	if priority != 3 {
+		t.Fatalf("priority = %d, want 3", priority)
+	}
+
+	// Quoted SSID
+	config, priority = removeNetwork(existingWPAConfig, "office")
+	if strings.Contains(config, "office") || !strings.Contains(config, "686f6d65") {
+		t.Fatalf("office network not removed alone:\n%s", config)
+	}
+	if priority != 1 {
+		t.Fatalf("priority = %d, want 1", priority)
+	}
+
+	// Unknown SSID
+	config, _ = removeNetwork(existingWPAConfig, "cafe")
+	if config != existingWPAConfig {
+		t.Fatalf("config changed:\n%s", config)
+	}
+}
+
+func TestWPASupplicantConnect(t *testing.T) {
+	dir := t.TempDir()
+	// Stand-in for wpa_cli that records its arguments
+	script := "#!/bin/sh\necho \"$@\" >> " + filepath.Join(dir, "calls") + "\necho OK\n"
+	if err := os.WriteFile(filepath.Join(dir, "wpa_cli"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	configPath := filepath.Join(dir, "wpa_supplicant.conf")
+	if err := os.WriteFile(configPath, []byte(existingWPAConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewWPASupplicantManager("wlan0", configPath)
+	if err := manager.Connect(context.Background(), "home", "newpassword"); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := string(data)
+	if strings.Count(config, "ssid=686f6d65") != 1 || strings.Contains(config, "psk=0000") {
+		t.Fatalf("home network not replaced:\n%s", config)
+	}
+	if !strings.Contains(config, "country=DE") || !strings.Contains(config, `psk="officepassword"`) {
+		t.Fatalf("other networks not kept:\n%s", config)
+	}
+	if !strings.Contains(config, "priority=4") {
+		t.Fatalf("new network not preferred:\n%s", config)
+	}
+
+	calls, err := os.ReadFile(filepath.Join(dir, "calls"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(calls)) != "-i wlan0 reconfigure" {
+		t.Fatalf("wpa_cli calls = %q", calls)
+	}
+
+	// A missing config is created with the default header
+	os.Remove(configPath)
+	if err := manager.Connect(context.Background(), "open", ""); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	data, _ = os.ReadFile(configPath)
+	if !strings.HasPrefix(string(data), wpaConfigHeader) || !strings.Contains(string(data), "key_mgmt=NONE") {
+		t.Fatalf("unexpected new config:\n%s", data)
+	}
+}
@@ -0,0 +1,70 @@
+package stream
+
+import (
+	"sync"
+	"time"
+)
+
+// StreamStats are the frame and data rates of a media stream
+type StreamStats struct {
+	FPS     float64
+	Bitrate float64 // bits per second
+}
+
+// meter counts frames read from a stream socket
+type meter struct {
+	mutex      sync.Mutex
+	frames     uint64
+	bytes      uint64
+	lastSample time.Time
+	lastFrames uint64
+	lastBytes  uint64
+	stats      StreamStats
+}
+
+var (
+	meters     = make(map[MediaType]*meter)
+	metersLock sync.Mutex
+)
+
+func meterFor(mediaType MediaType) *meter {
+	metersLock.Lock()
+	defer metersLock.Unlock()
+
+	m, ok := meters[mediaType]
+	if !ok {
+		m = &meter{lastSample: time.Now()}
+		meters[mediaType] = m
+	}
+	return m
+}
+
+func (m *meter) record(size int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.frames++
+	m.bytes += uint64(size)
+}
+
+// Stats returns the average rates of the stream since the previous call,
+// samples closer than a second apart return the previous rates
+func Stats(mediaType MediaType) StreamStats {
+	m := meterFor(mediaType)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(m.lastSample).Seconds()
+	if elapsed < 1 {
+		return m.stats
+	}
+
+	m.stats = StreamStats{
+		FPS:     float64(m.frames-m.lastFrames) / elapsed,
+		Bitrate: float64(m.bytes-m.lastBytes) * 8 / elapsed,
+	}
+	m.lastSample = now
+	m.lastFrames = m.frames
+	m.lastBytes = m.bytes
+	return m.stats
+}
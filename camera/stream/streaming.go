@@ -47,6 +47,7 @@ func connectToUnixSocket(ctx context.Context, socketPath string, callback Packet
 
 	inboundPacket := make([]byte, maxFrameSize)
 	lastFrame := time.Now()
+	meter := meterFor(mediaType)
 
 	// Create a channel for socket error handling
 	errCh := make(chan error, 1)
@@ -87,6 +88,7 @@ func connectToUnixSocket(ctx context.Context, socketPath string, callback Packet
 		now := time.Now()
 		sinceLastFrame := now.Sub(lastFrame)
 		lastFrame = now
+		meter.record(n)
 
 		// Create a copy of the packet to prevent data race when processing asynchronously
 		packetCopy := make([]byte, n)
@@ -0,0 +1,210 @@
+package telemetry
+
+import (
+	"camera/config"
+	"camera/record"
+	"camera/stream"
+	"camera/websocket"
+	"context"
+	"io/fs"
+	"log/slog"
+	pb "messages/msgspb"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultInterval is how often a telemetry sample is sent
+	DefaultInterval = 30 * time.Second
+	// sampleTTL drops samples that couldn't be delivered for this long
+	sampleTTL  = time.Hour
+	thermalDir = "/sys/class/thermal"
+)
+
+// Collector samples the camera's health and sends it to the server
+type Collector struct {
+	config   *config.Config
+	outbox   *websocket.OutboundQueue
+	viewers  func() int
+	storage  func() record.Usage
+	interval time.Duration
+
+	lastIdle  uint64
+	lastTotal uint64
+}
+
+// NewCollector creates a collector, viewers returns the current stream viewer
+// count and storage the recording usage seen by the retention policy
+func NewCollector(cfg *config.Config, outbox *websocket.OutboundQueue, viewers func() int, storage func() record.Usage) *Collector {
+	return &Collector{
+		config:   cfg,
+		outbox:   outbox,
+		viewers:  viewers,
+		storage:  storage,
+		interval: DefaultInterval,
+	}
+}
+
+// Run sends a sample every interval until the context is cancelled
+func (c *Collector) Run(ctx context.Context) {
+	// Prime the CPU counters so the first sample has a usage value
+	c.cpuUsage()
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := c.outbox.Send(&pb.Message{
+				From:     c.config.CameraUuid,
+				To:       "server",
+				DataType: &pb.Message_Telemetry{Telemetry: c.Collect()},
+			}, websocket.PriorityLow, sampleTTL)
+			if err != nil {
+				slog.Error("Failed to send telemetry", "error", err)
+			}
+		}
+	}
+}
+
+// Collect takes a telemetry sample, values that can't be read are left at zero
+func (c *Collector) Collect() *pb.Telemetry {
+	streamStats := stream.Stats(stream.H264Media)
+	sample := &pb.Telemetry{
+		Timestamp:       time.Now().Unix(),
+		UptimeSeconds:   uptime(),
+		LoadAverage:     loadAverage(),
+		CpuUsage:        c.cpuUsage(),
+		SocTemperature:  socTemperature(),
+		StreamFps:       streamStats.FPS,
+		StreamBitrate:   streamStats.Bitrate,
+		RecordingsBytes: dirSize(c.config.RecordDir),
+	}
+
+	var fsStats syscall.Statfs_t
+	if err := syscall.Statfs(c.config.RecordDir, &fsStats); err == nil {
+		sample.DiskTotalBytes = int64(fsStats.Blocks) * int64(fsStats.Bsize)
+		sample.DiskUsedBytes = sample.DiskTotalBytes - int64(fsStats.Bfree)*int64(fsStats.Bsize)
+	}
+
+	if c.viewers != nil {
+		sample.Viewers = int32(c.viewers())
+	}
+	if c.storage != nil {
+		usage := c.storage()
+		sample.LockedBytes = usage.LockedBytes
+		sample.RetentionDeletedBytes = usage.DeletedBytes
+		if usage.Oldest > 0 {
+			sample.OldestRecording = usage.Oldest / 1000
+		}
+	}
+	return sample
+}
+
+// uptime reads the system uptime in seconds from /proc/uptime
+func uptime() int64 {
+	data, err := os.ReadFile("/proc/uptime")
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0
+	}
+	seconds, _ := strconv.ParseFloat(fields[0], 64)
+	return int64(seconds)
+}
+
+// loadAverage reads the 1 minute load average from /proc/loadavg
+func loadAverage() float64 {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0
+	}
+	load, _ := strconv.ParseFloat(fields[0], 64)
+	return load
+}
+
+// cpuUsage returns the percentage of CPU time spent busy since the previous call
+func (c *Collector) cpuUsage() float64 {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return 0
+	}
+
+	line, _, _ := strings.Cut(string(data), "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0
+	}
+
+	var idle, total uint64
+	for i, field := range fields[1:] {
+		value, _ := strconv.ParseUint(field, 10, 64)
+		total += value
+		// idle and iowait
+		if i == 3 || i == 4 {
+			idle += value
+		}
+	}
+
+	deltaIdle := idle - c.lastIdle
+	deltaTotal := total - c.lastTotal
+	c.lastIdle, c.lastTotal = idle, total
+	if deltaTotal == 0 {
+		return 0
+	}
+	return 100 * float64(deltaTotal-deltaIdle) / float64(deltaTotal)
+}
+
+// socTemperature returns the hottest thermal zone in degrees Celsius
+func socTemperature() float64 {
+	zones, err := filepath.Glob(filepath.Join(thermalDir, "thermal_zone*", "temp"))
+	if err != nil {
+		return 0
+	}
+
+	var hottest float64
+	for _, zone := range zones {
+		data, err := os.ReadFile(zone)
+		if err != nil {
+			continue
+		}
+		milli, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+		if err != nil {
+			continue
+		}
+		if celsius := milli / 1000; celsius > hottest {
+			hottest = celsius
+		}
+	}
+	return hottest
+}
+
+// dirSize returns the total size of the files under dir
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,415 @@
+package update
+
+import (
+	"camera/config"
+	"camera/websocket"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"messages/jwtmsg"
+	pb "messages/msgspb"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Version is the running agent version, set at build time with
+// -ldflags "-X camera/update.Version=<version>"
+var Version = "dev"
+
+// ReleaseKey is the base64 encoded Ed25519 key releases are signed with
+// offline, set at build time with -ldflags "-X camera/update.ReleaseKey=<key>".
+// Builds without one don't install updates.
+var ReleaseKey = ""
+
+// StateFile, in the data directory, tracks an installed update until the new
+// version has proven it can reconnect
+const StateFile = "update.json"
+
+const (
+	// confirmTimeout is how long a new version has to reconnect before it is rolled back
+	confirmTimeout = 2 * time.Minute
+	// statusTTL is how long an undelivered status report is kept
+	statusTTL = 24 * time.Hour
+	// restartDelay gives the status report time to reach the server before exec
+	restartDelay = 2 * time.Second
+)
+
+// state is persisted across the exec into the new binary
+type state struct {
+	Status          pb.UpdateStatus `json:"status"`
+	PreviousVersion string          `json:"previousVersion"`
+	TargetVersion   string          `json:"targetVersion"`
+	Error           string          `json:"error,omitempty"`
+}
+
+// Updater installs signed agent releases pushed by the server and rolls them
+// back when the new version doesn't reconnect in time. A version that crashes
+// before that is rolled back by launcher.sh, which runs before the agent.
+type Updater struct {
+	config     *config.Config
+	outbox     *websocket.OutboundQueue
+	binaryPath string
+	statePath  string
+
+	mutex      sync.Mutex
+	installing bool
+	state      *state
+	confirmed  chan struct{}
+}
+
+// NewUpdater creates an updater for the running binary
+func NewUpdater(cfg *config.Config, outbox *websocket.OutboundQueue) (*Updater, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find agent binary: %w", err)
+	}
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve agent binary: %w", err)
+	}
+
+	u := &Updater{
+		config:     cfg,
+		outbox:     outbox,
+		binaryPath: exe,
+		statePath:  config.DataPath(StateFile),
+		confirmed:  make(chan struct{}),
+	}
+
+	if err := u.loadState(); err != nil {
+		slog.Error("Failed to load update state", "error", err)
+	}
+
+	// The launcher put the previous binary back because the new one kept
+	// failing to start, report it from here
+	if u.state != nil && u.state.Status == pb.UpdateStatus_UPDATE_STATUS_INSTALLING && Version != u.state.TargetVersion {
+		if _, err := os.Stat(u.backupPath()); errors.Is(err, os.ErrNotExist) {
+			u.state.Status = pb.UpdateStatus_UPDATE_STATUS_ROLLED_BACK
+			u.state.Error = fmt.Sprintf("version %s failed to start", u.state.TargetVersion)
+			if err := u.saveState(); err != nil {
+				slog.Error("Failed to save update state", "error", err)
+			}
+		}
+	}
+
+	return u, nil
+}
+
+// RegisterHandlers registers the release handler with the dispatcher
+func (u *Updater) RegisterHandlers(d *websocket.Dispatcher) {
+	d.Register(&pb.Message_AgentRelease{}, func(msg *pb.Message) error {
+		return u.handleRelease(msg.GetAgentRelease())
+	})
+}
+
+// Start rolls back a pending update if the new version doesn't reconnect in time
+func (u *Updater) Start(ctx context.Context) {
+	u.mutex.Lock()
+	pending := u.state != nil && u.state.Status == pb.UpdateStatus_UPDATE_STATUS_INSTALLING
+	u.mutex.Unlock()
+	if !pending {
+		return
+	}
+
+	slog.Info("Waiting for new version to reconnect", "version", Version, "timeout", confirmTimeout)
+	select {
+	case <-u.confirmed:
+	case <-ctx.Done():
+	case <-time.After(confirmTimeout):
+		u.rollback(fmt.Sprintf("version %s did not reconnect within %s", Version, confirmTimeout))
+	}
+}
+
+// Connected confirms a pending update and reports the agent status, it is
+// called every time the websocket connects
+func (u *Updater) Connected() {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	status := &pb.AgentStatus{
+		Version: Version,
+		Arch:    runtime.GOARCH,
+	}
+
+	if u.state != nil {
+		status.TargetVersion = u.state.TargetVersion
+		status.UpdateStatus = u.state.Status
+		status.Error = u.state.Error
+
+		if u.state.Status == pb.UpdateStatus_UPDATE_STATUS_INSTALLING {
+			if Version != u.state.TargetVersion {
+				// Still on the old binary, the restart never happened
+				return
+			}
+			status.UpdateStatus = pb.UpdateStatus_UPDATE_STATUS_SUCCEEDED
+			close(u.confirmed)
+			os.Remove(u.backupPath())
+			slog.Info("Update confirmed", "version", Version, "previous", u.state.PreviousVersion)
+		}
+
+		u.state = nil
+		if err := os.Remove(u.statePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Error("Failed to remove update state", "error", err)
+		}
+	}
+
+	u.report(status)
+}
+
+func (u *Updater) handleRelease(release *pb.AgentRelease) error {
+	if release.Version == Version {
+		slog.Info("Release already installed", "version", release.Version)
+		return nil
+	}
+
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	if u.installing {
+		return errors.New("update already in progress")
+	}
+	if u.state != nil && u.state.Status == pb.UpdateStatus_UPDATE_STATUS_INSTALLING {
+		return errors.New("previous update not confirmed yet")
+	}
+	u.installing = true
+
+	// Downloads can take a while, don't hold up the dispatcher
+	go u.install(release)
+	return nil
+}
+
+// install downloads, verifies and swaps in the release, then execs it
+func (u *Updater) install(release *pb.AgentRelease) {
+	slog.Info("Installing agent update", "version", release.Version, "current", Version)
+	u.reportProgress(release, pb.UpdateStatus_UPDATE_STATUS_DOWNLOADING, nil)
+
+	if err := u.installRelease(release); err != nil {
+		slog.Error("Agent update failed", "version", release.Version, "error", err)
+		os.Remove(u.binaryPath + ".new")
+		u.reportProgress(release, pb.UpdateStatus_UPDATE_STATUS_FAILED, err)
+
+		u.mutex.Lock()
+		u.installing = false
+		u.mutex.Unlock()
+		return
+	}
+
+	u.reportProgress(release, pb.UpdateStatus_UPDATE_STATUS_INSTALLING, nil)
+	if err := u.restart(); err != nil {
+		// Put the old binary back so the next start isn't an unconfirmed update
+		if err := os.Rename(u.backupPath(), u.binaryPath); err != nil {
+			slog.Error("Failed to restore previous binary", "error", err)
+		}
+
+		u.mutex.Lock()
+		u.state = nil
+		os.Remove(u.statePath)
+		u.installing = false
+		u.mutex.Unlock()
+		u.reportProgress(release, pb.UpdateStatus_UPDATE_STATUS_FAILED, err)
+	}
+}
+
+func (u *Updater) installRelease(release *pb.AgentRelease) error {
+	if release.Arch != runtime.GOARCH {
+		return fmt.Errorf("release is for %s, this camera is %s", release.Arch, runtime.GOARCH)
+	}
+
+	// Releases are signed offline, the server can't sign a build of its own
+	if ReleaseKey == "" {
+		return errors.New("this build has no release key, updates are disabled")
+	}
+	publicKey, err := base64.StdEncoding.DecodeString(ReleaseKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return errors.New("invalid release key")
+	}
+
+	payload := jwtmsg.ReleaseSigningPayload(release.Version, release.Arch, release.Sha256, release.Size)
+	if !ed25519.Verify(publicKey, payload, release.Signature) {
+		return errors.New("invalid release signature")
+	}
+
+	newPath := u.binaryPath + ".new"
+	if err := u.download(release, newPath); err != nil {
+		return err
+	}
+
+	// Keep the running binary for rollback, then swap atomically
+	backup := u.backupPath()
+	os.Remove(backup)
+	if err := os.Link(u.binaryPath, backup); err != nil {
+		return fmt.Errorf("failed to back up current binary: %w", err)
+	}
+
+	u.mutex.Lock()
+	u.state = &state{
+		Status:          pb.UpdateStatus_UPDATE_STATUS_INSTALLING,
+		PreviousVersion: Version,
+		TargetVersion:   release.Version,
+	}
+	err = u.saveState()
+	u.mutex.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to save update state: %w", err)
+	}
+
+	if err := os.Rename(newPath, u.binaryPath); err != nil {
+		u.mutex.Lock()
+		u.state = nil
+		os.Remove(u.statePath)
+		u.mutex.Unlock()
+		return fmt.Errorf("failed to replace binary: %w", err)
+	}
+	return nil
+}
+
+// download fetches the release binary and checks its size and digest
+func (u *Updater) download(release *pb.AgentRelease, path string) error {
+	req, err := http.NewRequest("GET", u.config.Addr+release.Url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", u.config.AuthToken())
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download release: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d %s", resp.StatusCode, resp.Status)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create binary: %w", err)
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, hash), io.LimitReader(resp.Body, release.Size+1))
+	if err != nil {
+		return fmt.Errorf("failed to download release: %w", err)
+	}
+	if size != release.Size {
+		return fmt.Errorf("release size mismatch: got %d, expected %d", size, release.Size)
+	}
+	if digest := hex.EncodeToString(hash.Sum(nil)); digest != release.Sha256 {
+		return fmt.Errorf("release digest mismatch: got %s", digest)
+	}
+
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to write binary: %w", err)
+	}
+	return f.Close()
+}
+
+// rollback restores the previous binary and execs it
+func (u *Updater) rollback(reason string) {
+	slog.Error("Rolling back agent update", "reason", reason)
+
+	u.mutex.Lock()
+	if err := os.Rename(u.backupPath(), u.binaryPath); err != nil {
+		slog.Error("Failed to restore previous binary", "error", err)
+		u.state.Status = pb.UpdateStatus_UPDATE_STATUS_FAILED
+		u.state.Error = fmt.Sprintf("%s, rollback failed: %v", reason, err)
+		u.saveState()
+		u.mutex.Unlock()
+		return
+	}
+
+	u.state.Status = pb.UpdateStatus_UPDATE_STATUS_ROLLED_BACK
+	u.state.Error = reason
+	if err := u.saveState(); err != nil {
+		slog.Error("Failed to save update state", "error", err)
+	}
+	u.mutex.Unlock()
+
+	if err := u.restart(); err != nil {
+		slog.Error("Failed to restart previous version", "error", err)
+	}
+}
+
+// restart replaces the running process with the binary on disk, it only
+// returns if the exec failed
+func (u *Updater) restart() error {
+	u.outbox.Flush()
+	time.Sleep(restartDelay)
+
+	slog.Info("Restarting agent", "path", u.binaryPath)
+	if err := syscall.Exec(u.binaryPath, os.Args, os.Environ()); err != nil {
+		return fmt.Errorf("failed to exec agent: %w", err)
+	}
+	return nil
+}
+
+func (u *Updater) reportProgress(release *pb.AgentRelease, status pb.UpdateStatus, err error) {
+	report := &pb.AgentStatus{
+		Version:       Version,
+		Arch:          runtime.GOARCH,
+		UpdateStatus:  status,
+		TargetVersion: release.Version,
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	u.report(report)
+}
+
+func (u *Updater) report(status *pb.AgentStatus) {
+	err := u.outbox.Send(&pb.Message{
+		From:     u.config.CameraUuid,
+		To:       "server",
+		DataType: &pb.Message_AgentStatus{AgentStatus: status},
+	}, websocket.PriorityHigh, statusTTL)
+	if err != nil {
+		slog.Error("Failed to send agent status", "error", err)
+	}
+}
+
+func (u *Updater) backupPath() string {
+	return u.binaryPath + ".old"
+}
+
+func (u *Updater) loadState() error {
+	data, err := os.ReadFile(u.statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var s state
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	u.state = &s
+	return nil
+}
+
+func (u *Updater) saveState() error {
+	data, err := json.MarshalIndent(u.state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := u.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, u.statePath)
+}
@@ -0,0 +1,51 @@
+package update
+
+import (
+	"camera/config"
+	"encoding/json"
+	pb "messages/msgspb"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestNewUpdaterReportsLauncherRollback(t *testing.T) {
+	if err := config.SetDataDir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	// launcher.sh put the previous binary back, the old version starts
+	pending := state{
+		Status:          pb.UpdateStatus_UPDATE_STATUS_INSTALLING,
+		PreviousVersion: Version,
+		TargetVersion:   "v2.0.0",
+	}
+	data, _ := json.Marshal(pending)
+	if err := os.WriteFile(config.DataPath(StateFile), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := NewUpdater(&config.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.statePath != config.DataPath(StateFile) {
+		t.Fatalf("state path %s is not in the data directory", u.statePath)
+	}
+	if u.state == nil || u.state.Status != pb.UpdateStatus_UPDATE_STATUS_ROLLED_BACK {
+		t.Fatalf("rollback not detected: %+v", u.state)
+	}
+}
+
+func TestInstallRequiresReleaseKey(t *testing.T) {
+	previous := ReleaseKey
+	ReleaseKey = ""
+	defer func() { ReleaseKey = previous }()
+
+	u := &Updater{binaryPath: t.TempDir() + "/sudocam"}
+	err := u.installRelease(&pb.AgentRelease{Version: "v2.0.0", Arch: runtime.GOARCH})
+	if err == nil || !strings.Contains(err.Error(), "no release key") {
+		t.Fatalf("release installed without a release key: %v", err)
+	}
+}
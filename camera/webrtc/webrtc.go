@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"sync"
 
 	"log/slog"
 	pb "messages/msgspb"
@@ -19,8 +20,13 @@ import (
 type WebRTCManager struct {
 	Websocket   *websocket.WebsocketManager
 	connections map[string]*webrtc.PeerConnection
-	videoTrack  webrtc.TrackLocal
+	connLock    sync.Mutex
+	videoTrack  *webrtc.TrackLocalStaticSample
 	mvt         *stepper.MovementManager
+
+	streamLock   sync.Mutex
+	streamCtx    context.Context // Streams are cancelled with it
+	streamCancel context.CancelFunc
 }
 
 func NewWebRTCManager(ws *websocket.WebsocketManager, mvt *stepper.MovementManager) *WebRTCManager {
@@ -31,15 +37,72 @@ func NewWebRTCManager(ws *websocket.WebsocketManager, mvt *stepper.MovementManag
 	}
 }
 
-func (manager *WebRTCManager) StartCamera() {
+// StartCamera connects to the video source, the stream runs until ctx is
+// cancelled or Close is called
+func (manager *WebRTCManager) StartCamera(ctx context.Context) {
 	videoTrack, videoTrackErr := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeH264}, "video", "sudocam")
 	if videoTrackErr != nil {
 		panic(videoTrackErr)
 	}
 
-	ctx := context.Background()
-	stream.CreateH264VideoStream(ctx, videoTrack)
+	manager.streamLock.Lock()
 	manager.videoTrack = videoTrack
+	manager.streamCtx = ctx
+	manager.streamLock.Unlock()
+	manager.RestartStream()
+}
+
+// RestartStream reconnects to the video source, viewers keep the same track
+// and pick up the new stream without renegotiating
+func (manager *WebRTCManager) RestartStream() error {
+	manager.streamLock.Lock()
+	defer manager.streamLock.Unlock()
+
+	if manager.videoTrack == nil {
+		return errors.New("camera not started")
+	}
+	if manager.streamCancel != nil {
+		manager.streamCancel()
+	}
+
+	ctx, cancel := context.WithCancel(manager.streamCtx)
+	manager.streamCancel = cancel
+	stream.CreateH264VideoStream(ctx, manager.videoTrack)
+	return nil
+}
+
+// Close stops the stream and hangs up on every viewer
+func (manager *WebRTCManager) Close() {
+	manager.streamLock.Lock()
+	if manager.streamCancel != nil {
+		manager.streamCancel()
+	}
+	manager.streamLock.Unlock()
+
+	manager.connLock.Lock()
+	connections := manager.connections
+	manager.connections = make(map[string]*webrtc.PeerConnection)
+	manager.connLock.Unlock()
+
+	for _, pc := range connections {
+		if err := pc.Close(); err != nil {
+			slog.Warn("Failed to close peer connection", "error", err)
+		}
+	}
+}
+
+// ViewerCount returns the number of peers currently receiving the stream
+func (manager *WebRTCManager) ViewerCount() int {
+	manager.connLock.Lock()
+	defer manager.connLock.Unlock()
+
+	viewers := 0
+	for _, pc := range manager.connections {
+		if pc.ConnectionState() == webrtc.PeerConnectionStateConnected {
+			viewers++
+		}
+	}
+	return viewers
 }
 
 func (manager *WebRTCManager) CreatePeerConnection(client_uuid string) *webrtc.PeerConnection {
@@ -121,6 +184,13 @@ func (manager *WebRTCManager) CreatePeerConnection(client_uuid string) *webrtc.P
 	return peerConnection
 }
 
+// RegisterHandlers registers the WebRTC signaling handler with the dispatcher
+func (manager *WebRTCManager) RegisterHandlers(d *websocket.Dispatcher) {
+	d.Register(&pb.Message_Webrtc{}, func(msg *pb.Message) error {
+		return manager.HandleMessage(msg.GetWebrtc(), msg.From)
+	})
+}
+
 func (manager *WebRTCManager) HandleMessage(msg *pb.Webrtc, from string) error {
 
 	if from == "" {
@@ -153,13 +223,17 @@ func (manager *WebRTCManager) HandleMessage(msg *pb.Webrtc, from string) error {
 		}
 		slog.Debug("Sent Answer")
 
+		manager.connLock.Lock()
 		manager.connections[from] = peerConnection
+		manager.connLock.Unlock()
 
 	// Attempt to unmarshal as a ICECandidateInit. If the candidate field is empty
 	// assume it is not one.
 	case json.Unmarshal([]byte(msg.Data), &candidate) == nil && candidate.Candidate != "":
 		slog.Debug("Recieved ICE Candidate")
+		manager.connLock.Lock()
 		peerConnection := manager.connections[from]
+		manager.connLock.Unlock()
 		if peerConnection == nil {
 			panic("Recieved ICE Candicate but no pc")
 		}
@@ -0,0 +1,166 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	pb "messages/msgspb"
+	"messages/rpc"
+	"reflect"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// MessageHandler handles a single message received from the server. A
+// returned error is reported to the server by the dispatcher, handlers that
+// report their outcome themselves return nil.
+type MessageHandler func(msg *pb.Message) error
+
+// Dispatcher reads messages from the websocket and routes them to the handler
+// registered for their data type. Replies to requests the camera sent with
+// Call go to the caller instead.
+type Dispatcher struct {
+	ws       *WebsocketManager
+	handlers map[reflect.Type]MessageHandler
+	mutex    sync.RWMutex
+	calls    *rpc.Table
+}
+
+// NewDispatcher creates a dispatcher reading from the given websocket manager
+func NewDispatcher(ws *WebsocketManager) *Dispatcher {
+	return &Dispatcher{
+		ws:       ws,
+		handlers: make(map[reflect.Type]MessageHandler),
+		calls:    rpc.NewTable(ws.SendMessage),
+	}
+}
+
+// Register sets the handler for a message type. The type is identified by an
+// instance of the oneof wrapper, e.g. &pb.Message_HlsRequest{}.
+func (d *Dispatcher) Register(dataType any, handler MessageHandler) {
+	if dataType == nil {
+		panic("dispatcher: nil data type")
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.handlers[reflect.TypeOf(dataType)] = handler
+}
+
+// Run reads and dispatches messages until the context is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		msg, err := d.ws.ReadMessage()
+		if err != nil {
+			// The websocket manager reconnects on its own, wait before reading again
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		d.Dispatch(msg)
+	}
+}
+
+// Call sends a request to the server and waits for its reply, see
+// rpc.Table.Call. Replies are read by Run, so handlers must not wait on a Call.
+func (d *Dispatcher) Call(ctx context.Context, msg *pb.Message) (*pb.Message, error) {
+	msg.From = d.ws.config.CameraUuid
+	msg.To = "server"
+	return d.calls.Call(ctx, msg)
+}
+
+// Dispatch routes a single message to its handler. Unknown messages and
+// handler failures are reported back to the server, as an rpc error reply when
+// the message is a request and as a pb.Response otherwise.
+func (d *Dispatcher) Dispatch(msg *pb.Message) {
+	if msg.DataType == nil {
+		slog.Warn("Received message without data", "from", msg.From)
+		return
+	}
+	if d.calls.Deliver(msg) {
+		return
+	}
+
+	name := dataTypeName(msg)
+
+	d.mutex.RLock()
+	handler, exists := d.handlers[reflect.TypeOf(msg.DataType)]
+	d.mutex.RUnlock()
+
+	if !exists {
+		// Never answer a response with another response
+		if msg.GetResponse() != nil {
+			slog.Info("Received response from server", "success", msg.GetResponse().Success, "message", msg.GetResponse().Message)
+			return
+		}
+
+		slog.Warn("No handler for message", "type", name, "from", msg.From)
+		d.fail(msg, fmt.Errorf("%w: unsupported message: %s", rpc.ErrUnimplemented, name))
+		return
+	}
+
+	if err := d.call(handler, msg); err != nil {
+		slog.Error("Failed to handle message", "type", name, "error", err)
+		d.fail(msg, fmt.Errorf("failed to handle %s: %w", name, err))
+	}
+}
+
+// fail reports a message that couldn't be handled
+func (d *Dispatcher) fail(msg *pb.Message, err error) {
+	if msg.RequestId == "" {
+		d.reply(err.Error(), false)
+		return
+	}
+	if sendErr := d.ws.SendMessage(rpc.ErrorReply(msg, d.ws.config.CameraUuid, err)); sendErr != nil {
+		slog.Error("Failed to send error reply", "request_id", msg.RequestId, "error", sendErr)
+	}
+}
+
+// call runs the handler and converts a panic into an error
+func (d *Dispatcher) call(handler MessageHandler, msg *pb.Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Message handler panicked", "panic", r, "stack", string(debug.Stack()))
+			err = fmt.Errorf("handler panic: %v", r)
+		}
+	}()
+
+	return handler(msg)
+}
+
+func (d *Dispatcher) reply(message string, success bool) {
+	err := d.ws.SendMessage(&pb.Message{
+		From: d.ws.config.CameraUuid,
+		To:   "server",
+		DataType: &pb.Message_Response{
+			Response: &pb.Response{
+				Message: message,
+				Success: success,
+			},
+		},
+	})
+	if err != nil {
+		slog.Error("Failed to send response", "error", err)
+	}
+}
+
+// dataTypeName returns the proto field name of the message's data_type oneof
+func dataTypeName(msg *pb.Message) string {
+	m := msg.ProtoReflect()
+	field := m.WhichOneof(m.Descriptor().Oneofs().ByName(protoreflect.Name("data_type")))
+	if field == nil {
+		return "unknown"
+	}
+	return string(field.Name())
+}
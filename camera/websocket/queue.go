@@ -0,0 +1,317 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	pb "messages/msgspb"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+)
+
+// Priority decides which queued messages are evicted first when the queue is full
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+const defaultQueueSize = 256
+
+// ErrQueueFull is returned when a message can't be queued because every
+// queued message has a higher priority
+var ErrQueueFull = errors.New("outbound queue is full")
+
+// QueueOptions configures an OutboundQueue
+type QueueOptions struct {
+	// MaxMessages bounds the number of queued messages, defaults to 256
+	MaxMessages int
+	// Path persists the queue to this file when set, so it survives restarts
+	Path string
+}
+
+type queuedMessage struct {
+	Seq      uint64    `json:"seq"`
+	Priority Priority  `json:"priority"`
+	Expires  time.Time `json:"expires,omitempty"`
+	Data     []byte    `json:"data"`
+
+	saved bool // Written to the queue file
+}
+
+func (m *queuedMessage) expired(now time.Time) bool {
+	return !m.Expires.IsZero() && now.After(m.Expires)
+}
+
+// OutboundQueue holds messages that must reach the server while the websocket
+// is down and delivers them in order once it reconnects. WebRTC signaling
+// should keep using SendWebRTCMessage, it is useless once stale.
+type OutboundQueue struct {
+	ws         *WebsocketManager
+	maxSize    int
+	path       string
+	mutex      sync.Mutex
+	flushMutex sync.Mutex
+	items      []*queuedMessage
+	nextSeq    uint64
+	stale      bool // The queue file holds messages no longer queued
+}
+
+// NewOutboundQueue creates a queue on top of the websocket manager, loading
+// any messages persisted by a previous run
+func NewOutboundQueue(ws *WebsocketManager, opts QueueOptions) (*OutboundQueue, error) {
+	if opts.MaxMessages <= 0 {
+		opts.MaxMessages = defaultQueueSize
+	}
+
+	q := &OutboundQueue{
+		ws:      ws,
+		maxSize: opts.MaxMessages,
+		path:    opts.Path,
+	}
+
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+
+	ws.OnStateChange(func(connected bool) {
+		if connected {
+			go q.Flush()
+		}
+	})
+
+	if ws.IsConnected() {
+		go q.Flush()
+	}
+
+	return q, nil
+}
+
+// Send delivers the message now if possible, otherwise it is queued until the
+// websocket reconnects. A ttl of zero means the message never expires. Only
+// messages that couldn't be sent right away are written to disk.
+func (q *OutboundQueue) Send(message *pb.Message, priority Priority, ttl time.Duration) error {
+	data, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	if err := q.enqueue(data, priority, ttl); err != nil {
+		return err
+	}
+
+	if q.ws.IsConnected() {
+		q.Flush()
+		return nil
+	}
+	q.persist()
+	return nil
+}
+
+// Len returns the number of queued messages
+func (q *OutboundQueue) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.items)
+}
+
+// Flush sends queued messages in order until the queue is empty or a send
+// fails, then writes what is left to disk once
+func (q *OutboundQueue) Flush() {
+	q.flushMutex.Lock()
+	defer q.flushMutex.Unlock()
+	defer q.persist()
+
+	for {
+		item := q.peek()
+		if item == nil {
+			return
+		}
+
+		conn, writer, ok := q.ws.ensureConnected()
+		if !ok {
+			return
+		}
+
+		if err := writer.writeMessage(websocket.BinaryMessage, item.Data); err != nil {
+			slog.Error("failed to flush queued message:", "error", err)
+			q.ws.markDisconnected(conn)
+			return
+		}
+
+		q.remove(item.Seq)
+	}
+}
+
+func (q *OutboundQueue) enqueue(data []byte, priority Priority, ttl time.Duration) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.dropExpired()
+
+	if len(q.items) >= q.maxSize && !q.evict(priority) {
+		return ErrQueueFull
+	}
+
+	item := &queuedMessage{
+		Seq:      q.nextSeq,
+		Priority: priority,
+		Data:     data,
+	}
+	if ttl > 0 {
+		item.Expires = time.Now().Add(ttl)
+	}
+	q.nextSeq++
+	q.items = append(q.items, item)
+	return nil
+}
+
+// evict drops the oldest message with the lowest priority below the given one
+func (q *OutboundQueue) evict(priority Priority) bool {
+	victim := -1
+	for i, item := range q.items {
+		if item.Priority >= priority {
+			continue
+		}
+		if victim == -1 || item.Priority < q.items[victim].Priority {
+			victim = i
+		}
+	}
+	if victim == -1 {
+		return false
+	}
+
+	slog.Warn("Outbound queue full, dropping message", "priority", q.items[victim].Priority)
+	q.stale = q.stale || q.items[victim].saved
+	q.items = append(q.items[:victim], q.items[victim+1:]...)
+	return true
+}
+
+// peek returns the oldest unexpired message
+func (q *OutboundQueue) peek() *queuedMessage {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.dropExpired()
+	if len(q.items) == 0 {
+		return nil
+	}
+	return q.items[0]
+}
+
+func (q *OutboundQueue) remove(seq uint64) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, item := range q.items {
+		if item.Seq == seq {
+			q.stale = q.stale || item.saved
+			q.items = append(q.items[:i], q.items[i+1:]...)
+			break
+		}
+	}
+}
+
+// dropExpired removes expired messages
+func (q *OutboundQueue) dropExpired() {
+	now := time.Now()
+	kept := q.items[:0]
+	for _, item := range q.items {
+		if item.expired(now) {
+			slog.Debug("Dropping expired queued message", "seq", item.Seq)
+			q.stale = q.stale || item.saved
+			continue
+		}
+		kept = append(kept, item)
+	}
+	q.items = kept
+}
+
+// persist writes the queue to disk when it holds messages the file doesn't or
+// the other way round
+func (q *OutboundQueue) persist() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	changed := q.stale
+	for _, item := range q.items {
+		changed = changed || !item.saved
+	}
+	if !changed {
+		return
+	}
+	if err := q.save(); err != nil {
+		slog.Error("Failed to persist outbound queue", "error", err)
+	}
+}
+
+// save writes the queue to disk, must be called with the mutex held
+func (q *OutboundQueue) save() error {
+	if q.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(q.items)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbound queue: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+		return fmt.Errorf("failed to create queue directory: %w", err)
+	}
+
+	// Write then rename so a crash never leaves a truncated queue behind
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write outbound queue: %w", err)
+	}
+	if err := os.Rename(tmp, q.path); err != nil {
+		return err
+	}
+	for _, item := range q.items {
+		item.saved = true
+	}
+	q.stale = false
+	return nil
+}
+
+// load reads a persisted queue from disk
+func (q *OutboundQueue) load() error {
+	if q.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(q.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read outbound queue: %w", err)
+	}
+
+	var items []*queuedMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		slog.Error("Discarding corrupt outbound queue", "path", q.path, "error", err)
+		return nil
+	}
+
+	q.items = items
+	for _, item := range items {
+		item.saved = true
+		if item.Seq >= q.nextSeq {
+			q.nextSeq = item.Seq + 1
+		}
+	}
+	q.dropExpired()
+
+	slog.Info("Loaded outbound queue", "messages", len(q.items))
+	return nil
+}
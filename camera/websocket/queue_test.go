@@ -0,0 +1,152 @@
+package websocket
+
+import (
+	"errors"
+	pb "messages/msgspb"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// newTestQueue returns a queue on a websocket that never connects, so every
+// message stays queued
+func newTestQueue(t *testing.T, opts QueueOptions) *OutboundQueue {
+	t.Helper()
+	q, err := NewOutboundQueue(&WebsocketManager{}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+// queued returns the request ids of the queued messages in order
+func queued(t *testing.T, q *OutboundQueue) []string {
+	t.Helper()
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	var ids []string
+	for _, item := range q.items {
+		msg := &pb.Message{}
+		if err := proto.Unmarshal(item.Data, msg); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, msg.RequestId)
+	}
+	return ids
+}
+
+type queueSend struct {
+	id       string
+	priority Priority
+}
+
+func TestQueueEviction(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		sends []queueSend
+		want  []string
+		full  int // Index of the send expected to fail with ErrQueueFull, -1 for none
+	}{
+		{
+			name:  "oldest of the lowest priority goes first",
+			sends: []queueSend{{"n1", PriorityNormal}, {"l1", PriorityLow}, {"l2", PriorityLow}, {"h1", PriorityHigh}},
+			want:  []string{"n1", "l2", "h1"},
+			full:  -1,
+		},
+		{
+			name:  "low before normal",
+			sends: []queueSend{{"l1", PriorityLow}, {"n1", PriorityNormal}, {"n2", PriorityNormal}, {"h1", PriorityHigh}, {"h2", PriorityHigh}},
+			want:  []string{"n2", "h1", "h2"},
+			full:  -1,
+		},
+		{
+			name:  "equal priority isn't evicted",
+			sends: []queueSend{{"n1", PriorityNormal}, {"n2", PriorityNormal}, {"n3", PriorityNormal}, {"n4", PriorityNormal}},
+			want:  []string{"n1", "n2", "n3"},
+			full:  3,
+		},
+		{
+			name:  "higher priority isn't evicted",
+			sends: []queueSend{{"h1", PriorityHigh}, {"h2", PriorityHigh}, {"n1", PriorityNormal}, {"l1", PriorityLow}},
+			want:  []string{"h1", "h2", "n1"},
+			full:  3,
+		},
+	} {
+		q := newTestQueue(t, QueueOptions{MaxMessages: 3})
+		for i, send := range test.sends {
+			err := q.Send(&pb.Message{RequestId: send.id}, send.priority, 0)
+			if (i == test.full) != errors.Is(err, ErrQueueFull) {
+				t.Errorf("%s: send %s: %v", test.name, send.id, err)
+			}
+		}
+		if ids := queued(t, q); !slices.Equal(ids, test.want) {
+			t.Errorf("%s: queued %v, want %v", test.name, ids, test.want)
+		}
+	}
+}
+
+func TestQueueExpiry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	q := newTestQueue(t, QueueOptions{Path: path})
+
+	if err := q.Send(&pb.Message{RequestId: "short"}, PriorityNormal, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Send(&pb.Message{RequestId: "forever"}, PriorityNormal, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Send(&pb.Message{RequestId: "long"}, PriorityNormal, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// Expired messages are never sent
+	if item := q.peek(); item == nil || q.Len() != 2 {
+		t.Fatalf("%d messages left after expiry, want 2", q.Len())
+	}
+	if ids := queued(t, q); !slices.Equal(ids, []string{"forever", "long"}) {
+		t.Fatalf("queued %v", ids)
+	}
+
+	// A message that expired while saved is dropped on load too
+	if err := q.Send(&pb.Message{RequestId: "saved"}, PriorityNormal, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if ids := queued(t, newTestQueue(t, QueueOptions{Path: path})); !slices.Equal(ids, []string{"forever", "long"}) {
+		t.Fatalf("loaded %v", ids)
+	}
+}
+
+func TestQueuePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	q := newTestQueue(t, QueueOptions{Path: path})
+
+	for _, id := range []string{"a", "b", "c"} {
+		if err := q.Send(&pb.Message{RequestId: id}, PriorityNormal, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.remove(q.peek().Seq) // As if "a" was flushed
+	q.persist()
+
+	loaded := newTestQueue(t, QueueOptions{Path: path})
+	if ids := queued(t, loaded); !slices.Equal(ids, []string{"b", "c"}) {
+		t.Fatalf("loaded %v, want [b c]", ids)
+	}
+	if item := loaded.peek(); item.Priority != PriorityNormal || item.Expires.IsZero() || !item.saved {
+		t.Fatalf("loaded item %+v", item)
+	}
+
+	// New messages are ordered after the loaded ones
+	if err := loaded.Send(&pb.Message{RequestId: "d"}, PriorityNormal, 0); err != nil {
+		t.Fatal(err)
+	}
+	if ids := queued(t, newTestQueue(t, QueueOptions{Path: path})); !slices.Equal(ids, []string{"b", "c", "d"}) {
+		t.Fatalf("reloaded %v, want [b c d]", ids)
+	}
+}
@@ -15,6 +15,15 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+const (
+	// writeWait is the time allowed to write a message to the server
+	writeWait = 10 * time.Second
+	// pongWait is the time allowed to read the next pong from the server
+	pongWait = 60 * time.Second
+	// pingPeriod is how often pings are sent, must be less than pongWait
+	pingPeriod = (pongWait * 9) / 10
+)
+
 type ThreadSafeWriter struct {
 	conn  *websocket.Conn
 	mutex sync.Mutex
@@ -29,9 +38,13 @@ func newThreadSafeWriter(conn *websocket.Conn) *ThreadSafeWriter {
 func (w *ThreadSafeWriter) writeMessage(messageType int, data []byte) error {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
+	w.conn.SetWriteDeadline(time.Now().Add(writeWait))
 	return w.conn.WriteMessage(messageType, data)
 }
 
+// StateChangeHandler is called whenever the websocket goes online or offline
+type StateChangeHandler func(connected bool)
+
 type WebsocketManager struct {
 	Writer         *ThreadSafeWriter
 	conn           *websocket.Conn
@@ -39,6 +52,9 @@ type WebsocketManager struct {
 	ServerUrl      *url.URL
 	WSServerURL    *url.URL
 	connected      bool
+	closed         bool
+	stateMutex     sync.RWMutex
+	stateHandlers  []StateChangeHandler
 	reconnecting   bool
 	reconnectMutex sync.Mutex
 	stopReconnect  chan struct{}
@@ -62,14 +78,52 @@ func NewWebsocketManager(u *url.URL, config *config.Config) *WebsocketManager {
 	}
 
 	// Establish initial connection
-	manager.connect()
+	if !manager.connect() {
+		manager.startReconnectLoop()
+	}
 	return manager
 }
 
+// OnStateChange registers a handler that is called on every offline/online transition
+func (manager *WebsocketManager) OnStateChange(handler StateChangeHandler) {
+	manager.stateMutex.Lock()
+	defer manager.stateMutex.Unlock()
+	manager.stateHandlers = append(manager.stateHandlers, handler)
+}
+
+// IsConnected reports whether the websocket is currently connected
+func (manager *WebsocketManager) IsConnected() bool {
+	manager.stateMutex.RLock()
+	defer manager.stateMutex.RUnlock()
+	return manager.connected
+}
+
+// current returns the active connection and writer, or nil if disconnected
+func (manager *WebsocketManager) current() (*websocket.Conn, *ThreadSafeWriter) {
+	manager.stateMutex.RLock()
+	defer manager.stateMutex.RUnlock()
+	if !manager.connected {
+		return nil, nil
+	}
+	return manager.conn, manager.Writer
+}
+
+// notifyStateChange calls every registered handler, must not be called with stateMutex held
+func (manager *WebsocketManager) notifyStateChange(connected bool) {
+	manager.stateMutex.RLock()
+	handlers := make([]StateChangeHandler, len(manager.stateHandlers))
+	copy(handlers, manager.stateHandlers)
+	manager.stateMutex.RUnlock()
+
+	for _, handler := range handlers {
+		handler(connected)
+	}
+}
+
 func (manager *WebsocketManager) connect() bool {
 	slog.Info("connecting to websocket", "url", manager.WSServerURL.String())
 	header := http.Header{}
-	header.Add("Authorization", manager.config.Token)
+	header.Add("Authorization", manager.config.AuthToken())
 
 	c, _, err := websocket.DefaultDialer.Dial(manager.WSServerURL.String(), header)
 	if err != nil {
@@ -77,16 +131,74 @@ func (manager *WebsocketManager) connect() bool {
 		return false
 	}
 
-	writer := newThreadSafeWriter(c)
-	manager.Writer = writer
+	// Every pong from the server pushes the read deadline forward. If the server
+	// stops answering, the pending ReadMessage fails and we reconnect.
+	c.SetReadDeadline(time.Now().Add(pongWait))
+	c.SetPongHandler(func(string) error {
+		return c.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	manager.stateMutex.Lock()
+	if manager.closed {
+		manager.stateMutex.Unlock()
+		c.Close()
+		return false
+	}
+	manager.Writer = newThreadSafeWriter(c)
 	manager.conn = c
 	manager.connected = true
+	manager.stateMutex.Unlock()
 
+	go manager.pingLoop(c)
 
 	slog.Info("websocket connection established")
+	manager.notifyStateChange(true)
 	return true
 }
 
+// pingLoop periodically pings the server until the connection is replaced or fails
+func (manager *WebsocketManager) pingLoop(c *websocket.Conn) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		manager.stateMutex.RLock()
+		active := manager.conn == c && manager.connected
+		manager.stateMutex.RUnlock()
+		if !active {
+			return
+		}
+
+		if err := c.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+			slog.Error("ping failed:", "error", err)
+			manager.markDisconnected(c)
+			return
+		}
+	}
+}
+
+// markDisconnected tears down the given connection if it is still the active one
+// and starts reconnecting. Stale connections are ignored so a late error from an
+// old connection can't knock a fresh one offline.
+func (manager *WebsocketManager) markDisconnected(c *websocket.Conn) {
+	manager.stateMutex.Lock()
+	if manager.conn != c || !manager.connected {
+		manager.stateMutex.Unlock()
+		return
+	}
+	manager.connected = false
+	closed := manager.closed
+	manager.stateMutex.Unlock()
+
+	c.Close()
+	slog.Warn("websocket connection lost")
+	manager.notifyStateChange(false)
+
+	if !closed {
+		manager.startReconnectLoop()
+	}
+}
+
 func (manager *WebsocketManager) startReconnectLoop() {
 	manager.reconnectMutex.Lock()
 	if manager.reconnecting {
@@ -108,7 +220,7 @@ func (manager *WebsocketManager) startReconnectLoop() {
 				manager.reconnectMutex.Unlock()
 				return
 			default:
-				if manager.connected {
+				if manager.IsConnected() {
 					manager.reconnectMutex.Lock()
 					manager.reconnecting = false
 					manager.reconnectMutex.Unlock()
@@ -124,7 +236,10 @@ func (manager *WebsocketManager) startReconnectLoop() {
 				}
 
 				// Wait before next attempt with exponential backoff
-				time.Sleep(backoff)
+				select {
+				case <-manager.stopReconnect:
+				case <-time.After(backoff):
+				}
 				backoff *= 2
 				if backoff > maxBackoff {
 					backoff = maxBackoff
@@ -134,43 +249,56 @@ func (manager *WebsocketManager) startReconnectLoop() {
 	}()
 }
 
-func (manager *WebsocketManager) ensureConnected() bool {
-	if manager.connected && manager.conn != nil {
-		return true
+func (manager *WebsocketManager) ensureConnected() (*websocket.Conn, *ThreadSafeWriter, bool) {
+	conn, writer := manager.current()
+	if conn != nil {
+		return conn, writer, true
 	}
 
 	// If not already trying to reconnect, start reconnection loop
-	if !manager.reconnecting {
+	manager.stateMutex.RLock()
+	closed := manager.closed
+	manager.stateMutex.RUnlock()
+	if !closed {
 		manager.startReconnectLoop()
 	}
 
-	return false
+	return nil, nil, false
 }
 
 func (manager *WebsocketManager) Close() {
-	// Signal reconnect loop to stop if it's running
-	manager.reconnectMutex.Lock()
-	if manager.reconnecting {
-		close(manager.stopReconnect)
+	manager.stateMutex.Lock()
+	if manager.closed {
+		manager.stateMutex.Unlock()
+		return
 	}
-	manager.reconnectMutex.Unlock()
+	manager.closed = true
+	conn := manager.conn
+	wasConnected := manager.connected
+	manager.connected = false
+	manager.stateMutex.Unlock()
 
-	if manager.conn != nil {
-		manager.conn.Close()
-		manager.connected = false
+	// Signal reconnect loop to stop if it's running
+	close(manager.stopReconnect)
+
+	if conn != nil {
+		conn.Close()
+	}
+	if wasConnected {
+		manager.notifyStateChange(false)
 	}
 }
 
 func (manager *WebsocketManager) ReadMessage() (*pb.Message, error) {
-	if !manager.ensureConnected() {
+	conn, _, ok := manager.ensureConnected()
+	if !ok {
 		return nil, websocket.ErrCloseSent
 	}
 
-	_, data, err := manager.conn.ReadMessage()
+	_, data, err := conn.ReadMessage()
 	if err != nil {
 		slog.Error("read message error:", "error", err)
-		manager.connected = false
-		manager.startReconnectLoop()
+		manager.markDisconnected(conn)
 		return nil, err
 	}
 
@@ -184,7 +312,8 @@ func (manager *WebsocketManager) ReadMessage() (*pb.Message, error) {
 }
 
 func (manager *WebsocketManager) SendMessage(message *pb.Message) error {
-	if !manager.ensureConnected() {
+	conn, writer, ok := manager.ensureConnected()
+	if !ok {
 		return websocket.ErrCloseSent
 	}
 
@@ -192,15 +321,16 @@ func (manager *WebsocketManager) SendMessage(message *pb.Message) error {
 	if err != nil {
 		return err
 	}
-	err = manager.Writer.writeMessage(websocket.BinaryMessage, data)
+	err = writer.writeMessage(websocket.BinaryMessage, data)
 	if err != nil {
 		slog.Error("failed to send message:", "error", err)
-		manager.connected = false
-		manager.startReconnectLoop()
+		manager.markDisconnected(conn)
 	}
 	return err
 }
 
+// SendWebRTCMessage sends a signaling message to a client. Signaling is
+// best-effort and never queued, a stale offer or candidate is useless.
 func (manager *WebsocketManager) SendWebRTCMessage(payload any, to string) error {
 
 	message := &pb.Message{
@@ -222,8 +352,6 @@ func (manager *WebsocketManager) SendWebRTCMessage(payload any, to string) error
 	err := manager.SendMessage(message)
 	if err != nil {
 		slog.Error("failed to send WebRTC message:", "error", err)
-		manager.connected = false
-		manager.startReconnectLoop()
 	}
 	return err
 }
@@ -0,0 +1,18 @@
+package jwtmsg
+
+import "fmt"
+
+// Query parameters describing a backed up segment, the index entry of the
+// camera the server lists backed up recordings from
+const (
+	BackupStartParam    = "start"    // Unix milliseconds
+	BackupDurationParam = "duration" // Seconds
+	BackupTriggerParam  = "trigger"  // RecordingTrigger number
+)
+
+// BackupPath is where a camera uploads a recording file, name is relative to
+// its recordings directory, i.e. <session>/<file>. Files are write-once, the
+// server answers 409 Conflict to uploading one again.
+func BackupPath(cameraID, name string) string {
+	return fmt.Sprintf("/api/cameras/%s/backup/%s", cameraID, name)
+}
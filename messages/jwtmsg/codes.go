@@ -0,0 +1,77 @@
+package jwtmsg
+
+import (
+	"crypto/rand"
+	"net/url"
+	"strings"
+)
+
+// shortCodeAlphabet is Crockford's base32, without letters that look like digits
+const shortCodeAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+const shortCodeLength = 8
+
+// ClaimPath prefixes the claim code in the compact QR payload <serverURL>/claim/<code>
+const ClaimPath = "/claim/"
+
+// GenerateShortCode returns a random one-time code formatted as XXXX-XXXX,
+// used for Wi-Fi codes and camera claim codes
+func GenerateShortCode() (string, error) {
+	b := make([]byte, shortCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := make([]byte, 0, shortCodeLength+1)
+	for i, v := range b {
+		if i == shortCodeLength/2 {
+			code = append(code, '-')
+		}
+		code = append(code, shortCodeAlphabet[int(v)%len(shortCodeAlphabet)])
+	}
+	return string(code), nil
+}
+
+// NormalizeShortCode uppercases the code and strips separators so it can be typed loosely
+func NormalizeShortCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.NewReplacer("-", "", " ", "", "O", "0", "I", "1", "L", "1").Replace(code)
+	return code
+}
+
+// IsShortCode reports whether the text looks like a short code
+func IsShortCode(text string) bool {
+	code := NormalizeShortCode(text)
+	if len(code) != shortCodeLength {
+		return false
+	}
+	for _, r := range code {
+		if !strings.ContainsRune(shortCodeAlphabet, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// ClaimURL builds the compact QR payload for a claim code
+func ClaimURL(serverURL, code string) string {
+	return strings.TrimSuffix(serverURL, "/") + ClaimPath + NormalizeShortCode(code)
+}
+
+// ParseClaimURL splits a compact QR payload into the server URL and claim code
+func ParseClaimURL(text string) (serverURL string, code string, ok bool) {
+	u, err := url.Parse(strings.TrimSpace(text))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", "", false
+	}
+
+	prefix, code, found := strings.Cut(u.Path, ClaimPath)
+	if !found || !IsShortCode(code) {
+		return "", "", false
+	}
+
+	u.Path = prefix
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String(), NormalizeShortCode(code), true
+}
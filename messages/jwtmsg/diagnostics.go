@@ -0,0 +1,13 @@
+package jwtmsg
+
+import "fmt"
+
+// DiagnosticsOffsetHeader carries the number of bytes the server has stored
+// for a diagnostic bundle, so an interrupted upload can resume
+const DiagnosticsOffsetHeader = "X-Upload-Offset"
+
+// DiagnosticsChunkPath is where a camera uploads a chunk of a diagnostic bundle,
+// the bundle ID is the ID of the command that asked for it
+func DiagnosticsChunkPath(cameraID, bundleID string) string {
+	return fmt.Sprintf("/api/cameras/%s/diagnostics/%s/chunks", cameraID, bundleID)
+}
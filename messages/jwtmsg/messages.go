@@ -15,15 +15,67 @@ type CameraAdd struct {
 	ServerURL    string `json:"serverUrl"`
 	WifiNetwork  string `json:"wifiNetwork,omitempty"`
 	WifiPassword string `json:"wifiPassword,omitempty"`
+	// WifiSealed holds WifiCredentials encrypted with the one-time Wi-Fi code,
+	// new tokens use it instead of the plaintext fields above
+	WifiSealed string `json:"wifiSealed,omitempty"`
 }
 
+// ProvisioningKey is served at ProvisioningKeyPath so cameras can verify CameraAdd tokens
+type ProvisioningKey struct {
+	Algorithm string `json:"alg"`
+	PublicKey string `json:"publicKey"` // base64 encoded Ed25519 public key
+}
+
+// ProvisioningKeyPath is the well-known path of the server's provisioning public key
+const ProvisioningKeyPath = "/.well-known/sudocam/provisioning-key"
+
 // RegisterCamera is the request sent to register a camera
 type RegisterCamera struct {
-	CameraUUID   string `json:"cameraUUID"`
+	CameraUUID   string              `json:"cameraUUID"`
+	Token        string              `json:"token,omitempty"`
+	ClaimCode    string              `json:"claimCode,omitempty"` // Used instead of Token
+	FriendlyName string              `json:"friendlyName"`
+	Connectivity *ConnectivityReport `json:"connectivity,omitempty"`
+}
+
+// ConnectivityReport is the result of verifying the camera's network during provisioning
+type ConnectivityReport struct {
+	Network         string `json:"network,omitempty"`
+	Connected       bool   `json:"connected"`
+	IPAddress       string `json:"ipAddress,omitempty"`
+	ServerReachable bool   `json:"serverReachable"`
+	Error           string `json:"error,omitempty"`
+}
+
+// CameraTokens is returned when a camera registers or refreshes its credentials
+type CameraTokens struct {
 	Token        string `json:"token"`
-	FriendlyName string `json:"friendlyName"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresAt    int64  `json:"expiresAt"`
+	FriendlyName string `json:"friendlyName,omitempty"` // Set on registration
+}
+
+// RefreshCameraToken is the request sent to exchange a refresh token for new credentials
+type RefreshCameraToken struct {
+	CameraUUID   string `json:"cameraUUID"`
+	RefreshToken string `json:"refreshToken"`
 }
 
+// Deregistration is the 410 body a deleted camera gets when it tries to refresh
+// its token before it could be told over the websocket. The camera confirms
+// it with a RefreshCameraToken sent to DeregistrationAckPath once it wiped
+// itself.
+type Deregistration struct {
+	Reason           string `json:"reason"`
+	DeleteRecordings bool   `json:"deleteRecordings"`
+}
+
+// DeregistrationAckPath is where a deleted camera confirms its deregistration
+const DeregistrationAckPath = "/api/cameras/deregistration/ack"
+
+// UnregisterPath is where a camera being factory reset removes itself from
+// the server, authenticated with a RefreshCameraToken
+const UnregisterPath = "/api/cameras/unregister"
 
 type AuthClaims struct {
 	Email      string     `json:"email"`
@@ -63,4 +115,4 @@ func (e *EntityType) UnmarshalJSON(data []byte) error {
 // String returns the string representation of the entity type
 func (e EntityType) String() string {
 	return string(e)
-}
\ No newline at end of file
+}
@@ -0,0 +1,18 @@
+package jwtmsg
+
+import "fmt"
+
+// ReleaseSignatureSuffix names the file holding a release binary's base64
+// encoded signature, next to the binary
+const ReleaseSignatureSuffix = ".sig"
+
+// ReleaseSigningPayload is the data signed for an agent release, binding the
+// binary digest to its version and architecture
+func ReleaseSigningPayload(version, arch, sha256 string, size int64) []byte {
+	return fmt.Appendf(nil, "sudocam-release\n%s\n%s\n%s\n%d\n", version, arch, sha256, size)
+}
+
+// ReleaseDownloadPath is where the server serves a release binary
+func ReleaseDownloadPath(version, arch string) string {
+	return fmt.Sprintf("/api/releases/%s/%s/download", version, arch)
+}
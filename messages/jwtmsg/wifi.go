@@ -0,0 +1,104 @@
+package jwtmsg
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// WifiCredentials are sealed into CameraAdd.WifiSealed so the QR code alone
+// doesn't reveal the Wi-Fi password
+type WifiCredentials struct {
+	Network  string `json:"network"`
+	Password string `json:"password,omitempty"`
+}
+
+const wifiSaltSize = 16
+
+func wifiKey(code string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(NormalizeShortCode(code)), salt, 1<<15, 8, 1, 32)
+}
+
+// SealWifiCredentials encrypts the credentials with a key derived from a short code
+func SealWifiCredentials(creds WifiCredentials, code string) (string, error) {
+	plaintext, err := json.Marshal(creds)
+	if err != nil {
+		return "", err
+	}
+
+	salt := make([]byte, wifiSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key, err := wifiKey(code, salt)
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	// salt | nonce | ciphertext
+	sealed := append(salt, nonce...)
+	sealed = gcm.Seal(sealed, nonce, plaintext, nil)
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// OpenWifiCredentials decrypts credentials sealed by SealWifiCredentials
+func OpenWifiCredentials(sealed string, code string) (*WifiCredentials, error) {
+	data, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sealed credentials: %w", err)
+	}
+	if len(data) < wifiSaltSize {
+		return nil, errors.New("sealed credentials too short")
+	}
+
+	key, err := wifiKey(code, data[:wifiSaltSize])
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	data = data[wifiSaltSize:]
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("sealed credentials too short")
+	}
+
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("wrong Wi-Fi code")
+	}
+
+	var creds WifiCredentials
+	if err := json.Unmarshal(plaintext, &creds); err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
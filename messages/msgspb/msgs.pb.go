@@ -21,6 +21,355 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// ErrorCode classifies a failed request
+type ErrorCode int32
+
+const (
+	ErrorCode_ERROR_CODE_UNSPECIFIED       ErrorCode = 0
+	ErrorCode_ERROR_CODE_NOT_FOUND         ErrorCode = 1
+	ErrorCode_ERROR_CODE_INVALID_ARGUMENT  ErrorCode = 2
+	ErrorCode_ERROR_CODE_OUT_OF_RANGE      ErrorCode = 3
+	ErrorCode_ERROR_CODE_UNAVAILABLE       ErrorCode = 4
+	ErrorCode_ERROR_CODE_DEADLINE_EXCEEDED ErrorCode = 5
+	ErrorCode_ERROR_CODE_CANCELLED         ErrorCode = 6
+	ErrorCode_ERROR_CODE_UNIMPLEMENTED     ErrorCode = 7
+	ErrorCode_ERROR_CODE_INTERNAL          ErrorCode = 8
+)
+
+// Enum value maps for ErrorCode.
+var (
+	ErrorCode_name = map[int32]string{
+		0: "ERROR_CODE_UNSPECIFIED",
+		1: "ERROR_CODE_NOT_FOUND",
+		2: "ERROR_CODE_INVALID_ARGUMENT",
+		3: "ERROR_CODE_OUT_OF_RANGE",
+		4: "ERROR_CODE_UNAVAILABLE",
+		5: "ERROR_CODE_DEADLINE_EXCEEDED",
+		6: "ERROR_CODE_CANCELLED",
+		7: "ERROR_CODE_UNIMPLEMENTED",
+		8: "ERROR_CODE_INTERNAL",
+	}
+	ErrorCode_value = map[string]int32{
+		"ERROR_CODE_UNSPECIFIED":       0,
+		"ERROR_CODE_NOT_FOUND":         1,
+		"ERROR_CODE_INVALID_ARGUMENT":  2,
+		"ERROR_CODE_OUT_OF_RANGE":      3,
+		"ERROR_CODE_UNAVAILABLE":       4,
+		"ERROR_CODE_DEADLINE_EXCEEDED": 5,
+		"ERROR_CODE_CANCELLED":         6,
+		"ERROR_CODE_UNIMPLEMENTED":     7,
+		"ERROR_CODE_INTERNAL":          8,
+	}
+)
+
+func (x ErrorCode) Enum() *ErrorCode {
+	p := new(ErrorCode)
+	*p = x
+	return p
+}
+
+func (x ErrorCode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrorCode) Descriptor() protoreflect.EnumDescriptor {
+	return file_msgs_proto_enumTypes[0].Descriptor()
+}
+
+func (ErrorCode) Type() protoreflect.EnumType {
+	return &file_msgs_proto_enumTypes[0]
+}
+
+func (x ErrorCode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ErrorCode.Descriptor instead.
+func (ErrorCode) EnumDescriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{0}
+}
+
+// RecordingTrigger is what started a recording session
+type RecordingTrigger int32
+
+const (
+	RecordingTrigger_RECORDING_TRIGGER_UNSPECIFIED RecordingTrigger = 0
+	RecordingTrigger_RECORDING_TRIGGER_CONTINUOUS  RecordingTrigger = 1
+	RecordingTrigger_RECORDING_TRIGGER_SCHEDULED   RecordingTrigger = 2
+	RecordingTrigger_RECORDING_TRIGGER_MOTION      RecordingTrigger = 3
+	RecordingTrigger_RECORDING_TRIGGER_MANUAL      RecordingTrigger = 4
+)
+
+// Enum value maps for RecordingTrigger.
+var (
+	RecordingTrigger_name = map[int32]string{
+		0: "RECORDING_TRIGGER_UNSPECIFIED",
+		1: "RECORDING_TRIGGER_CONTINUOUS",
+		2: "RECORDING_TRIGGER_SCHEDULED",
+		3: "RECORDING_TRIGGER_MOTION",
+		4: "RECORDING_TRIGGER_MANUAL",
+	}
+	RecordingTrigger_value = map[string]int32{
+		"RECORDING_TRIGGER_UNSPECIFIED": 0,
+		"RECORDING_TRIGGER_CONTINUOUS":  1,
+		"RECORDING_TRIGGER_SCHEDULED":   2,
+		"RECORDING_TRIGGER_MOTION":      3,
+		"RECORDING_TRIGGER_MANUAL":      4,
+	}
+)
+
+func (x RecordingTrigger) Enum() *RecordingTrigger {
+	p := new(RecordingTrigger)
+	*p = x
+	return p
+}
+
+func (x RecordingTrigger) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RecordingTrigger) Descriptor() protoreflect.EnumDescriptor {
+	return file_msgs_proto_enumTypes[1].Descriptor()
+}
+
+func (RecordingTrigger) Type() protoreflect.EnumType {
+	return &file_msgs_proto_enumTypes[1]
+}
+
+func (x RecordingTrigger) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RecordingTrigger.Descriptor instead.
+func (RecordingTrigger) EnumDescriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{1}
+}
+
+// CommandType lists the remote maintenance actions a camera supports
+type CommandType int32
+
+const (
+	CommandType_COMMAND_TYPE_UNSPECIFIED    CommandType = 0
+	CommandType_COMMAND_TYPE_REBOOT         CommandType = 1
+	CommandType_COMMAND_TYPE_RESTART_AGENT  CommandType = 2
+	CommandType_COMMAND_TYPE_RESTART_STREAM CommandType = 3
+	CommandType_COMMAND_TYPE_FACTORY_RESET  CommandType = 4
+	CommandType_COMMAND_TYPE_RESYNC_CONFIG  CommandType = 5
+	CommandType_COMMAND_TYPE_DIAGNOSTICS    CommandType = 6 // Collect a diagnostic bundle and upload it
+)
+
+// Enum value maps for CommandType.
+var (
+	CommandType_name = map[int32]string{
+		0: "COMMAND_TYPE_UNSPECIFIED",
+		1: "COMMAND_TYPE_REBOOT",
+		2: "COMMAND_TYPE_RESTART_AGENT",
+		3: "COMMAND_TYPE_RESTART_STREAM",
+		4: "COMMAND_TYPE_FACTORY_RESET",
+		5: "COMMAND_TYPE_RESYNC_CONFIG",
+		6: "COMMAND_TYPE_DIAGNOSTICS",
+	}
+	CommandType_value = map[string]int32{
+		"COMMAND_TYPE_UNSPECIFIED":    0,
+		"COMMAND_TYPE_REBOOT":         1,
+		"COMMAND_TYPE_RESTART_AGENT":  2,
+		"COMMAND_TYPE_RESTART_STREAM": 3,
+		"COMMAND_TYPE_FACTORY_RESET":  4,
+		"COMMAND_TYPE_RESYNC_CONFIG":  5,
+		"COMMAND_TYPE_DIAGNOSTICS":    6,
+	}
+)
+
+func (x CommandType) Enum() *CommandType {
+	p := new(CommandType)
+	*p = x
+	return p
+}
+
+func (x CommandType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CommandType) Descriptor() protoreflect.EnumDescriptor {
+	return file_msgs_proto_enumTypes[2].Descriptor()
+}
+
+func (CommandType) Type() protoreflect.EnumType {
+	return &file_msgs_proto_enumTypes[2]
+}
+
+func (x CommandType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CommandType.Descriptor instead.
+func (CommandType) EnumDescriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{2}
+}
+
+type CommandStatus int32
+
+const (
+	CommandStatus_COMMAND_STATUS_UNSPECIFIED CommandStatus = 0
+	CommandStatus_COMMAND_STATUS_PENDING     CommandStatus = 1 // Sent, no result yet
+	CommandStatus_COMMAND_STATUS_SUCCEEDED   CommandStatus = 2
+	CommandStatus_COMMAND_STATUS_FAILED      CommandStatus = 3
+)
+
+// Enum value maps for CommandStatus.
+var (
+	CommandStatus_name = map[int32]string{
+		0: "COMMAND_STATUS_UNSPECIFIED",
+		1: "COMMAND_STATUS_PENDING",
+		2: "COMMAND_STATUS_SUCCEEDED",
+		3: "COMMAND_STATUS_FAILED",
+	}
+	CommandStatus_value = map[string]int32{
+		"COMMAND_STATUS_UNSPECIFIED": 0,
+		"COMMAND_STATUS_PENDING":     1,
+		"COMMAND_STATUS_SUCCEEDED":   2,
+		"COMMAND_STATUS_FAILED":      3,
+	}
+)
+
+func (x CommandStatus) Enum() *CommandStatus {
+	p := new(CommandStatus)
+	*p = x
+	return p
+}
+
+func (x CommandStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CommandStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_msgs_proto_enumTypes[3].Descriptor()
+}
+
+func (CommandStatus) Type() protoreflect.EnumType {
+	return &file_msgs_proto_enumTypes[3]
+}
+
+func (x CommandStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CommandStatus.Descriptor instead.
+func (CommandStatus) EnumDescriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{3}
+}
+
+// RecordingRetention is what a deregistered camera does with its recordings
+type RecordingRetention int32
+
+const (
+	RecordingRetention_RECORDING_RETENTION_UNSPECIFIED RecordingRetention = 0 // Treated as keep
+	RecordingRetention_RECORDING_RETENTION_KEEP        RecordingRetention = 1
+	RecordingRetention_RECORDING_RETENTION_DELETE      RecordingRetention = 2
+)
+
+// Enum value maps for RecordingRetention.
+var (
+	RecordingRetention_name = map[int32]string{
+		0: "RECORDING_RETENTION_UNSPECIFIED",
+		1: "RECORDING_RETENTION_KEEP",
+		2: "RECORDING_RETENTION_DELETE",
+	}
+	RecordingRetention_value = map[string]int32{
+		"RECORDING_RETENTION_UNSPECIFIED": 0,
+		"RECORDING_RETENTION_KEEP":        1,
+		"RECORDING_RETENTION_DELETE":      2,
+	}
+)
+
+func (x RecordingRetention) Enum() *RecordingRetention {
+	p := new(RecordingRetention)
+	*p = x
+	return p
+}
+
+func (x RecordingRetention) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RecordingRetention) Descriptor() protoreflect.EnumDescriptor {
+	return file_msgs_proto_enumTypes[4].Descriptor()
+}
+
+func (RecordingRetention) Type() protoreflect.EnumType {
+	return &file_msgs_proto_enumTypes[4]
+}
+
+func (x RecordingRetention) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RecordingRetention.Descriptor instead.
+func (RecordingRetention) EnumDescriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{4}
+}
+
+type UpdateStatus int32
+
+const (
+	UpdateStatus_UPDATE_STATUS_UNSPECIFIED UpdateStatus = 0
+	UpdateStatus_UPDATE_STATUS_PENDING     UpdateStatus = 1 // Rollout requested, not picked up yet
+	UpdateStatus_UPDATE_STATUS_DOWNLOADING UpdateStatus = 2
+	UpdateStatus_UPDATE_STATUS_INSTALLING  UpdateStatus = 3 // New binary in place, waiting to reconnect
+	UpdateStatus_UPDATE_STATUS_SUCCEEDED   UpdateStatus = 4
+	UpdateStatus_UPDATE_STATUS_FAILED      UpdateStatus = 5
+	UpdateStatus_UPDATE_STATUS_ROLLED_BACK UpdateStatus = 6
+)
+
+// Enum value maps for UpdateStatus.
+var (
+	UpdateStatus_name = map[int32]string{
+		0: "UPDATE_STATUS_UNSPECIFIED",
+		1: "UPDATE_STATUS_PENDING",
+		2: "UPDATE_STATUS_DOWNLOADING",
+		3: "UPDATE_STATUS_INSTALLING",
+		4: "UPDATE_STATUS_SUCCEEDED",
+		5: "UPDATE_STATUS_FAILED",
+		6: "UPDATE_STATUS_ROLLED_BACK",
+	}
+	UpdateStatus_value = map[string]int32{
+		"UPDATE_STATUS_UNSPECIFIED": 0,
+		"UPDATE_STATUS_PENDING":     1,
+		"UPDATE_STATUS_DOWNLOADING": 2,
+		"UPDATE_STATUS_INSTALLING":  3,
+		"UPDATE_STATUS_SUCCEEDED":   4,
+		"UPDATE_STATUS_FAILED":      5,
+		"UPDATE_STATUS_ROLLED_BACK": 6,
+	}
+)
+
+func (x UpdateStatus) Enum() *UpdateStatus {
+	p := new(UpdateStatus)
+	*p = x
+	return p
+}
+
+func (x UpdateStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (UpdateStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_msgs_proto_enumTypes[5].Descriptor()
+}
+
+func (UpdateStatus) Type() protoreflect.EnumType {
+	return &file_msgs_proto_enumTypes[5]
+}
+
+func (x UpdateStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use UpdateStatus.Descriptor instead.
+func (UpdateStatus) EnumDescriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{5}
+}
+
 // RecordingType enum for the available recording modes
 type RecordingType int32
 
@@ -61,11 +410,11 @@ func (x RecordingType) String() string {
 }
 
 func (RecordingType) Descriptor() protoreflect.EnumDescriptor {
-	return file_msgs_proto_enumTypes[0].Descriptor()
+	return file_msgs_proto_enumTypes[6].Descriptor()
 }
 
 func (RecordingType) Type() protoreflect.EnumType {
-	return &file_msgs_proto_enumTypes[0]
+	return &file_msgs_proto_enumTypes[6]
 }
 
 func (x RecordingType) Number() protoreflect.EnumNumber {
@@ -74,7 +423,60 @@ func (x RecordingType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use RecordingType.Descriptor instead.
 func (RecordingType) EnumDescriptor() ([]byte, []int) {
-	return file_msgs_proto_rawDescGZIP(), []int{0}
+	return file_msgs_proto_rawDescGZIP(), []int{6}
+}
+
+// BackupMode is which recordings a camera copies to the server's storage
+type BackupMode int32
+
+const (
+	BackupMode_BACKUP_MODE_UNSPECIFIED BackupMode = 0 // Treated as off
+	BackupMode_BACKUP_MODE_OFF         BackupMode = 1
+	BackupMode_BACKUP_MODE_ALL         BackupMode = 2
+	BackupMode_BACKUP_MODE_EVENTS      BackupMode = 3 // Only sessions started by motion or manually
+)
+
+// Enum value maps for BackupMode.
+var (
+	BackupMode_name = map[int32]string{
+		0: "BACKUP_MODE_UNSPECIFIED",
+		1: "BACKUP_MODE_OFF",
+		2: "BACKUP_MODE_ALL",
+		3: "BACKUP_MODE_EVENTS",
+	}
+	BackupMode_value = map[string]int32{
+		"BACKUP_MODE_UNSPECIFIED": 0,
+		"BACKUP_MODE_OFF":         1,
+		"BACKUP_MODE_ALL":         2,
+		"BACKUP_MODE_EVENTS":      3,
+	}
+)
+
+func (x BackupMode) Enum() *BackupMode {
+	p := new(BackupMode)
+	*p = x
+	return p
+}
+
+func (x BackupMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BackupMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_msgs_proto_enumTypes[7].Descriptor()
+}
+
+func (BackupMode) Type() protoreflect.EnumType {
+	return &file_msgs_proto_enumTypes[7]
+}
+
+func (x BackupMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BackupMode.Descriptor instead.
+func (BackupMode) EnumDescriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{7}
 }
 
 type Message struct {
@@ -92,7 +494,27 @@ type Message struct {
 	//	*Message_RecordResponse
 	//	*Message_UserConfig
 	//	*Message_TriggerRefresh
-	DataType      isMessage_DataType `protobuf_oneof:"data_type"`
+	//	*Message_CameraCommand
+	//	*Message_CommandResult
+	//	*Message_Deregister
+	//	*Message_AgentRelease
+	//	*Message_AgentStatus
+	//	*Message_Telemetry
+	//	*Message_LogRequest
+	//	*Message_LogBatch
+	//	*Message_HlsAck
+	//	*Message_RpcError
+	//	*Message_SegmentRequest
+	//	*Message_SegmentResponse
+	//	*Message_LockRangeRequest
+	//	*Message_DeleteRangeRequest
+	//	*Message_RangeResult
+	//	*Message_BookmarkUpdate
+	//	*Message_BookmarkQuery
+	//	*Message_BookmarkList
+	DataType isMessage_DataType `protobuf_oneof:"data_type"`
+	// Set on requests that expect replies, every reply echoes it
+	RequestId     string `protobuf:"bytes,22,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -229,49 +651,290 @@ func (x *Message) GetTriggerRefresh() *TriggerRefresh {
 	return nil
 }
 
-type isMessage_DataType interface {
-	isMessage_DataType()
+func (x *Message) GetCameraCommand() *CameraCommand {
+	if x != nil {
+		if x, ok := x.DataType.(*Message_CameraCommand); ok {
+			return x.CameraCommand
+		}
+	}
+	return nil
 }
 
-type Message_Webrtc struct {
-	Webrtc *Webrtc `protobuf:"bytes,3,opt,name=webrtc,proto3,oneof"`
+func (x *Message) GetCommandResult() *CommandResult {
+	if x != nil {
+		if x, ok := x.DataType.(*Message_CommandResult); ok {
+			return x.CommandResult
+		}
+	}
+	return nil
 }
 
-type Message_Initalization struct {
-	Initalization *Initalization `protobuf:"bytes,4,opt,name=initalization,proto3,oneof"`
+func (x *Message) GetDeregister() *Deregister {
+	if x != nil {
+		if x, ok := x.DataType.(*Message_Deregister); ok {
+			return x.Deregister
+		}
+	}
+	return nil
 }
 
-type Message_Response struct {
-	Response *Response `protobuf:"bytes,5,opt,name=response,proto3,oneof"`
+func (x *Message) GetAgentRelease() *AgentRelease {
+	if x != nil {
+		if x, ok := x.DataType.(*Message_AgentRelease); ok {
+			return x.AgentRelease
+		}
+	}
+	return nil
 }
 
-type Message_HlsRequest struct {
-	HlsRequest *HLSRequest `protobuf:"bytes,6,opt,name=hls_request,json=hlsRequest,proto3,oneof"`
+func (x *Message) GetAgentStatus() *AgentStatus {
+	if x != nil {
+		if x, ok := x.DataType.(*Message_AgentStatus); ok {
+			return x.AgentStatus
+		}
+	}
+	return nil
 }
 
-type Message_HlsResponse struct {
-	HlsResponse *HLSResponse `protobuf:"bytes,7,opt,name=hls_response,json=hlsResponse,proto3,oneof"`
+func (x *Message) GetTelemetry() *Telemetry {
+	if x != nil {
+		if x, ok := x.DataType.(*Message_Telemetry); ok {
+			return x.Telemetry
+		}
+	}
+	return nil
 }
 
-type Message_RecordRequest struct {
-	RecordRequest *RecordRequest `protobuf:"bytes,8,opt,name=record_request,json=recordRequest,proto3,oneof"`
+func (x *Message) GetLogRequest() *LogRequest {
+	if x != nil {
+		if x, ok := x.DataType.(*Message_LogRequest); ok {
+			return x.LogRequest
+		}
+	}
+	return nil
 }
 
-type Message_RecordResponse struct {
-	RecordResponse *RecordResponse `protobuf:"bytes,9,opt,name=record_response,json=recordResponse,proto3,oneof"`
+func (x *Message) GetLogBatch() *LogBatch {
+	if x != nil {
+		if x, ok := x.DataType.(*Message_LogBatch); ok {
+			return x.LogBatch
+		}
+	}
+	return nil
 }
 
-type Message_UserConfig struct {
-	UserConfig *UserConfig `protobuf:"bytes,10,opt,name=user_config,json=userConfig,proto3,oneof"`
+func (x *Message) GetHlsAck() *HLSAck {
+	if x != nil {
+		if x, ok := x.DataType.(*Message_HlsAck); ok {
+			return x.HlsAck
+		}
+	}
+	return nil
 }
 
-type Message_TriggerRefresh struct {
-	TriggerRefresh *TriggerRefresh `protobuf:"bytes,11,opt,name=trigger_refresh,json=triggerRefresh,proto3,oneof"`
+func (x *Message) GetRpcError() *RpcError {
+	if x != nil {
+		if x, ok := x.DataType.(*Message_RpcError); ok {
+			return x.RpcError
+		}
+	}
+	return nil
 }
 
-func (*Message_Webrtc) isMessage_DataType() {}
-
-func (*Message_Initalization) isMessage_DataType() {}
+func (x *Message) GetSegmentRequest() *SegmentRequest {
+	if x != nil {
+		if x, ok := x.DataType.(*Message_SegmentRequest); ok {
+			return x.SegmentRequest
+		}
+	}
+	return nil
+}
+
+func (x *Message) GetSegmentResponse() *SegmentResponse {
+	if x != nil {
+		if x, ok := x.DataType.(*Message_SegmentResponse); ok {
+			return x.SegmentResponse
+		}
+	}
+	return nil
+}
+
+func (x *Message) GetLockRangeRequest() *LockRangeRequest {
+	if x != nil {
+		if x, ok := x.DataType.(*Message_LockRangeRequest); ok {
+			return x.LockRangeRequest
+		}
+	}
+	return nil
+}
+
+func (x *Message) GetDeleteRangeRequest() *DeleteRangeRequest {
+	if x != nil {
+		if x, ok := x.DataType.(*Message_DeleteRangeRequest); ok {
+			return x.DeleteRangeRequest
+		}
+	}
+	return nil
+}
+
+func (x *Message) GetRangeResult() *RangeResult {
+	if x != nil {
+		if x, ok := x.DataType.(*Message_RangeResult); ok {
+			return x.RangeResult
+		}
+	}
+	return nil
+}
+
+func (x *Message) GetBookmarkUpdate() *BookmarkUpdate {
+	if x != nil {
+		if x, ok := x.DataType.(*Message_BookmarkUpdate); ok {
+			return x.BookmarkUpdate
+		}
+	}
+	return nil
+}
+
+func (x *Message) GetBookmarkQuery() *BookmarkQuery {
+	if x != nil {
+		if x, ok := x.DataType.(*Message_BookmarkQuery); ok {
+			return x.BookmarkQuery
+		}
+	}
+	return nil
+}
+
+func (x *Message) GetBookmarkList() *BookmarkList {
+	if x != nil {
+		if x, ok := x.DataType.(*Message_BookmarkList); ok {
+			return x.BookmarkList
+		}
+	}
+	return nil
+}
+
+func (x *Message) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type isMessage_DataType interface {
+	isMessage_DataType()
+}
+
+type Message_Webrtc struct {
+	Webrtc *Webrtc `protobuf:"bytes,3,opt,name=webrtc,proto3,oneof"`
+}
+
+type Message_Initalization struct {
+	Initalization *Initalization `protobuf:"bytes,4,opt,name=initalization,proto3,oneof"`
+}
+
+type Message_Response struct {
+	Response *Response `protobuf:"bytes,5,opt,name=response,proto3,oneof"`
+}
+
+type Message_HlsRequest struct {
+	HlsRequest *HLSRequest `protobuf:"bytes,6,opt,name=hls_request,json=hlsRequest,proto3,oneof"`
+}
+
+type Message_HlsResponse struct {
+	HlsResponse *HLSResponse `protobuf:"bytes,7,opt,name=hls_response,json=hlsResponse,proto3,oneof"`
+}
+
+type Message_RecordRequest struct {
+	RecordRequest *RecordRequest `protobuf:"bytes,8,opt,name=record_request,json=recordRequest,proto3,oneof"`
+}
+
+type Message_RecordResponse struct {
+	RecordResponse *RecordResponse `protobuf:"bytes,9,opt,name=record_response,json=recordResponse,proto3,oneof"`
+}
+
+type Message_UserConfig struct {
+	UserConfig *UserConfig `protobuf:"bytes,10,opt,name=user_config,json=userConfig,proto3,oneof"`
+}
+
+type Message_TriggerRefresh struct {
+	TriggerRefresh *TriggerRefresh `protobuf:"bytes,11,opt,name=trigger_refresh,json=triggerRefresh,proto3,oneof"`
+}
+
+type Message_CameraCommand struct {
+	CameraCommand *CameraCommand `protobuf:"bytes,12,opt,name=camera_command,json=cameraCommand,proto3,oneof"`
+}
+
+type Message_CommandResult struct {
+	CommandResult *CommandResult `protobuf:"bytes,13,opt,name=command_result,json=commandResult,proto3,oneof"`
+}
+
+type Message_Deregister struct {
+	Deregister *Deregister `protobuf:"bytes,14,opt,name=deregister,proto3,oneof"`
+}
+
+type Message_AgentRelease struct {
+	AgentRelease *AgentRelease `protobuf:"bytes,15,opt,name=agent_release,json=agentRelease,proto3,oneof"`
+}
+
+type Message_AgentStatus struct {
+	AgentStatus *AgentStatus `protobuf:"bytes,16,opt,name=agent_status,json=agentStatus,proto3,oneof"`
+}
+
+type Message_Telemetry struct {
+	Telemetry *Telemetry `protobuf:"bytes,17,opt,name=telemetry,proto3,oneof"`
+}
+
+type Message_LogRequest struct {
+	LogRequest *LogRequest `protobuf:"bytes,18,opt,name=log_request,json=logRequest,proto3,oneof"`
+}
+
+type Message_LogBatch struct {
+	LogBatch *LogBatch `protobuf:"bytes,19,opt,name=log_batch,json=logBatch,proto3,oneof"`
+}
+
+type Message_HlsAck struct {
+	HlsAck *HLSAck `protobuf:"bytes,20,opt,name=hls_ack,json=hlsAck,proto3,oneof"`
+}
+
+type Message_RpcError struct {
+	RpcError *RpcError `protobuf:"bytes,21,opt,name=rpc_error,json=rpcError,proto3,oneof"`
+}
+
+type Message_SegmentRequest struct {
+	SegmentRequest *SegmentRequest `protobuf:"bytes,23,opt,name=segment_request,json=segmentRequest,proto3,oneof"`
+}
+
+type Message_SegmentResponse struct {
+	SegmentResponse *SegmentResponse `protobuf:"bytes,24,opt,name=segment_response,json=segmentResponse,proto3,oneof"`
+}
+
+type Message_LockRangeRequest struct {
+	LockRangeRequest *LockRangeRequest `protobuf:"bytes,25,opt,name=lock_range_request,json=lockRangeRequest,proto3,oneof"`
+}
+
+type Message_DeleteRangeRequest struct {
+	DeleteRangeRequest *DeleteRangeRequest `protobuf:"bytes,26,opt,name=delete_range_request,json=deleteRangeRequest,proto3,oneof"`
+}
+
+type Message_RangeResult struct {
+	RangeResult *RangeResult `protobuf:"bytes,27,opt,name=range_result,json=rangeResult,proto3,oneof"`
+}
+
+type Message_BookmarkUpdate struct {
+	BookmarkUpdate *BookmarkUpdate `protobuf:"bytes,28,opt,name=bookmark_update,json=bookmarkUpdate,proto3,oneof"`
+}
+
+type Message_BookmarkQuery struct {
+	BookmarkQuery *BookmarkQuery `protobuf:"bytes,29,opt,name=bookmark_query,json=bookmarkQuery,proto3,oneof"`
+}
+
+type Message_BookmarkList struct {
+	BookmarkList *BookmarkList `protobuf:"bytes,30,opt,name=bookmark_list,json=bookmarkList,proto3,oneof"`
+}
+
+func (*Message_Webrtc) isMessage_DataType() {}
+
+func (*Message_Initalization) isMessage_DataType() {}
 
 func (*Message_Response) isMessage_DataType() {}
 
@@ -287,16 +950,109 @@ func (*Message_UserConfig) isMessage_DataType() {}
 
 func (*Message_TriggerRefresh) isMessage_DataType() {}
 
+func (*Message_CameraCommand) isMessage_DataType() {}
+
+func (*Message_CommandResult) isMessage_DataType() {}
+
+func (*Message_Deregister) isMessage_DataType() {}
+
+func (*Message_AgentRelease) isMessage_DataType() {}
+
+func (*Message_AgentStatus) isMessage_DataType() {}
+
+func (*Message_Telemetry) isMessage_DataType() {}
+
+func (*Message_LogRequest) isMessage_DataType() {}
+
+func (*Message_LogBatch) isMessage_DataType() {}
+
+func (*Message_HlsAck) isMessage_DataType() {}
+
+func (*Message_RpcError) isMessage_DataType() {}
+
+func (*Message_SegmentRequest) isMessage_DataType() {}
+
+func (*Message_SegmentResponse) isMessage_DataType() {}
+
+func (*Message_LockRangeRequest) isMessage_DataType() {}
+
+func (*Message_DeleteRangeRequest) isMessage_DataType() {}
+
+func (*Message_RangeResult) isMessage_DataType() {}
+
+func (*Message_BookmarkUpdate) isMessage_DataType() {}
+
+func (*Message_BookmarkQuery) isMessage_DataType() {}
+
+func (*Message_BookmarkList) isMessage_DataType() {}
+
+// RpcError is the reply to a request that failed, it ends the request
+type RpcError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          ErrorCode              `protobuf:"varint,1,opt,name=code,proto3,enum=rover.ErrorCode" json:"code,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RpcError) Reset() {
+	*x = RpcError{}
+	mi := &file_msgs_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RpcError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RpcError) ProtoMessage() {}
+
+func (x *RpcError) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RpcError.ProtoReflect.Descriptor instead.
+func (*RpcError) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RpcError) GetCode() ErrorCode {
+	if x != nil {
+		return x.Code
+	}
+	return ErrorCode_ERROR_CODE_UNSPECIFIED
+}
+
+func (x *RpcError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// HLSRequest asks the camera for a recording file, it answers with a stream
+// of HLSResponse chunks for the requested byte range
 type HLSRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	FileName      string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
+	Offset        int64                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"` // Negative counts from the end of the file
+	Length        int64                  `protobuf:"varint,4,opt,name=length,proto3" json:"length,omitempty"` // 0 reads to the end of the file
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *HLSRequest) Reset() {
 	*x = HLSRequest{}
-	mi := &file_msgs_proto_msgTypes[1]
+	mi := &file_msgs_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -308,7 +1064,7 @@ func (x *HLSRequest) String() string {
 func (*HLSRequest) ProtoMessage() {}
 
 func (x *HLSRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_msgs_proto_msgTypes[1]
+	mi := &file_msgs_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -321,7 +1077,7 @@ func (x *HLSRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HLSRequest.ProtoReflect.Descriptor instead.
 func (*HLSRequest) Descriptor() ([]byte, []int) {
-	return file_msgs_proto_rawDescGZIP(), []int{1}
+	return file_msgs_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *HLSRequest) GetFileName() string {
@@ -331,17 +1087,37 @@ func (x *HLSRequest) GetFileName() string {
 	return ""
 }
 
+func (x *HLSRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *HLSRequest) GetLength() int64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+// HLSResponse is one chunk of a file transfer
 type HLSResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	FileName      string                 `protobuf:"bytes,2,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
 	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Offset        int64                  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`                        // File offset of data
+	TotalSize     int64                  `protobuf:"varint,5,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"` // Size of the whole file
+	Length        int64                  `protobuf:"varint,6,opt,name=length,proto3" json:"length,omitempty"`                        // Bytes the transfer sends in total
+	Final         bool                   `protobuf:"varint,7,opt,name=final,proto3" json:"final,omitempty"`                          // Last chunk of the transfer
+	Sealed        bool                   `protobuf:"varint,8,opt,name=sealed,proto3" json:"sealed,omitempty"`                        // The file is an encrypted segment
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *HLSResponse) Reset() {
 	*x = HLSResponse{}
-	mi := &file_msgs_proto_msgTypes[2]
+	mi := &file_msgs_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -353,7 +1129,7 @@ func (x *HLSResponse) String() string {
 func (*HLSResponse) ProtoMessage() {}
 
 func (x *HLSResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_msgs_proto_msgTypes[2]
+	mi := &file_msgs_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -366,7 +1142,7 @@ func (x *HLSResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HLSResponse.ProtoReflect.Descriptor instead.
 func (*HLSResponse) Descriptor() ([]byte, []int) {
-	return file_msgs_proto_rawDescGZIP(), []int{2}
+	return file_msgs_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *HLSResponse) GetFileName() string {
@@ -383,30 +1159,67 @@ func (x *HLSResponse) GetData() []byte {
 	return nil
 }
 
-type RecordRequest struct {
+func (x *HLSResponse) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *HLSResponse) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+func (x *HLSResponse) GetLength() int64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+func (x *HLSResponse) GetFinal() bool {
+	if x != nil {
+		return x.Final
+	}
+	return false
+}
+
+func (x *HLSResponse) GetSealed() bool {
+	if x != nil {
+		return x.Sealed
+	}
+	return false
+}
+
+// HLSAck acknowledges the chunks received so far, the camera only keeps a
+// window of unacknowledged chunks in flight
+type HLSAck struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	StartTime     int64                  `protobuf:"varint,2,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
-	EndTime       int64                  `protobuf:"varint,3,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`          // request_id of the HLSRequest
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"` // File offset everything before has been received
+	Cancel        bool                   `protobuf:"varint,3,opt,name=cancel,proto3" json:"cancel,omitempty"` // Stop the transfer
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RecordRequest) Reset() {
-	*x = RecordRequest{}
-	mi := &file_msgs_proto_msgTypes[3]
+func (x *HLSAck) Reset() {
+	*x = HLSAck{}
+	mi := &file_msgs_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RecordRequest) String() string {
+func (x *HLSAck) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RecordRequest) ProtoMessage() {}
+func (*HLSAck) ProtoMessage() {}
 
-func (x *RecordRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_msgs_proto_msgTypes[3]
+func (x *HLSAck) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -417,56 +1230,1436 @@ func (x *RecordRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RecordRequest.ProtoReflect.Descriptor instead.
-func (*RecordRequest) Descriptor() ([]byte, []int) {
-	return file_msgs_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use HLSAck.ProtoReflect.Descriptor instead.
+func (*HLSAck) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *RecordRequest) GetId() int64 {
+func (x *HLSAck) GetId() string {
 	if x != nil {
 		return x.Id
 	}
-	return 0
+	return ""
 }
 
-func (x *RecordRequest) GetStartTime() int64 {
+func (x *HLSAck) GetOffset() int64 {
 	if x != nil {
-		return x.StartTime
+		return x.Offset
 	}
 	return 0
 }
 
-func (x *RecordRequest) GetEndTime() int64 {
+func (x *HLSAck) GetCancel() bool {
 	if x != nil {
-		return x.EndTime
+		return x.Cancel
 	}
-	return 0
+	return false
 }
 
-type VideoRange struct {
+// RecordRequest queries the camera's recording index for sessions overlapping
+// a time range. Times are Unix milliseconds, 0 leaves that end of the range open.
+type RecordRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartTime     int64                  `protobuf:"varint,2,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime       int64                  `protobuf:"varint,3,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	Offset        int32                  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"` // Recordings to skip, newest first
+	Limit         int32                  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`   // 0 for the camera default
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordRequest) Reset() {
+	*x = RecordRequest{}
+	mi := &file_msgs_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordRequest) ProtoMessage() {}
+
+func (x *RecordRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordRequest.ProtoReflect.Descriptor instead.
+func (*RecordRequest) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RecordRequest) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *RecordRequest) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+func (x *RecordRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *RecordRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// VideoRange is one recording session as seen by the index
+type VideoRange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartTime     int64                  `protobuf:"varint,1,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"` // Unix milliseconds of the first segment
+	EndTime       int64                  `protobuf:"varint,2,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`       // Unix milliseconds where the last segment ends
+	FileName      string                 `protobuf:"bytes,3,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`     // Session directory
+	Duration      float64                `protobuf:"fixed64,4,opt,name=duration,proto3" json:"duration,omitempty"`                   // Seconds of recorded video
+	Size          int64                  `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`                            // Bytes on disk
+	Segments      int32                  `protobuf:"varint,6,opt,name=segments,proto3" json:"segments,omitempty"`
+	Trigger       RecordingTrigger       `protobuf:"varint,7,opt,name=trigger,proto3,enum=rover.RecordingTrigger" json:"trigger,omitempty"`
+	Locked        bool                   `protobuf:"varint,8,opt,name=locked,proto3" json:"locked,omitempty"` // Some of it is locked, kept by retention and range deletes
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VideoRange) Reset() {
+	*x = VideoRange{}
+	mi := &file_msgs_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VideoRange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VideoRange) ProtoMessage() {}
+
+func (x *VideoRange) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VideoRange.ProtoReflect.Descriptor instead.
+func (*VideoRange) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *VideoRange) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *VideoRange) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+func (x *VideoRange) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *VideoRange) GetDuration() float64 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *VideoRange) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *VideoRange) GetSegments() int32 {
+	if x != nil {
+		return x.Segments
+	}
+	return 0
+}
+
+func (x *VideoRange) GetTrigger() RecordingTrigger {
+	if x != nil {
+		return x.Trigger
+	}
+	return RecordingTrigger_RECORDING_TRIGGER_UNSPECIFIED
+}
+
+func (x *VideoRange) GetLocked() bool {
+	if x != nil {
+		return x.Locked
+	}
+	return false
+}
+
+type RecordResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Records       []*VideoRange          `protobuf:"bytes,2,rep,name=records,proto3" json:"records,omitempty"`
+	Total         int32                  `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`                             // Matching recordings across all pages
+	NextOffset    int32                  `protobuf:"varint,4,opt,name=next_offset,json=nextOffset,proto3" json:"next_offset,omitempty"` // Offset of the next page, 0 on the last one
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordResponse) Reset() {
+	*x = RecordResponse{}
+	mi := &file_msgs_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordResponse) ProtoMessage() {}
+
+func (x *RecordResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordResponse.ProtoReflect.Descriptor instead.
+func (*RecordResponse) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RecordResponse) GetRecords() []*VideoRange {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+func (x *RecordResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *RecordResponse) GetNextOffset() int32 {
+	if x != nil {
+		return x.NextOffset
+	}
+	return 0
+}
+
+// SegmentRequest lists the recorded segments overlapping a time range, in Unix
+// milliseconds, e.g. to export them as one clip
+type SegmentRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	StartTime     int64                  `protobuf:"varint,1,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
 	EndTime       int64                  `protobuf:"varint,2,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
-	FileName      string                 `protobuf:"bytes,3,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *VideoRange) Reset() {
-	*x = VideoRange{}
-	mi := &file_msgs_proto_msgTypes[4]
+func (x *SegmentRequest) Reset() {
+	*x = SegmentRequest{}
+	mi := &file_msgs_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SegmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SegmentRequest) ProtoMessage() {}
+
+func (x *SegmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SegmentRequest.ProtoReflect.Descriptor instead.
+func (*SegmentRequest) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SegmentRequest) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *SegmentRequest) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+type SegmentInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileName      string                 `protobuf:"bytes,1,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`     // Path of the segment, <session>/<file>
+	StartTime     int64                  `protobuf:"varint,2,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"` // Unix milliseconds
+	Duration      float64                `protobuf:"fixed64,3,opt,name=duration,proto3" json:"duration,omitempty"`                   // Seconds
+	Size          int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SegmentInfo) Reset() {
+	*x = SegmentInfo{}
+	mi := &file_msgs_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SegmentInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SegmentInfo) ProtoMessage() {}
+
+func (x *SegmentInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SegmentInfo.ProtoReflect.Descriptor instead.
+func (*SegmentInfo) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SegmentInfo) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
+}
+
+func (x *SegmentInfo) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *SegmentInfo) GetDuration() float64 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *SegmentInfo) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+// SegmentResponse lists segments oldest first
+type SegmentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Segments      []*SegmentInfo         `protobuf:"bytes,1,rep,name=segments,proto3" json:"segments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SegmentResponse) Reset() {
+	*x = SegmentResponse{}
+	mi := &file_msgs_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SegmentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SegmentResponse) ProtoMessage() {}
+
+func (x *SegmentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SegmentResponse.ProtoReflect.Descriptor instead.
+func (*SegmentResponse) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SegmentResponse) GetSegments() []*SegmentInfo {
+	if x != nil {
+		return x.Segments
+	}
+	return nil
+}
+
+// LockRangeRequest locks or unlocks the recordings overlapping a time range in
+// Unix milliseconds. Retention keeps locked recordings and they can't be deleted.
+type LockRangeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartTime     int64                  `protobuf:"varint,1,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime       int64                  `protobuf:"varint,2,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	Locked        bool                   `protobuf:"varint,3,opt,name=locked,proto3" json:"locked,omitempty"` // false unlocks
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LockRangeRequest) Reset() {
+	*x = LockRangeRequest{}
+	mi := &file_msgs_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LockRangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockRangeRequest) ProtoMessage() {}
+
+func (x *LockRangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockRangeRequest.ProtoReflect.Descriptor instead.
+func (*LockRangeRequest) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *LockRangeRequest) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *LockRangeRequest) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+func (x *LockRangeRequest) GetLocked() bool {
+	if x != nil {
+		return x.Locked
+	}
+	return false
+}
+
+// DeleteRangeRequest deletes the recordings overlapping a time range in Unix
+// milliseconds, except locked ones and the session being recorded
+type DeleteRangeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartTime     int64                  `protobuf:"varint,1,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime       int64                  `protobuf:"varint,2,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRangeRequest) Reset() {
+	*x = DeleteRangeRequest{}
+	mi := &file_msgs_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRangeRequest) ProtoMessage() {}
+
+func (x *DeleteRangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRangeRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRangeRequest) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *DeleteRangeRequest) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *DeleteRangeRequest) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+// RangeResult answers a LockRangeRequest or DeleteRangeRequest
+type RangeResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Files         []string               `protobuf:"bytes,1,rep,name=files,proto3" json:"files,omitempty"`      // Segments locked, unlocked or deleted, <session>/<file>
+	Size          int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`       // Their total size in bytes
+	Skipped       int32                  `protobuf:"varint,3,opt,name=skipped,proto3" json:"skipped,omitempty"` // Segments a delete kept, locked or being recorded
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RangeResult) Reset() {
+	*x = RangeResult{}
+	mi := &file_msgs_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RangeResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangeResult) ProtoMessage() {}
+
+func (x *RangeResult) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangeResult.ProtoReflect.Descriptor instead.
+func (*RangeResult) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RangeResult) GetFiles() []string {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *RangeResult) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *RangeResult) GetSkipped() int32 {
+	if x != nil {
+		return x.Skipped
+	}
+	return 0
+}
+
+// Bookmark is a named moment or span of a camera's recordings, retention
+// keeps the segments it covers
+type Bookmark struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	StartTime     int64                  `protobuf:"varint,2,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"` // Unix milliseconds
+	EndTime       int64                  `protobuf:"varint,3,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`       // 0 for a moment
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Note          string                 `protobuf:"bytes,5,opt,name=note,proto3" json:"note,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // Unix milliseconds
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Bookmark) Reset() {
+	*x = Bookmark{}
+	mi := &file_msgs_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Bookmark) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Bookmark) ProtoMessage() {}
+
+func (x *Bookmark) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Bookmark.ProtoReflect.Descriptor instead.
+func (*Bookmark) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Bookmark) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Bookmark) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *Bookmark) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+func (x *Bookmark) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Bookmark) GetNote() string {
+	if x != nil {
+		return x.Note
+	}
+	return ""
+}
+
+func (x *Bookmark) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+// BookmarkUpdate adds or replaces a bookmark by id, or deletes it
+type BookmarkUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bookmark      *Bookmark              `protobuf:"bytes,1,opt,name=bookmark,proto3" json:"bookmark,omitempty"`
+	Delete        bool                   `protobuf:"varint,2,opt,name=delete,proto3" json:"delete,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookmarkUpdate) Reset() {
+	*x = BookmarkUpdate{}
+	mi := &file_msgs_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookmarkUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookmarkUpdate) ProtoMessage() {}
+
+func (x *BookmarkUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookmarkUpdate.ProtoReflect.Descriptor instead.
+func (*BookmarkUpdate) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *BookmarkUpdate) GetBookmark() *Bookmark {
+	if x != nil {
+		return x.Bookmark
+	}
+	return nil
+}
+
+func (x *BookmarkUpdate) GetDelete() bool {
+	if x != nil {
+		return x.Delete
+	}
+	return false
+}
+
+// BookmarkQuery searches bookmarks, every word of text must appear in the
+// name or note. Times are Unix milliseconds, 0 leaves that end open.
+type BookmarkQuery struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	StartTime     int64                  `protobuf:"varint,2,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime       int64                  `protobuf:"varint,3,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	Limit         int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookmarkQuery) Reset() {
+	*x = BookmarkQuery{}
+	mi := &file_msgs_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookmarkQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookmarkQuery) ProtoMessage() {}
+
+func (x *BookmarkQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookmarkQuery.ProtoReflect.Descriptor instead.
+func (*BookmarkQuery) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *BookmarkQuery) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *BookmarkQuery) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *BookmarkQuery) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+func (x *BookmarkQuery) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// BookmarkList answers a BookmarkQuery or BookmarkUpdate, newest first
+type BookmarkList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bookmarks     []*Bookmark            `protobuf:"bytes,1,rep,name=bookmarks,proto3" json:"bookmarks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BookmarkList) Reset() {
+	*x = BookmarkList{}
+	mi := &file_msgs_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BookmarkList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BookmarkList) ProtoMessage() {}
+
+func (x *BookmarkList) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BookmarkList.ProtoReflect.Descriptor instead.
+func (*BookmarkList) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *BookmarkList) GetBookmarks() []*Bookmark {
+	if x != nil {
+		return x.Bookmarks
+	}
+	return nil
+}
+
+type TriggerRefresh struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerRefresh) Reset() {
+	*x = TriggerRefresh{}
+	mi := &file_msgs_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerRefresh) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerRefresh) ProtoMessage() {}
+
+func (x *TriggerRefresh) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerRefresh.ProtoReflect.Descriptor instead.
+func (*TriggerRefresh) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{18}
+}
+
+// CameraCommand asks a camera to run a maintenance action
+type CameraCommand struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // Echoed back in the CommandResult
+	Type          CommandType            `protobuf:"varint,2,opt,name=type,proto3,enum=rover.CommandType" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CameraCommand) Reset() {
+	*x = CameraCommand{}
+	mi := &file_msgs_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CameraCommand) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CameraCommand) ProtoMessage() {}
+
+func (x *CameraCommand) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CameraCommand.ProtoReflect.Descriptor instead.
+func (*CameraCommand) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *CameraCommand) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CameraCommand) GetType() CommandType {
+	if x != nil {
+		return x.Type
+	}
+	return CommandType_COMMAND_TYPE_UNSPECIFIED
+}
+
+// CommandResult reports the outcome of a CameraCommand to the server
+type CommandResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type          CommandType            `protobuf:"varint,2,opt,name=type,proto3,enum=rover.CommandType" json:"type,omitempty"`
+	Status        CommandStatus          `protobuf:"varint,3,opt,name=status,proto3,enum=rover.CommandStatus" json:"status,omitempty"`
+	Output        string                 `protobuf:"bytes,4,opt,name=output,proto3" json:"output,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandResult) Reset() {
+	*x = CommandResult{}
+	mi := &file_msgs_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandResult) ProtoMessage() {}
+
+func (x *CommandResult) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandResult.ProtoReflect.Descriptor instead.
+func (*CommandResult) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *CommandResult) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CommandResult) GetType() CommandType {
+	if x != nil {
+		return x.Type
+	}
+	return CommandType_COMMAND_TYPE_UNSPECIFIED
+}
+
+func (x *CommandResult) GetStatus() CommandStatus {
+	if x != nil {
+		return x.Status
+	}
+	return CommandStatus_COMMAND_STATUS_UNSPECIFIED
+}
+
+func (x *CommandResult) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+type Webrtc struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StreamId      string                 `protobuf:"bytes,1,opt,name=stream_id,json=streamId,proto3" json:"stream_id,omitempty"`
+	Data          string                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Webrtc) Reset() {
+	*x = Webrtc{}
+	mi := &file_msgs_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Webrtc) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Webrtc) ProtoMessage() {}
+
+func (x *Webrtc) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Webrtc.ProtoReflect.Descriptor instead.
+func (*Webrtc) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *Webrtc) GetStreamId() string {
+	if x != nil {
+		return x.StreamId
+	}
+	return ""
+}
+
+func (x *Webrtc) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+type Initalization struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	IsUser        bool                   `protobuf:"varint,2,opt,name=is_user,json=isUser,proto3" json:"is_user,omitempty"`
+	Token         string                 `protobuf:"bytes,3,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Initalization) Reset() {
+	*x = Initalization{}
+	mi := &file_msgs_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Initalization) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Initalization) ProtoMessage() {}
+
+func (x *Initalization) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Initalization.ProtoReflect.Descriptor instead.
+func (*Initalization) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *Initalization) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Initalization) GetIsUser() bool {
+	if x != nil {
+		return x.IsUser
+	}
+	return false
+}
+
+func (x *Initalization) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type Response struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Response) Reset() {
+	*x = Response{}
+	mi := &file_msgs_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Response) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Response) ProtoMessage() {}
+
+func (x *Response) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Response.ProtoReflect.Descriptor instead.
+func (*Response) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *Response) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Response) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+// Deregister tells a camera it was deleted from the server, it wipes its
+// config and returns to setup
+type Deregister struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reason        string                 `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`
+	Recordings    RecordingRetention     `protobuf:"varint,2,opt,name=recordings,proto3,enum=rover.RecordingRetention" json:"recordings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Deregister) Reset() {
+	*x = Deregister{}
+	mi := &file_msgs_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Deregister) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Deregister) ProtoMessage() {}
+
+func (x *Deregister) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Deregister.ProtoReflect.Descriptor instead.
+func (*Deregister) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *Deregister) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Deregister) GetRecordings() RecordingRetention {
+	if x != nil {
+		return x.Recordings
+	}
+	return RecordingRetention_RECORDING_RETENTION_UNSPECIFIED
+}
+
+// AgentRelease tells a camera to install a signed agent build
+type AgentRelease struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Arch          string                 `protobuf:"bytes,2,opt,name=arch,proto3" json:"arch,omitempty"`
+	Url           string                 `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`       // Download path, relative to the server address
+	Sha256        string                 `protobuf:"bytes,4,opt,name=sha256,proto3" json:"sha256,omitempty"` // Hex encoded digest of the binary
+	Size          int64                  `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`
+	Signature     []byte                 `protobuf:"bytes,6,opt,name=signature,proto3" json:"signature,omitempty"` // Ed25519 signature by the offline release key
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentRelease) Reset() {
+	*x = AgentRelease{}
+	mi := &file_msgs_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentRelease) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentRelease) ProtoMessage() {}
+
+func (x *AgentRelease) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentRelease.ProtoReflect.Descriptor instead.
+func (*AgentRelease) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *AgentRelease) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *AgentRelease) GetArch() string {
+	if x != nil {
+		return x.Arch
+	}
+	return ""
+}
+
+func (x *AgentRelease) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *AgentRelease) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *AgentRelease) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *AgentRelease) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+// AgentStatus reports the running agent version and the last update outcome,
+// sent by the camera on every connect and while an update progresses
+type AgentStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Arch          string                 `protobuf:"bytes,2,opt,name=arch,proto3" json:"arch,omitempty"`
+	UpdateStatus  UpdateStatus           `protobuf:"varint,3,opt,name=update_status,json=updateStatus,proto3,enum=rover.UpdateStatus" json:"update_status,omitempty"`
+	TargetVersion string                 `protobuf:"bytes,4,opt,name=target_version,json=targetVersion,proto3" json:"target_version,omitempty"`
+	Error         string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentStatus) Reset() {
+	*x = AgentStatus{}
+	mi := &file_msgs_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentStatus) ProtoMessage() {}
+
+func (x *AgentStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentStatus.ProtoReflect.Descriptor instead.
+func (*AgentStatus) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *AgentStatus) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *AgentStatus) GetArch() string {
+	if x != nil {
+		return x.Arch
+	}
+	return ""
+}
+
+func (x *AgentStatus) GetUpdateStatus() UpdateStatus {
+	if x != nil {
+		return x.UpdateStatus
+	}
+	return UpdateStatus_UPDATE_STATUS_UNSPECIFIED
+}
+
+func (x *AgentStatus) GetTargetVersion() string {
+	if x != nil {
+		return x.TargetVersion
+	}
+	return ""
+}
+
+func (x *AgentStatus) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Telemetry is a periodic health sample sent by the camera
+type Telemetry struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp             int64                  `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // Unix seconds
+	UptimeSeconds         int64                  `protobuf:"varint,2,opt,name=uptime_seconds,json=uptimeSeconds,proto3" json:"uptime_seconds,omitempty"`
+	LoadAverage           float64                `protobuf:"fixed64,3,opt,name=load_average,json=loadAverage,proto3" json:"load_average,omitempty"`          // 1 minute load average
+	CpuUsage              float64                `protobuf:"fixed64,4,opt,name=cpu_usage,json=cpuUsage,proto3" json:"cpu_usage,omitempty"`                   // Percent busy since the previous sample
+	SocTemperature        float64                `protobuf:"fixed64,5,opt,name=soc_temperature,json=socTemperature,proto3" json:"soc_temperature,omitempty"` // Degrees Celsius, 0 when unavailable
+	DiskUsedBytes         int64                  `protobuf:"varint,6,opt,name=disk_used_bytes,json=diskUsedBytes,proto3" json:"disk_used_bytes,omitempty"`   // Filesystem holding the recordings
+	DiskTotalBytes        int64                  `protobuf:"varint,7,opt,name=disk_total_bytes,json=diskTotalBytes,proto3" json:"disk_total_bytes,omitempty"`
+	RecordingsBytes       int64                  `protobuf:"varint,8,opt,name=recordings_bytes,json=recordingsBytes,proto3" json:"recordings_bytes,omitempty"`
+	StreamFps             float64                `protobuf:"fixed64,9,opt,name=stream_fps,json=streamFps,proto3" json:"stream_fps,omitempty"`
+	StreamBitrate         float64                `protobuf:"fixed64,10,opt,name=stream_bitrate,json=streamBitrate,proto3" json:"stream_bitrate,omitempty"` // Bits per second
+	Viewers               int32                  `protobuf:"varint,11,opt,name=viewers,proto3" json:"viewers,omitempty"`
+	OldestRecording       int64                  `protobuf:"varint,12,opt,name=oldest_recording,json=oldestRecording,proto3" json:"oldest_recording,omitempty"`                     // Unix seconds, 0 without recordings
+	LockedBytes           int64                  `protobuf:"varint,13,opt,name=locked_bytes,json=lockedBytes,proto3" json:"locked_bytes,omitempty"`                                 // Recordings the retention policy must keep
+	RetentionDeletedBytes int64                  `protobuf:"varint,14,opt,name=retention_deleted_bytes,json=retentionDeletedBytes,proto3" json:"retention_deleted_bytes,omitempty"` // Deleted by the retention policy since the agent started
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *Telemetry) Reset() {
+	*x = Telemetry{}
+	mi := &file_msgs_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *VideoRange) String() string {
+func (x *Telemetry) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*VideoRange) ProtoMessage() {}
+func (*Telemetry) ProtoMessage() {}
 
-func (x *VideoRange) ProtoReflect() protoreflect.Message {
-	mi := &file_msgs_proto_msgTypes[4]
+func (x *Telemetry) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -477,143 +2670,137 @@ func (x *VideoRange) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use VideoRange.ProtoReflect.Descriptor instead.
-func (*VideoRange) Descriptor() ([]byte, []int) {
-	return file_msgs_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use Telemetry.ProtoReflect.Descriptor instead.
+func (*Telemetry) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{27}
 }
 
-func (x *VideoRange) GetStartTime() int64 {
+func (x *Telemetry) GetTimestamp() int64 {
 	if x != nil {
-		return x.StartTime
+		return x.Timestamp
 	}
 	return 0
 }
 
-func (x *VideoRange) GetEndTime() int64 {
+func (x *Telemetry) GetUptimeSeconds() int64 {
 	if x != nil {
-		return x.EndTime
+		return x.UptimeSeconds
 	}
 	return 0
 }
 
-func (x *VideoRange) GetFileName() string {
+func (x *Telemetry) GetLoadAverage() float64 {
 	if x != nil {
-		return x.FileName
+		return x.LoadAverage
 	}
-	return ""
-}
-
-type RecordResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Records       []*VideoRange          `protobuf:"bytes,2,rep,name=records,proto3" json:"records,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	return 0
 }
 
-func (x *RecordResponse) Reset() {
-	*x = RecordResponse{}
-	mi := &file_msgs_proto_msgTypes[5]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *Telemetry) GetCpuUsage() float64 {
+	if x != nil {
+		return x.CpuUsage
+	}
+	return 0
 }
 
-func (x *RecordResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *Telemetry) GetSocTemperature() float64 {
+	if x != nil {
+		return x.SocTemperature
+	}
+	return 0
 }
 
-func (*RecordResponse) ProtoMessage() {}
-
-func (x *RecordResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_msgs_proto_msgTypes[5]
+func (x *Telemetry) GetDiskUsedBytes() int64 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.DiskUsedBytes
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use RecordResponse.ProtoReflect.Descriptor instead.
-func (*RecordResponse) Descriptor() ([]byte, []int) {
-	return file_msgs_proto_rawDescGZIP(), []int{5}
+func (x *Telemetry) GetDiskTotalBytes() int64 {
+	if x != nil {
+		return x.DiskTotalBytes
+	}
+	return 0
 }
 
-func (x *RecordResponse) GetId() int64 {
+func (x *Telemetry) GetRecordingsBytes() int64 {
 	if x != nil {
-		return x.Id
+		return x.RecordingsBytes
 	}
 	return 0
 }
 
-func (x *RecordResponse) GetRecords() []*VideoRange {
+func (x *Telemetry) GetStreamFps() float64 {
 	if x != nil {
-		return x.Records
+		return x.StreamFps
 	}
-	return nil
+	return 0
 }
 
-type TriggerRefresh struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *Telemetry) GetStreamBitrate() float64 {
+	if x != nil {
+		return x.StreamBitrate
+	}
+	return 0
 }
 
-func (x *TriggerRefresh) Reset() {
-	*x = TriggerRefresh{}
-	mi := &file_msgs_proto_msgTypes[6]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *Telemetry) GetViewers() int32 {
+	if x != nil {
+		return x.Viewers
+	}
+	return 0
 }
 
-func (x *TriggerRefresh) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *Telemetry) GetOldestRecording() int64 {
+	if x != nil {
+		return x.OldestRecording
+	}
+	return 0
 }
 
-func (*TriggerRefresh) ProtoMessage() {}
-
-func (x *TriggerRefresh) ProtoReflect() protoreflect.Message {
-	mi := &file_msgs_proto_msgTypes[6]
+func (x *Telemetry) GetLockedBytes() int64 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.LockedBytes
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use TriggerRefresh.ProtoReflect.Descriptor instead.
-func (*TriggerRefresh) Descriptor() ([]byte, []int) {
-	return file_msgs_proto_rawDescGZIP(), []int{6}
+func (x *Telemetry) GetRetentionDeletedBytes() int64 {
+	if x != nil {
+		return x.RetentionDeletedBytes
+	}
+	return 0
 }
 
-type Webrtc struct {
+// LogRequest asks the camera for its buffered logs and optionally to keep
+// streaming new records until a request with stop set arrives
+type LogRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	StreamId      string                 `protobuf:"bytes,1,opt,name=stream_id,json=streamId,proto3" json:"stream_id,omitempty"`
-	Data          string                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // With stop, the request_id of the tail to stop
+	Follow        bool                   `protobuf:"varint,2,opt,name=follow,proto3" json:"follow,omitempty"`
+	Since         int64                  `protobuf:"varint,3,opt,name=since,proto3" json:"since,omitempty"`                       // Unix seconds, 0 for everything buffered
+	MinLevel      int32                  `protobuf:"varint,4,opt,name=min_level,json=minLevel,proto3" json:"min_level,omitempty"` // slog level, e.g. -4 debug, 0 info, 4 warn, 8 error
+	Stop          bool                   `protobuf:"varint,5,opt,name=stop,proto3" json:"stop,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Webrtc) Reset() {
-	*x = Webrtc{}
-	mi := &file_msgs_proto_msgTypes[7]
+func (x *LogRequest) Reset() {
+	*x = LogRequest{}
+	mi := &file_msgs_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Webrtc) String() string {
+func (x *LogRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Webrtc) ProtoMessage() {}
+func (*LogRequest) ProtoMessage() {}
 
-func (x *Webrtc) ProtoReflect() protoreflect.Message {
-	mi := &file_msgs_proto_msgTypes[7]
+func (x *LogRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -624,49 +2811,71 @@ func (x *Webrtc) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Webrtc.ProtoReflect.Descriptor instead.
-func (*Webrtc) Descriptor() ([]byte, []int) {
-	return file_msgs_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use LogRequest.ProtoReflect.Descriptor instead.
+func (*LogRequest) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{28}
 }
 
-func (x *Webrtc) GetStreamId() string {
+func (x *LogRequest) GetId() string {
 	if x != nil {
-		return x.StreamId
+		return x.Id
 	}
 	return ""
 }
 
-func (x *Webrtc) GetData() string {
+func (x *LogRequest) GetFollow() bool {
 	if x != nil {
-		return x.Data
+		return x.Follow
 	}
-	return ""
+	return false
 }
 
-type Initalization struct {
+func (x *LogRequest) GetSince() int64 {
+	if x != nil {
+		return x.Since
+	}
+	return 0
+}
+
+func (x *LogRequest) GetMinLevel() int32 {
+	if x != nil {
+		return x.MinLevel
+	}
+	return 0
+}
+
+func (x *LogRequest) GetStop() bool {
+	if x != nil {
+		return x.Stop
+	}
+	return false
+}
+
+type LogRecord struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	IsUser        bool                   `protobuf:"varint,2,opt,name=is_user,json=isUser,proto3" json:"is_user,omitempty"`
-	Token         string                 `protobuf:"bytes,3,opt,name=token,proto3" json:"token,omitempty"`
+	Time          int64                  `protobuf:"varint,1,opt,name=time,proto3" json:"time,omitempty"` // Unix milliseconds
+	Level         int32                  `protobuf:"varint,2,opt,name=level,proto3" json:"level,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Attrs         string                 `protobuf:"bytes,4,opt,name=attrs,proto3" json:"attrs,omitempty"` // key=value pairs
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Initalization) Reset() {
-	*x = Initalization{}
-	mi := &file_msgs_proto_msgTypes[8]
+func (x *LogRecord) Reset() {
+	*x = LogRecord{}
+	mi := &file_msgs_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Initalization) String() string {
+func (x *LogRecord) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Initalization) ProtoMessage() {}
+func (*LogRecord) ProtoMessage() {}
 
-func (x *Initalization) ProtoReflect() protoreflect.Message {
-	mi := &file_msgs_proto_msgTypes[8]
+func (x *LogRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -677,55 +2886,62 @@ func (x *Initalization) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Initalization.ProtoReflect.Descriptor instead.
-func (*Initalization) Descriptor() ([]byte, []int) {
-	return file_msgs_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use LogRecord.ProtoReflect.Descriptor instead.
+func (*LogRecord) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{29}
 }
 
-func (x *Initalization) GetId() string {
+func (x *LogRecord) GetTime() int64 {
 	if x != nil {
-		return x.Id
+		return x.Time
 	}
-	return ""
+	return 0
 }
 
-func (x *Initalization) GetIsUser() bool {
+func (x *LogRecord) GetLevel() int32 {
 	if x != nil {
-		return x.IsUser
+		return x.Level
 	}
-	return false
+	return 0
 }
 
-func (x *Initalization) GetToken() string {
+func (x *LogRecord) GetMessage() string {
 	if x != nil {
-		return x.Token
+		return x.Message
 	}
 	return ""
 }
 
-type Response struct {
+func (x *LogRecord) GetAttrs() string {
+	if x != nil {
+		return x.Attrs
+	}
+	return ""
+}
+
+type LogBatch struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
-	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	Records       []*LogRecord           `protobuf:"bytes,2,rep,name=records,proto3" json:"records,omitempty"`
+	Done          bool                   `protobuf:"varint,3,opt,name=done,proto3" json:"done,omitempty"` // No more batches for this request
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Response) Reset() {
-	*x = Response{}
-	mi := &file_msgs_proto_msgTypes[9]
+func (x *LogBatch) Reset() {
+	*x = LogBatch{}
+	mi := &file_msgs_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Response) String() string {
+func (x *LogBatch) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Response) ProtoMessage() {}
+func (*LogBatch) ProtoMessage() {}
 
-func (x *Response) ProtoReflect() protoreflect.Message {
-	mi := &file_msgs_proto_msgTypes[9]
+func (x *LogBatch) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -736,21 +2952,21 @@ func (x *Response) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Response.ProtoReflect.Descriptor instead.
-func (*Response) Descriptor() ([]byte, []int) {
-	return file_msgs_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use LogBatch.ProtoReflect.Descriptor instead.
+func (*LogBatch) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{30}
 }
 
-func (x *Response) GetMessage() string {
+func (x *LogBatch) GetRecords() []*LogRecord {
 	if x != nil {
-		return x.Message
+		return x.Records
 	}
-	return ""
+	return nil
 }
 
-func (x *Response) GetSuccess() bool {
+func (x *LogBatch) GetDone() bool {
 	if x != nil {
-		return x.Success
+		return x.Done
 	}
 	return false
 }
@@ -767,7 +2983,7 @@ type Schedule struct {
 
 func (x *Schedule) Reset() {
 	*x = Schedule{}
-	mi := &file_msgs_proto_msgTypes[10]
+	mi := &file_msgs_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -779,7 +2995,7 @@ func (x *Schedule) String() string {
 func (*Schedule) ProtoMessage() {}
 
 func (x *Schedule) ProtoReflect() protoreflect.Message {
-	mi := &file_msgs_proto_msgTypes[10]
+	mi := &file_msgs_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -792,7 +3008,7 @@ func (x *Schedule) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Schedule.ProtoReflect.Descriptor instead.
 func (*Schedule) Descriptor() ([]byte, []int) {
-	return file_msgs_proto_rawDescGZIP(), []int{10}
+	return file_msgs_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *Schedule) GetDaysOfWeek() []int32 {
@@ -828,7 +3044,7 @@ type MotionConfig struct {
 
 func (x *MotionConfig) Reset() {
 	*x = MotionConfig{}
-	mi := &file_msgs_proto_msgTypes[11]
+	mi := &file_msgs_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -840,7 +3056,7 @@ func (x *MotionConfig) String() string {
 func (*MotionConfig) ProtoMessage() {}
 
 func (x *MotionConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_msgs_proto_msgTypes[11]
+	mi := &file_msgs_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -853,7 +3069,7 @@ func (x *MotionConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MotionConfig.ProtoReflect.Descriptor instead.
 func (*MotionConfig) Descriptor() ([]byte, []int) {
-	return file_msgs_proto_rawDescGZIP(), []int{11}
+	return file_msgs_proto_rawDescGZIP(), []int{32}
 }
 
 func (x *MotionConfig) GetSensitivity() int32 {
@@ -877,6 +3093,68 @@ func (x *MotionConfig) GetPostRecordSeconds() int32 {
 	return 0
 }
 
+// RetentionPolicy bounds the recordings kept on the camera, the oldest
+// unlocked segments are deleted first. 0 disables a limit.
+type RetentionPolicy struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MaxAgeDays    int32                  `protobuf:"varint,1,opt,name=max_age_days,json=maxAgeDays,proto3" json:"max_age_days,omitempty"`
+	MaxBytes      int64                  `protobuf:"varint,2,opt,name=max_bytes,json=maxBytes,proto3" json:"max_bytes,omitempty"`               // Total size of all recordings
+	MinFreeBytes  int64                  `protobuf:"varint,3,opt,name=min_free_bytes,json=minFreeBytes,proto3" json:"min_free_bytes,omitempty"` // Free space floor, 0 for the camera default
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetentionPolicy) Reset() {
+	*x = RetentionPolicy{}
+	mi := &file_msgs_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetentionPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetentionPolicy) ProtoMessage() {}
+
+func (x *RetentionPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_msgs_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetentionPolicy.ProtoReflect.Descriptor instead.
+func (*RetentionPolicy) Descriptor() ([]byte, []int) {
+	return file_msgs_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *RetentionPolicy) GetMaxAgeDays() int32 {
+	if x != nil {
+		return x.MaxAgeDays
+	}
+	return 0
+}
+
+func (x *RetentionPolicy) GetMaxBytes() int64 {
+	if x != nil {
+		return x.MaxBytes
+	}
+	return 0
+}
+
+func (x *RetentionPolicy) GetMinFreeBytes() int64 {
+	if x != nil {
+		return x.MinFreeBytes
+	}
+	return 0
+}
+
 // UserConfig holds user configuration for camera recording settings
 type UserConfig struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -885,13 +3163,16 @@ type UserConfig struct {
 	MotionConfig  *MotionConfig          `protobuf:"bytes,3,opt,name=motion_config,json=motionConfig,proto3" json:"motion_config,omitempty"` // Only used for RECORDING_TYPE_MOTION
 	MotionEnabled bool                   `protobuf:"varint,4,opt,name=motion_enabled,json=motionEnabled,proto3" json:"motion_enabled,omitempty"`
 	Name          string                 `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
+	Retention     *RetentionPolicy       `protobuf:"bytes,6,opt,name=retention,proto3" json:"retention,omitempty"`
+	RecordingKey  []byte                 `protobuf:"bytes,7,opt,name=recording_key,json=recordingKey,proto3" json:"recording_key,omitempty"` // Owner's X25519 public key, recordings are encrypted to it when set
+	BackupMode    BackupMode             `protobuf:"varint,8,opt,name=backup_mode,json=backupMode,proto3,enum=rover.BackupMode" json:"backup_mode,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *UserConfig) Reset() {
 	*x = UserConfig{}
-	mi := &file_msgs_proto_msgTypes[12]
+	mi := &file_msgs_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -903,7 +3184,7 @@ func (x *UserConfig) String() string {
 func (*UserConfig) ProtoMessage() {}
 
 func (x *UserConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_msgs_proto_msgTypes[12]
+	mi := &file_msgs_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -916,7 +3197,7 @@ func (x *UserConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserConfig.ProtoReflect.Descriptor instead.
 func (*UserConfig) Descriptor() ([]byte, []int) {
-	return file_msgs_proto_rawDescGZIP(), []int{12}
+	return file_msgs_proto_rawDescGZIP(), []int{34}
 }
 
 func (x *UserConfig) GetRecordingType() RecordingType {
@@ -954,6 +3235,27 @@ func (x *UserConfig) GetName() string {
 	return ""
 }
 
+func (x *UserConfig) GetRetention() *RetentionPolicy {
+	if x != nil {
+		return x.Retention
+	}
+	return nil
+}
+
+func (x *UserConfig) GetRecordingKey() []byte {
+	if x != nil {
+		return x.RecordingKey
+	}
+	return nil
+}
+
+func (x *UserConfig) GetBackupMode() BackupMode {
+	if x != nil {
+		return x.BackupMode
+	}
+	return BackupMode_BACKUP_MODE_UNSPECIFIED
+}
+
 type Timestamp struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Seconds       int64                  `protobuf:"varint,1,opt,name=seconds,proto3" json:"seconds,omitempty"`
@@ -964,7 +3266,7 @@ type Timestamp struct {
 
 func (x *Timestamp) Reset() {
 	*x = Timestamp{}
-	mi := &file_msgs_proto_msgTypes[13]
+	mi := &file_msgs_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -976,7 +3278,7 @@ func (x *Timestamp) String() string {
 func (*Timestamp) ProtoMessage() {}
 
 func (x *Timestamp) ProtoReflect() protoreflect.Message {
-	mi := &file_msgs_proto_msgTypes[13]
+	mi := &file_msgs_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -989,7 +3291,7 @@ func (x *Timestamp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Timestamp.ProtoReflect.Descriptor instead.
 func (*Timestamp) Descriptor() ([]byte, []int) {
-	return file_msgs_proto_rawDescGZIP(), []int{13}
+	return file_msgs_proto_rawDescGZIP(), []int{35}
 }
 
 func (x *Timestamp) GetSeconds() int64 {
@@ -1010,7 +3312,7 @@ var File_msgs_proto protoreflect.FileDescriptor
 
 var file_msgs_proto_rawDesc = string([]byte{
 	0x0a, 0x0a, 0x6d, 0x73, 0x67, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x05, 0x72, 0x6f,
-	0x76, 0x65, 0x72, 0x22, 0xb8, 0x04, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12,
+	0x76, 0x65, 0x72, 0x22, 0x86, 0x0d, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12,
 	0x0e, 0x0a, 0x02, 0x74, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x74, 0x6f, 0x12,
 	0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x66,
 	0x72, 0x6f, 0x6d, 0x12, 0x27, 0x0a, 0x06, 0x77, 0x65, 0x62, 0x72, 0x74, 0x63, 0x18, 0x03, 0x20,
@@ -1045,92 +3347,447 @@ var file_msgs_proto_rawDesc = string([]byte{
 	0x73, 0x68, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72,
 	0x2e, 0x54, 0x72, 0x69, 0x67, 0x67, 0x65, 0x72, 0x52, 0x65, 0x66, 0x72, 0x65, 0x73, 0x68, 0x48,
 	0x00, 0x52, 0x0e, 0x74, 0x72, 0x69, 0x67, 0x67, 0x65, 0x72, 0x52, 0x65, 0x66, 0x72, 0x65, 0x73,
-	0x68, 0x42, 0x0b, 0x0a, 0x09, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x22, 0x29,
-	0x0a, 0x0a, 0x48, 0x4c, 0x53, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09,
-	0x66, 0x69, 0x6c, 0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x08, 0x66, 0x69, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x3e, 0x0a, 0x0b, 0x48, 0x4c, 0x53,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x66, 0x69, 0x6c, 0x65,
-	0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x69, 0x6c,
-	0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x59, 0x0a, 0x0d, 0x52, 0x65, 0x63,
-	0x6f, 0x72, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x74,
-	0x61, 0x72, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09,
-	0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x65, 0x6e, 0x64,
-	0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x65, 0x6e, 0x64,
-	0x54, 0x69, 0x6d, 0x65, 0x22, 0x63, 0x0a, 0x0a, 0x56, 0x69, 0x64, 0x65, 0x6f, 0x52, 0x61, 0x6e,
-	0x67, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d,
-	0x65, 0x12, 0x19, 0x0a, 0x08, 0x65, 0x6e, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x03, 0x52, 0x07, 0x65, 0x6e, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1b, 0x0a, 0x09,
-	0x66, 0x69, 0x6c, 0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x08, 0x66, 0x69, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x4d, 0x0a, 0x0e, 0x52, 0x65, 0x63,
-	0x6f, 0x72, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69,
-	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x2b, 0x0a, 0x07, 0x72,
-	0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x72,
-	0x6f, 0x76, 0x65, 0x72, 0x2e, 0x56, 0x69, 0x64, 0x65, 0x6f, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x52,
-	0x07, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x22, 0x10, 0x0a, 0x0e, 0x54, 0x72, 0x69, 0x67,
-	0x67, 0x65, 0x72, 0x52, 0x65, 0x66, 0x72, 0x65, 0x73, 0x68, 0x22, 0x39, 0x0a, 0x06, 0x57, 0x65,
-	0x62, 0x72, 0x74, 0x63, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x5f, 0x69,
-	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49,
-	0x64, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x4e, 0x0a, 0x0d, 0x49, 0x6e, 0x69, 0x74, 0x61, 0x6c, 0x69,
-	0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x69, 0x73, 0x5f, 0x75, 0x73, 0x65,
-	0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x69, 0x73, 0x55, 0x73, 0x65, 0x72, 0x12,
-	0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
-	0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x3e, 0x0a, 0x08, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73,
-	0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x75,
-	0x63, 0x63, 0x65, 0x73, 0x73, 0x22, 0x66, 0x0a, 0x08, 0x53, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c,
-	0x65, 0x12, 0x20, 0x0a, 0x0c, 0x64, 0x61, 0x79, 0x73, 0x5f, 0x6f, 0x66, 0x5f, 0x77, 0x65, 0x65,
-	0x6b, 0x18, 0x01, 0x20, 0x03, 0x28, 0x05, 0x52, 0x0a, 0x64, 0x61, 0x79, 0x73, 0x4f, 0x66, 0x57,
-	0x65, 0x65, 0x6b, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x74, 0x69, 0x6d,
-	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69,
-	0x6d, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x65, 0x6e, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x03,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x65, 0x6e, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x8e, 0x01,
-	0x0a, 0x0c, 0x4d, 0x6f, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x20,
-	0x0a, 0x0b, 0x73, 0x65, 0x6e, 0x73, 0x69, 0x74, 0x69, 0x76, 0x69, 0x74, 0x79, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x05, 0x52, 0x0b, 0x73, 0x65, 0x6e, 0x73, 0x69, 0x74, 0x69, 0x76, 0x69, 0x74, 0x79,
-	0x12, 0x2c, 0x0a, 0x12, 0x70, 0x72, 0x65, 0x5f, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x5f, 0x73,
-	0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x10, 0x70, 0x72,
-	0x65, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x2e,
-	0x0a, 0x13, 0x70, 0x6f, 0x73, 0x74, 0x5f, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x5f, 0x73, 0x65,
-	0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x11, 0x70, 0x6f, 0x73,
-	0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x22, 0xed,
-	0x01, 0x0a, 0x0a, 0x55, 0x73, 0x65, 0x72, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x3b, 0x0a,
-	0x0e, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x14, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x52, 0x65,
-	0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0d, 0x72, 0x65, 0x63,
-	0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x54, 0x79, 0x70, 0x65, 0x12, 0x2d, 0x0a, 0x09, 0x73, 0x63,
-	0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e,
-	0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x53, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x52, 0x09,
-	0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x73, 0x12, 0x38, 0x0a, 0x0d, 0x6d, 0x6f, 0x74,
-	0x69, 0x6f, 0x6e, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x13, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x4d, 0x6f, 0x74, 0x69, 0x6f, 0x6e, 0x43,
-	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x0c, 0x6d, 0x6f, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e,
-	0x66, 0x69, 0x67, 0x12, 0x25, 0x0a, 0x0e, 0x6d, 0x6f, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x65, 0x6e,
-	0x61, 0x62, 0x6c, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x6d, 0x6f, 0x74,
-	0x69, 0x6f, 0x6e, 0x45, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
-	0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x3b,
-	0x0a, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x18, 0x0a, 0x07, 0x73,
-	0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x73, 0x65,
-	0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x61, 0x6e, 0x6f, 0x73, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6e, 0x61, 0x6e, 0x6f, 0x73, 0x2a, 0xaa, 0x01, 0x0a, 0x0d,
-	0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1e, 0x0a,
-	0x1a, 0x52, 0x45, 0x43, 0x4f, 0x52, 0x44, 0x49, 0x4e, 0x47, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f,
-	0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x16, 0x0a,
-	0x12, 0x52, 0x45, 0x43, 0x4f, 0x52, 0x44, 0x49, 0x4e, 0x47, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f,
-	0x4f, 0x46, 0x46, 0x10, 0x01, 0x12, 0x1d, 0x0a, 0x19, 0x52, 0x45, 0x43, 0x4f, 0x52, 0x44, 0x49,
+	0x68, 0x12, 0x3d, 0x0a, 0x0e, 0x63, 0x61, 0x6d, 0x65, 0x72, 0x61, 0x5f, 0x63, 0x6f, 0x6d, 0x6d,
+	0x61, 0x6e, 0x64, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x72, 0x6f, 0x76, 0x65,
+	0x72, 0x2e, 0x43, 0x61, 0x6d, 0x65, 0x72, 0x61, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x48,
+	0x00, 0x52, 0x0d, 0x63, 0x61, 0x6d, 0x65, 0x72, 0x61, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64,
+	0x12, 0x3d, 0x0a, 0x0e, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x5f, 0x72, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72,
+	0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x48, 0x00,
+	0x52, 0x0d, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12,
+	0x33, 0x0a, 0x0a, 0x64, 0x65, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x18, 0x0e, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x44, 0x65, 0x72, 0x65,
+	0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x48, 0x00, 0x52, 0x0a, 0x64, 0x65, 0x72, 0x65, 0x67, 0x69,
+	0x73, 0x74, 0x65, 0x72, 0x12, 0x3a, 0x0a, 0x0d, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x5f, 0x72, 0x65,
+	0x6c, 0x65, 0x61, 0x73, 0x65, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x72, 0x6f,
+	0x76, 0x65, 0x72, 0x2e, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65,
+	0x48, 0x00, 0x52, 0x0c, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65,
+	0x12, 0x37, 0x0a, 0x0c, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x18, 0x10, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x41,
+	0x67, 0x65, 0x6e, 0x74, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x48, 0x00, 0x52, 0x0b, 0x61, 0x67,
+	0x65, 0x6e, 0x74, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x30, 0x0a, 0x09, 0x74, 0x65, 0x6c,
+	0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x18, 0x11, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x72,
+	0x6f, 0x76, 0x65, 0x72, 0x2e, 0x54, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x48, 0x00,
+	0x52, 0x09, 0x74, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x12, 0x34, 0x0a, 0x0b, 0x6c,
+	0x6f, 0x67, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x12, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x11, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x4c, 0x6f, 0x67, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x0a, 0x6c, 0x6f, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x2e, 0x0a, 0x09, 0x6c, 0x6f, 0x67, 0x5f, 0x62, 0x61, 0x74, 0x63, 0x68, 0x18, 0x13,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x4c, 0x6f, 0x67,
+	0x42, 0x61, 0x74, 0x63, 0x68, 0x48, 0x00, 0x52, 0x08, 0x6c, 0x6f, 0x67, 0x42, 0x61, 0x74, 0x63,
+	0x68, 0x12, 0x28, 0x0a, 0x07, 0x68, 0x6c, 0x73, 0x5f, 0x61, 0x63, 0x6b, 0x18, 0x14, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x48, 0x4c, 0x53, 0x41, 0x63,
+	0x6b, 0x48, 0x00, 0x52, 0x06, 0x68, 0x6c, 0x73, 0x41, 0x63, 0x6b, 0x12, 0x2e, 0x0a, 0x09, 0x72,
+	0x70, 0x63, 0x5f, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x15, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f,
+	0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x52, 0x70, 0x63, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x48,
+	0x00, 0x52, 0x08, 0x72, 0x70, 0x63, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x40, 0x0a, 0x0f, 0x73,
+	0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x17,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x53, 0x65, 0x67,
+	0x6d, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x0e, 0x73,
+	0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x43, 0x0a,
+	0x10, 0x73, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x18, 0x18, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e,
+	0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48,
+	0x00, 0x52, 0x0f, 0x73, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x47, 0x0a, 0x12, 0x6c, 0x6f, 0x63, 0x6b, 0x5f, 0x72, 0x61, 0x6e, 0x67, 0x65,
+	0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x19, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17,
+	0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x4c, 0x6f, 0x63, 0x6b, 0x52, 0x61, 0x6e, 0x67, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x10, 0x6c, 0x6f, 0x63, 0x6b, 0x52,
+	0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x4d, 0x0a, 0x14, 0x64,
+	0x65, 0x6c, 0x65, 0x74, 0x65, 0x5f, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x5f, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x72, 0x6f, 0x76, 0x65,
+	0x72, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x12, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x61,
+	0x6e, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x37, 0x0a, 0x0c, 0x72, 0x61,
+	0x6e, 0x67, 0x65, 0x5f, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x1b, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x12, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x48, 0x00, 0x52, 0x0b, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x12, 0x40, 0x0a, 0x0f, 0x62, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72, 0x6b, 0x5f,
+	0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x18, 0x1c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x72,
+	0x6f, 0x76, 0x65, 0x72, 0x2e, 0x42, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72, 0x6b, 0x55, 0x70, 0x64,
+	0x61, 0x74, 0x65, 0x48, 0x00, 0x52, 0x0e, 0x62, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72, 0x6b, 0x55,
+	0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x3d, 0x0a, 0x0e, 0x62, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72,
+	0x6b, 0x5f, 0x71, 0x75, 0x65, 0x72, 0x79, 0x18, 0x1d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e,
+	0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x42, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72, 0x6b, 0x51, 0x75,
+	0x65, 0x72, 0x79, 0x48, 0x00, 0x52, 0x0d, 0x62, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72, 0x6b, 0x51,
+	0x75, 0x65, 0x72, 0x79, 0x12, 0x3a, 0x0a, 0x0d, 0x62, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72, 0x6b,
+	0x5f, 0x6c, 0x69, 0x73, 0x74, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x72, 0x6f,
+	0x76, 0x65, 0x72, 0x2e, 0x42, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72, 0x6b, 0x4c, 0x69, 0x73, 0x74,
+	0x48, 0x00, 0x52, 0x0c, 0x62, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72, 0x6b, 0x4c, 0x69, 0x73, 0x74,
+	0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x16,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x42,
+	0x0b, 0x0a, 0x09, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x22, 0x4a, 0x0a, 0x08,
+	0x52, 0x70, 0x63, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x24, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x45,
+	0x72, 0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x18,
+	0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x5f, 0x0a, 0x0a, 0x48, 0x4c, 0x53, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x66, 0x69, 0x6c, 0x65, 0x5f, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x4e,
+	0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x6c,
+	0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x6c, 0x65, 0x6e,
+	0x67, 0x74, 0x68, 0x4a, 0x04, 0x08, 0x01, 0x10, 0x02, 0x22, 0xcd, 0x01, 0x0a, 0x0b, 0x48, 0x4c,
+	0x53, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x66, 0x69, 0x6c,
+	0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x69,
+	0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x66,
+	0x66, 0x73, 0x65, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x6f, 0x66, 0x66, 0x73,
+	0x65, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x73, 0x69, 0x7a, 0x65,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x53, 0x69, 0x7a,
+	0x65, 0x12, 0x16, 0x0a, 0x06, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x06, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x14, 0x0a, 0x05, 0x66, 0x69, 0x6e,
+	0x61, 0x6c, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x66, 0x69, 0x6e, 0x61, 0x6c, 0x12,
+	0x16, 0x0a, 0x06, 0x73, 0x65, 0x61, 0x6c, 0x65, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x06, 0x73, 0x65, 0x61, 0x6c, 0x65, 0x64, 0x4a, 0x04, 0x08, 0x03, 0x10, 0x04, 0x4a, 0x04, 0x08,
+	0x09, 0x10, 0x0a, 0x4a, 0x04, 0x08, 0x0a, 0x10, 0x0b, 0x22, 0x48, 0x0a, 0x06, 0x48, 0x4c, 0x53,
+	0x41, 0x63, 0x6b, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x02, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x63,
+	0x61, 0x6e, 0x63, 0x65, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x63, 0x61, 0x6e,
+	0x63, 0x65, 0x6c, 0x22, 0x7d, 0x0a, 0x0d, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x74, 0x69,
+	0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54,
+	0x69, 0x6d, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x65, 0x6e, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x65, 0x6e, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x16,
+	0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06,
+	0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x4a, 0x04, 0x08, 0x01,
+	0x10, 0x02, 0x22, 0xfa, 0x01, 0x0a, 0x0a, 0x56, 0x69, 0x64, 0x65, 0x6f, 0x52, 0x61, 0x6e, 0x67,
+	0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65,
+	0x12, 0x19, 0x0a, 0x08, 0x65, 0x6e, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x07, 0x65, 0x6e, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x66,
+	0x69, 0x6c, 0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x66, 0x69, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x75, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x64, 0x75, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x67, 0x6d,
+	0x65, 0x6e, 0x74, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x73, 0x65, 0x67, 0x6d,
+	0x65, 0x6e, 0x74, 0x73, 0x12, 0x31, 0x0a, 0x07, 0x74, 0x72, 0x69, 0x67, 0x67, 0x65, 0x72, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x52, 0x65,
+	0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x54, 0x72, 0x69, 0x67, 0x67, 0x65, 0x72, 0x52, 0x07,
+	0x74, 0x72, 0x69, 0x67, 0x67, 0x65, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x6c, 0x6f, 0x63, 0x6b, 0x65,
+	0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x22,
+	0x7a, 0x0a, 0x0e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x2b, 0x0a, 0x07, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x11, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x56, 0x69, 0x64, 0x65, 0x6f,
+	0x52, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x07, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x12, 0x14,
+	0x0a, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x74,
+	0x6f, 0x74, 0x61, 0x6c, 0x12, 0x1f, 0x0a, 0x0b, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x6f, 0x66, 0x66,
+	0x73, 0x65, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x6e, 0x65, 0x78, 0x74, 0x4f,
+	0x66, 0x66, 0x73, 0x65, 0x74, 0x4a, 0x04, 0x08, 0x01, 0x10, 0x02, 0x22, 0x4a, 0x0a, 0x0e, 0x53,
+	0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a,
+	0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x19, 0x0a, 0x08,
+	0x65, 0x6e, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07,
+	0x65, 0x6e, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x79, 0x0a, 0x0b, 0x53, 0x65, 0x67, 0x6d, 0x65,
+	0x6e, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1b, 0x0a, 0x09, 0x66, 0x69, 0x6c, 0x65, 0x5f, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x4e,
+	0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x74, 0x69, 0x6d,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69,
+	0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12,
+	0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x73, 0x69,
+	0x7a, 0x65, 0x22, 0x41, 0x0a, 0x0f, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2e, 0x0a, 0x08, 0x73, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e,
+	0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x08, 0x73, 0x65, 0x67,
+	0x6d, 0x65, 0x6e, 0x74, 0x73, 0x22, 0x64, 0x0a, 0x10, 0x4c, 0x6f, 0x63, 0x6b, 0x52, 0x61, 0x6e,
+	0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x74, 0x61,
+	0x72, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x73,
+	0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x65, 0x6e, 0x64, 0x5f,
+	0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x65, 0x6e, 0x64, 0x54,
+	0x69, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x06, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x22, 0x4e, 0x0a, 0x12, 0x44,
+	0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65,
+	0x12, 0x19, 0x0a, 0x08, 0x65, 0x6e, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x07, 0x65, 0x6e, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x51, 0x0a, 0x0b, 0x52,
+	0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x66, 0x69,
+	0x6c, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x66, 0x69, 0x6c, 0x65, 0x73,
+	0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04,
+	0x73, 0x69, 0x7a, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x6b, 0x69, 0x70, 0x70, 0x65, 0x64, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x73, 0x6b, 0x69, 0x70, 0x70, 0x65, 0x64, 0x22, 0x9b,
+	0x01, 0x0a, 0x08, 0x42, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72, 0x6b, 0x12, 0x0e, 0x0a, 0x02, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x73,
+	0x74, 0x61, 0x72, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x65, 0x6e,
+	0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x65, 0x6e,
+	0x64, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x6f, 0x74,
+	0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x6f, 0x74, 0x65, 0x12, 0x1d, 0x0a,
+	0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x22, 0x55, 0x0a, 0x0e,
+	0x42, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72, 0x6b, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x2b,
+	0x0a, 0x08, 0x62, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0f, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x42, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72,
+	0x6b, 0x52, 0x08, 0x62, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72, 0x6b, 0x12, 0x16, 0x0a, 0x06, 0x64,
+	0x65, 0x6c, 0x65, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x64, 0x65, 0x6c,
+	0x65, 0x74, 0x65, 0x22, 0x73, 0x0a, 0x0d, 0x42, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72, 0x6b, 0x51,
+	0x75, 0x65, 0x72, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x74, 0x61, 0x72,
+	0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x73, 0x74,
+	0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x65, 0x6e, 0x64, 0x5f, 0x74,
+	0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x65, 0x6e, 0x64, 0x54, 0x69,
+	0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x22, 0x3d, 0x0a, 0x0c, 0x42, 0x6f, 0x6f, 0x6b,
+	0x6d, 0x61, 0x72, 0x6b, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x2d, 0x0a, 0x09, 0x62, 0x6f, 0x6f, 0x6b,
+	0x6d, 0x61, 0x72, 0x6b, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x72, 0x6f,
+	0x76, 0x65, 0x72, 0x2e, 0x42, 0x6f, 0x6f, 0x6b, 0x6d, 0x61, 0x72, 0x6b, 0x52, 0x09, 0x62, 0x6f,
+	0x6f, 0x6b, 0x6d, 0x61, 0x72, 0x6b, 0x73, 0x22, 0x10, 0x0a, 0x0e, 0x54, 0x72, 0x69, 0x67, 0x67,
+	0x65, 0x72, 0x52, 0x65, 0x66, 0x72, 0x65, 0x73, 0x68, 0x22, 0x47, 0x0a, 0x0d, 0x43, 0x61, 0x6d,
+	0x65, 0x72, 0x61, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x26, 0x0a, 0x04, 0x74, 0x79,
+	0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x12, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72,
+	0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79,
+	0x70, 0x65, 0x22, 0x8d, 0x01, 0x0a, 0x0d, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x52, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x02, 0x69, 0x64, 0x12, 0x26, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x12, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x61,
+	0x6e, 0x64, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x2c, 0x0a, 0x06,
+	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x14, 0x2e, 0x72,
+	0x6f, 0x76, 0x65, 0x72, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x75,
+	0x74, 0x70, 0x75, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6f, 0x75, 0x74, 0x70,
+	0x75, 0x74, 0x22, 0x39, 0x0a, 0x06, 0x57, 0x65, 0x62, 0x72, 0x74, 0x63, 0x12, 0x1b, 0x0a, 0x09,
+	0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74,
+	0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x4e, 0x0a,
+	0x0d, 0x49, 0x6e, 0x69, 0x74, 0x61, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0e,
+	0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x17,
+	0x0a, 0x07, 0x69, 0x73, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x06, 0x69, 0x73, 0x55, 0x73, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x3e, 0x0a,
+	0x08, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x22, 0x5f, 0x0a,
+	0x0a, 0x44, 0x65, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x72,
+	0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61,
+	0x73, 0x6f, 0x6e, 0x12, 0x39, 0x0a, 0x0a, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e,
+	0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x0a, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x73, 0x22, 0x98,
+	0x01, 0x0a, 0x0c, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x12,
+	0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x63,
+	0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x61, 0x72, 0x63, 0x68, 0x12, 0x10, 0x0a,
+	0x03, 0x75, 0x72, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x12,
+	0x16, 0x0a, 0x06, 0x73, 0x68, 0x61, 0x32, 0x35, 0x36, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x73, 0x68, 0x61, 0x32, 0x35, 0x36, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x73,
+	0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09,
+	0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x22, 0xb2, 0x01, 0x0a, 0x0b, 0x41, 0x67,
+	0x65, 0x6e, 0x74, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x63, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x61, 0x72, 0x63, 0x68, 0x12, 0x38, 0x0a, 0x0d, 0x75, 0x70, 0x64, 0x61, 0x74,
+	0x65, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13,
+	0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x52, 0x0c, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x12, 0x25, 0x0a, 0x0e, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x5f, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x74, 0x61, 0x72, 0x67, 0x65,
+	0x74, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f,
+	0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x9c,
+	0x04, 0x0a, 0x09, 0x54, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x12, 0x1c, 0x0a, 0x09,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x25, 0x0a, 0x0e, 0x75, 0x70,
+	0x74, 0x69, 0x6d, 0x65, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0d, 0x75, 0x70, 0x74, 0x69, 0x6d, 0x65, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64,
+	0x73, 0x12, 0x21, 0x0a, 0x0c, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x61, 0x76, 0x65, 0x72, 0x61, 0x67,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0b, 0x6c, 0x6f, 0x61, 0x64, 0x41, 0x76, 0x65,
+	0x72, 0x61, 0x67, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x70, 0x75, 0x5f, 0x75, 0x73, 0x61, 0x67,
+	0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x63, 0x70, 0x75, 0x55, 0x73, 0x61, 0x67,
+	0x65, 0x12, 0x27, 0x0a, 0x0f, 0x73, 0x6f, 0x63, 0x5f, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61,
+	0x74, 0x75, 0x72, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0e, 0x73, 0x6f, 0x63, 0x54,
+	0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x26, 0x0a, 0x0f, 0x64, 0x69,
+	0x73, 0x6b, 0x5f, 0x75, 0x73, 0x65, 0x64, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x0d, 0x64, 0x69, 0x73, 0x6b, 0x55, 0x73, 0x65, 0x64, 0x42, 0x79, 0x74,
+	0x65, 0x73, 0x12, 0x28, 0x0a, 0x10, 0x64, 0x69, 0x73, 0x6b, 0x5f, 0x74, 0x6f, 0x74, 0x61, 0x6c,
+	0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x64, 0x69,
+	0x73, 0x6b, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x29, 0x0a, 0x10,
+	0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x73, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e,
+	0x67, 0x73, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x74, 0x72, 0x65, 0x61,
+	0x6d, 0x5f, 0x66, 0x70, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x73, 0x74, 0x72,
+	0x65, 0x61, 0x6d, 0x46, 0x70, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x5f, 0x62, 0x69, 0x74, 0x72, 0x61, 0x74, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0d,
+	0x73, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x42, 0x69, 0x74, 0x72, 0x61, 0x74, 0x65, 0x12, 0x18, 0x0a,
+	0x07, 0x76, 0x69, 0x65, 0x77, 0x65, 0x72, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07,
+	0x76, 0x69, 0x65, 0x77, 0x65, 0x72, 0x73, 0x12, 0x29, 0x0a, 0x10, 0x6f, 0x6c, 0x64, 0x65, 0x73,
+	0x74, 0x5f, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x18, 0x0c, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0f, 0x6f, 0x6c, 0x64, 0x65, 0x73, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69,
+	0x6e, 0x67, 0x12, 0x21, 0x0a, 0x0c, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x5f, 0x62, 0x79, 0x74,
+	0x65, 0x73, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x6c, 0x6f, 0x63, 0x6b, 0x65, 0x64,
+	0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x36, 0x0a, 0x17, 0x72, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69,
+	0x6f, 0x6e, 0x5f, 0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73,
+	0x18, 0x0e, 0x20, 0x01, 0x28, 0x03, 0x52, 0x15, 0x72, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f,
+	0x6e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x42, 0x79, 0x74, 0x65, 0x73, 0x22, 0x7b, 0x0a,
+	0x0a, 0x4c, 0x6f, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x66,
+	0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x66, 0x6f, 0x6c,
+	0x6c, 0x6f, 0x77, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x05, 0x73, 0x69, 0x6e, 0x63, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x69, 0x6e,
+	0x5f, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x6d, 0x69,
+	0x6e, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x74, 0x6f, 0x70, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x73, 0x74, 0x6f, 0x70, 0x22, 0x65, 0x0a, 0x09, 0x4c, 0x6f,
+	0x67, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6c,
+	0x65, 0x76, 0x65, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65,
+	0x6c, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x61,
+	0x74, 0x74, 0x72, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x74, 0x74, 0x72,
+	0x73, 0x22, 0x50, 0x0a, 0x08, 0x4c, 0x6f, 0x67, 0x42, 0x61, 0x74, 0x63, 0x68, 0x12, 0x2a, 0x0a,
+	0x07, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10,
+	0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x4c, 0x6f, 0x67, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64,
+	0x52, 0x07, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x6f, 0x6e,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x64, 0x6f, 0x6e, 0x65, 0x4a, 0x04, 0x08,
+	0x01, 0x10, 0x02, 0x22, 0x66, 0x0a, 0x08, 0x53, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x12,
+	0x20, 0x0a, 0x0c, 0x64, 0x61, 0x79, 0x73, 0x5f, 0x6f, 0x66, 0x5f, 0x77, 0x65, 0x65, 0x6b, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x05, 0x52, 0x0a, 0x64, 0x61, 0x79, 0x73, 0x4f, 0x66, 0x57, 0x65, 0x65,
+	0x6b, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65,
+	0x12, 0x19, 0x0a, 0x08, 0x65, 0x6e, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x65, 0x6e, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x8e, 0x01, 0x0a, 0x0c,
+	0x4d, 0x6f, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x20, 0x0a, 0x0b,
+	0x73, 0x65, 0x6e, 0x73, 0x69, 0x74, 0x69, 0x76, 0x69, 0x74, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x0b, 0x73, 0x65, 0x6e, 0x73, 0x69, 0x74, 0x69, 0x76, 0x69, 0x74, 0x79, 0x12, 0x2c,
+	0x0a, 0x12, 0x70, 0x72, 0x65, 0x5f, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x5f, 0x73, 0x65, 0x63,
+	0x6f, 0x6e, 0x64, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x10, 0x70, 0x72, 0x65, 0x52,
+	0x65, 0x63, 0x6f, 0x72, 0x64, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x2e, 0x0a, 0x13,
+	0x70, 0x6f, 0x73, 0x74, 0x5f, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x5f, 0x73, 0x65, 0x63, 0x6f,
+	0x6e, 0x64, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x11, 0x70, 0x6f, 0x73, 0x74, 0x52,
+	0x65, 0x63, 0x6f, 0x72, 0x64, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x22, 0x76, 0x0a, 0x0f,
+	0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12,
+	0x20, 0x0a, 0x0c, 0x6d, 0x61, 0x78, 0x5f, 0x61, 0x67, 0x65, 0x5f, 0x64, 0x61, 0x79, 0x73, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x6d, 0x61, 0x78, 0x41, 0x67, 0x65, 0x44, 0x61, 0x79,
+	0x73, 0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x61, 0x78, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x6d, 0x61, 0x78, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x24,
+	0x0a, 0x0e, 0x6d, 0x69, 0x6e, 0x5f, 0x66, 0x72, 0x65, 0x65, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x6d, 0x69, 0x6e, 0x46, 0x72, 0x65, 0x65, 0x42,
+	0x79, 0x74, 0x65, 0x73, 0x22, 0xfc, 0x02, 0x0a, 0x0a, 0x55, 0x73, 0x65, 0x72, 0x43, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x12, 0x3b, 0x0a, 0x0e, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67,
+	0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x14, 0x2e, 0x72, 0x6f,
+	0x76, 0x65, 0x72, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x54, 0x79, 0x70,
+	0x65, 0x52, 0x0d, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x54, 0x79, 0x70, 0x65,
+	0x12, 0x2d, 0x0a, 0x09, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x53, 0x63, 0x68, 0x65,
+	0x64, 0x75, 0x6c, 0x65, 0x52, 0x09, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x73, 0x12,
+	0x38, 0x0a, 0x0d, 0x6d, 0x6f, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x4d,
+	0x6f, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x0c, 0x6d, 0x6f, 0x74,
+	0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x25, 0x0a, 0x0e, 0x6d, 0x6f, 0x74,
+	0x69, 0x6f, 0x6e, 0x5f, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x0d, 0x6d, 0x6f, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64,
+	0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x12, 0x34, 0x0a, 0x09, 0x72, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e,
+	0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52,
+	0x09, 0x72, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x23, 0x0a, 0x0d, 0x72, 0x65,
+	0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x0c, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x4b, 0x65, 0x79, 0x12,
+	0x32, 0x0a, 0x0b, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x5f, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x72, 0x6f, 0x76, 0x65, 0x72, 0x2e, 0x42, 0x61, 0x63,
+	0x6b, 0x75, 0x70, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x0a, 0x62, 0x61, 0x63, 0x6b, 0x75, 0x70, 0x4d,
+	0x6f, 0x64, 0x65, 0x22, 0x3b, 0x0a, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x12, 0x18, 0x0a, 0x07, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x07, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x61,
+	0x6e, 0x6f, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6e, 0x61, 0x6e, 0x6f, 0x73,
+	0x2a, 0x8e, 0x02, 0x0a, 0x09, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x1a,
+	0x0a, 0x16, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x5f, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x55, 0x4e, 0x53,
+	0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x18, 0x0a, 0x14, 0x45, 0x52,
+	0x52, 0x4f, 0x52, 0x5f, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x46, 0x4f, 0x55,
+	0x4e, 0x44, 0x10, 0x01, 0x12, 0x1f, 0x0a, 0x1b, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x5f, 0x43, 0x4f,
+	0x44, 0x45, 0x5f, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x5f, 0x41, 0x52, 0x47, 0x55, 0x4d,
+	0x45, 0x4e, 0x54, 0x10, 0x02, 0x12, 0x1b, 0x0a, 0x17, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x5f, 0x43,
+	0x4f, 0x44, 0x45, 0x5f, 0x4f, 0x55, 0x54, 0x5f, 0x4f, 0x46, 0x5f, 0x52, 0x41, 0x4e, 0x47, 0x45,
+	0x10, 0x03, 0x12, 0x1a, 0x0a, 0x16, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x5f, 0x43, 0x4f, 0x44, 0x45,
+	0x5f, 0x55, 0x4e, 0x41, 0x56, 0x41, 0x49, 0x4c, 0x41, 0x42, 0x4c, 0x45, 0x10, 0x04, 0x12, 0x20,
+	0x0a, 0x1c, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x5f, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x44, 0x45, 0x41,
+	0x44, 0x4c, 0x49, 0x4e, 0x45, 0x5f, 0x45, 0x58, 0x43, 0x45, 0x45, 0x44, 0x45, 0x44, 0x10, 0x05,
+	0x12, 0x18, 0x0a, 0x14, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x5f, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x43,
+	0x41, 0x4e, 0x43, 0x45, 0x4c, 0x4c, 0x45, 0x44, 0x10, 0x06, 0x12, 0x1c, 0x0a, 0x18, 0x45, 0x52,
+	0x52, 0x4f, 0x52, 0x5f, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x55, 0x4e, 0x49, 0x4d, 0x50, 0x4c, 0x45,
+	0x4d, 0x45, 0x4e, 0x54, 0x45, 0x44, 0x10, 0x07, 0x12, 0x17, 0x0a, 0x13, 0x45, 0x52, 0x52, 0x4f,
+	0x52, 0x5f, 0x43, 0x4f, 0x44, 0x45, 0x5f, 0x49, 0x4e, 0x54, 0x45, 0x52, 0x4e, 0x41, 0x4c, 0x10,
+	0x08, 0x2a, 0xb4, 0x01, 0x0a, 0x10, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x54,
+	0x72, 0x69, 0x67, 0x67, 0x65, 0x72, 0x12, 0x21, 0x0a, 0x1d, 0x52, 0x45, 0x43, 0x4f, 0x52, 0x44,
+	0x49, 0x4e, 0x47, 0x5f, 0x54, 0x52, 0x49, 0x47, 0x47, 0x45, 0x52, 0x5f, 0x55, 0x4e, 0x53, 0x50,
+	0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x20, 0x0a, 0x1c, 0x52, 0x45, 0x43,
+	0x4f, 0x52, 0x44, 0x49, 0x4e, 0x47, 0x5f, 0x54, 0x52, 0x49, 0x47, 0x47, 0x45, 0x52, 0x5f, 0x43,
+	0x4f, 0x4e, 0x54, 0x49, 0x4e, 0x55, 0x4f, 0x55, 0x53, 0x10, 0x01, 0x12, 0x1f, 0x0a, 0x1b, 0x52,
+	0x45, 0x43, 0x4f, 0x52, 0x44, 0x49, 0x4e, 0x47, 0x5f, 0x54, 0x52, 0x49, 0x47, 0x47, 0x45, 0x52,
+	0x5f, 0x53, 0x43, 0x48, 0x45, 0x44, 0x55, 0x4c, 0x45, 0x44, 0x10, 0x02, 0x12, 0x1c, 0x0a, 0x18,
+	0x52, 0x45, 0x43, 0x4f, 0x52, 0x44, 0x49, 0x4e, 0x47, 0x5f, 0x54, 0x52, 0x49, 0x47, 0x47, 0x45,
+	0x52, 0x5f, 0x4d, 0x4f, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x03, 0x12, 0x1c, 0x0a, 0x18, 0x52, 0x45,
+	0x43, 0x4f, 0x52, 0x44, 0x49, 0x4e, 0x47, 0x5f, 0x54, 0x52, 0x49, 0x47, 0x47, 0x45, 0x52, 0x5f,
+	0x4d, 0x41, 0x4e, 0x55, 0x41, 0x4c, 0x10, 0x04, 0x2a, 0xe3, 0x01, 0x0a, 0x0b, 0x43, 0x6f, 0x6d,
+	0x6d, 0x61, 0x6e, 0x64, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1c, 0x0a, 0x18, 0x43, 0x4f, 0x4d, 0x4d,
+	0x41, 0x4e, 0x44, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49,
+	0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x17, 0x0a, 0x13, 0x43, 0x4f, 0x4d, 0x4d, 0x41, 0x4e,
+	0x44, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x52, 0x45, 0x42, 0x4f, 0x4f, 0x54, 0x10, 0x01, 0x12,
+	0x1e, 0x0a, 0x1a, 0x43, 0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f,
+	0x52, 0x45, 0x53, 0x54, 0x41, 0x52, 0x54, 0x5f, 0x41, 0x47, 0x45, 0x4e, 0x54, 0x10, 0x02, 0x12,
+	0x1f, 0x0a, 0x1b, 0x43, 0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f,
+	0x52, 0x45, 0x53, 0x54, 0x41, 0x52, 0x54, 0x5f, 0x53, 0x54, 0x52, 0x45, 0x41, 0x4d, 0x10, 0x03,
+	0x12, 0x1e, 0x0a, 0x1a, 0x43, 0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x54, 0x59, 0x50, 0x45,
+	0x5f, 0x46, 0x41, 0x43, 0x54, 0x4f, 0x52, 0x59, 0x5f, 0x52, 0x45, 0x53, 0x45, 0x54, 0x10, 0x04,
+	0x12, 0x1e, 0x0a, 0x1a, 0x43, 0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x54, 0x59, 0x50, 0x45,
+	0x5f, 0x52, 0x45, 0x53, 0x59, 0x4e, 0x43, 0x5f, 0x43, 0x4f, 0x4e, 0x46, 0x49, 0x47, 0x10, 0x05,
+	0x12, 0x1c, 0x0a, 0x18, 0x43, 0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x54, 0x59, 0x50, 0x45,
+	0x5f, 0x44, 0x49, 0x41, 0x47, 0x4e, 0x4f, 0x53, 0x54, 0x49, 0x43, 0x53, 0x10, 0x06, 0x2a, 0x84,
+	0x01, 0x0a, 0x0d, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x12, 0x1e, 0x0a, 0x1a, 0x43, 0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x53, 0x54, 0x41, 0x54,
+	0x55, 0x53, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00,
+	0x12, 0x1a, 0x0a, 0x16, 0x43, 0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x53, 0x54, 0x41, 0x54,
+	0x55, 0x53, 0x5f, 0x50, 0x45, 0x4e, 0x44, 0x49, 0x4e, 0x47, 0x10, 0x01, 0x12, 0x1c, 0x0a, 0x18,
+	0x43, 0x4f, 0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x53,
+	0x55, 0x43, 0x43, 0x45, 0x45, 0x44, 0x45, 0x44, 0x10, 0x02, 0x12, 0x19, 0x0a, 0x15, 0x43, 0x4f,
+	0x4d, 0x4d, 0x41, 0x4e, 0x44, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x46, 0x41, 0x49,
+	0x4c, 0x45, 0x44, 0x10, 0x03, 0x2a, 0x77, 0x0a, 0x12, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69,
+	0x6e, 0x67, 0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x23, 0x0a, 0x1f, 0x52,
+	0x45, 0x43, 0x4f, 0x52, 0x44, 0x49, 0x4e, 0x47, 0x5f, 0x52, 0x45, 0x54, 0x45, 0x4e, 0x54, 0x49,
+	0x4f, 0x4e, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00,
+	0x12, 0x1c, 0x0a, 0x18, 0x52, 0x45, 0x43, 0x4f, 0x52, 0x44, 0x49, 0x4e, 0x47, 0x5f, 0x52, 0x45,
+	0x54, 0x45, 0x4e, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x4b, 0x45, 0x45, 0x50, 0x10, 0x01, 0x12, 0x1e,
+	0x0a, 0x1a, 0x52, 0x45, 0x43, 0x4f, 0x52, 0x44, 0x49, 0x4e, 0x47, 0x5f, 0x52, 0x45, 0x54, 0x45,
+	0x4e, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x44, 0x45, 0x4c, 0x45, 0x54, 0x45, 0x10, 0x02, 0x2a, 0xdb,
+	0x01, 0x0a, 0x0c, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12,
+	0x1d, 0x0a, 0x19, 0x55, 0x50, 0x44, 0x41, 0x54, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53,
+	0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x19,
+	0x0a, 0x15, 0x55, 0x50, 0x44, 0x41, 0x54, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f,
+	0x50, 0x45, 0x4e, 0x44, 0x49, 0x4e, 0x47, 0x10, 0x01, 0x12, 0x1d, 0x0a, 0x19, 0x55, 0x50, 0x44,
+	0x41, 0x54, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x44, 0x4f, 0x57, 0x4e, 0x4c,
+	0x4f, 0x41, 0x44, 0x49, 0x4e, 0x47, 0x10, 0x02, 0x12, 0x1c, 0x0a, 0x18, 0x55, 0x50, 0x44, 0x41,
+	0x54, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x49, 0x4e, 0x53, 0x54, 0x41, 0x4c,
+	0x4c, 0x49, 0x4e, 0x47, 0x10, 0x03, 0x12, 0x1b, 0x0a, 0x17, 0x55, 0x50, 0x44, 0x41, 0x54, 0x45,
+	0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x53, 0x55, 0x43, 0x43, 0x45, 0x45, 0x44, 0x45,
+	0x44, 0x10, 0x04, 0x12, 0x18, 0x0a, 0x14, 0x55, 0x50, 0x44, 0x41, 0x54, 0x45, 0x5f, 0x53, 0x54,
+	0x41, 0x54, 0x55, 0x53, 0x5f, 0x46, 0x41, 0x49, 0x4c, 0x45, 0x44, 0x10, 0x05, 0x12, 0x1d, 0x0a,
+	0x19, 0x55, 0x50, 0x44, 0x41, 0x54, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x52,
+	0x4f, 0x4c, 0x4c, 0x45, 0x44, 0x5f, 0x42, 0x41, 0x43, 0x4b, 0x10, 0x06, 0x2a, 0xaa, 0x01, 0x0a,
+	0x0d, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x67, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1e,
+	0x0a, 0x1a, 0x52, 0x45, 0x43, 0x4f, 0x52, 0x44, 0x49, 0x4e, 0x47, 0x5f, 0x54, 0x59, 0x50, 0x45,
+	0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x16,
+	0x0a, 0x12, 0x52, 0x45, 0x43, 0x4f, 0x52, 0x44, 0x49, 0x4e, 0x47, 0x5f, 0x54, 0x59, 0x50, 0x45,
+	0x5f, 0x4f, 0x46, 0x46, 0x10, 0x01, 0x12, 0x1d, 0x0a, 0x19, 0x52, 0x45, 0x43, 0x4f, 0x52, 0x44,
+	0x49, 0x4e, 0x47, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x43, 0x4f, 0x4e, 0x54, 0x49, 0x4e, 0x55,
+	0x4f, 0x55, 0x53, 0x10, 0x02, 0x12, 0x27, 0x0a, 0x23, 0x52, 0x45, 0x43, 0x4f, 0x52, 0x44, 0x49,
 	0x4e, 0x47, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x43, 0x4f, 0x4e, 0x54, 0x49, 0x4e, 0x55, 0x4f,
-	0x55, 0x53, 0x10, 0x02, 0x12, 0x27, 0x0a, 0x23, 0x52, 0x45, 0x43, 0x4f, 0x52, 0x44, 0x49, 0x4e,
-	0x47, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x43, 0x4f, 0x4e, 0x54, 0x49, 0x4e, 0x55, 0x4f, 0x55,
-	0x53, 0x5f, 0x53, 0x43, 0x48, 0x45, 0x44, 0x55, 0x4c, 0x45, 0x44, 0x10, 0x03, 0x12, 0x19, 0x0a,
-	0x15, 0x52, 0x45, 0x43, 0x4f, 0x52, 0x44, 0x49, 0x4e, 0x47, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f,
-	0x4d, 0x4f, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x04, 0x42, 0x11, 0x5a, 0x0f, 0x6d, 0x65, 0x73, 0x73,
-	0x61, 0x67, 0x65, 0x73, 0x2f, 0x6d, 0x73, 0x67, 0x73, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x33,
+	0x55, 0x53, 0x5f, 0x53, 0x43, 0x48, 0x45, 0x44, 0x55, 0x4c, 0x45, 0x44, 0x10, 0x03, 0x12, 0x19,
+	0x0a, 0x15, 0x52, 0x45, 0x43, 0x4f, 0x52, 0x44, 0x49, 0x4e, 0x47, 0x5f, 0x54, 0x59, 0x50, 0x45,
+	0x5f, 0x4d, 0x4f, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x04, 0x2a, 0x6b, 0x0a, 0x0a, 0x42, 0x61, 0x63,
+	0x6b, 0x75, 0x70, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x1b, 0x0a, 0x17, 0x42, 0x41, 0x43, 0x4b, 0x55,
+	0x50, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49,
+	0x45, 0x44, 0x10, 0x00, 0x12, 0x13, 0x0a, 0x0f, 0x42, 0x41, 0x43, 0x4b, 0x55, 0x50, 0x5f, 0x4d,
+	0x4f, 0x44, 0x45, 0x5f, 0x4f, 0x46, 0x46, 0x10, 0x01, 0x12, 0x13, 0x0a, 0x0f, 0x42, 0x41, 0x43,
+	0x4b, 0x55, 0x50, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x41, 0x4c, 0x4c, 0x10, 0x02, 0x12, 0x16,
+	0x0a, 0x12, 0x42, 0x41, 0x43, 0x4b, 0x55, 0x50, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x45, 0x56,
+	0x45, 0x4e, 0x54, 0x53, 0x10, 0x03, 0x42, 0x11, 0x5a, 0x0f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x73, 0x2f, 0x6d, 0x73, 0x67, 0x73, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
 })
 
 var (
@@ -1145,44 +3802,104 @@ func file_msgs_proto_rawDescGZIP() []byte {
 	return file_msgs_proto_rawDescData
 }
 
-var file_msgs_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_msgs_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_msgs_proto_enumTypes = make([]protoimpl.EnumInfo, 8)
+var file_msgs_proto_msgTypes = make([]protoimpl.MessageInfo, 36)
 var file_msgs_proto_goTypes = []any{
-	(RecordingType)(0),     // 0: rover.RecordingType
-	(*Message)(nil),        // 1: rover.Message
-	(*HLSRequest)(nil),     // 2: rover.HLSRequest
-	(*HLSResponse)(nil),    // 3: rover.HLSResponse
-	(*RecordRequest)(nil),  // 4: rover.RecordRequest
-	(*VideoRange)(nil),     // 5: rover.VideoRange
-	(*RecordResponse)(nil), // 6: rover.RecordResponse
-	(*TriggerRefresh)(nil), // 7: rover.TriggerRefresh
-	(*Webrtc)(nil),         // 8: rover.Webrtc
-	(*Initalization)(nil),  // 9: rover.Initalization
-	(*Response)(nil),       // 10: rover.Response
-	(*Schedule)(nil),       // 11: rover.Schedule
-	(*MotionConfig)(nil),   // 12: rover.MotionConfig
-	(*UserConfig)(nil),     // 13: rover.UserConfig
-	(*Timestamp)(nil),      // 14: rover.Timestamp
+	(ErrorCode)(0),             // 0: rover.ErrorCode
+	(RecordingTrigger)(0),      // 1: rover.RecordingTrigger
+	(CommandType)(0),           // 2: rover.CommandType
+	(CommandStatus)(0),         // 3: rover.CommandStatus
+	(RecordingRetention)(0),    // 4: rover.RecordingRetention
+	(UpdateStatus)(0),          // 5: rover.UpdateStatus
+	(RecordingType)(0),         // 6: rover.RecordingType
+	(BackupMode)(0),            // 7: rover.BackupMode
+	(*Message)(nil),            // 8: rover.Message
+	(*RpcError)(nil),           // 9: rover.RpcError
+	(*HLSRequest)(nil),         // 10: rover.HLSRequest
+	(*HLSResponse)(nil),        // 11: rover.HLSResponse
+	(*HLSAck)(nil),             // 12: rover.HLSAck
+	(*RecordRequest)(nil),      // 13: rover.RecordRequest
+	(*VideoRange)(nil),         // 14: rover.VideoRange
+	(*RecordResponse)(nil),     // 15: rover.RecordResponse
+	(*SegmentRequest)(nil),     // 16: rover.SegmentRequest
+	(*SegmentInfo)(nil),        // 17: rover.SegmentInfo
+	(*SegmentResponse)(nil),    // 18: rover.SegmentResponse
+	(*LockRangeRequest)(nil),   // 19: rover.LockRangeRequest
+	(*DeleteRangeRequest)(nil), // 20: rover.DeleteRangeRequest
+	(*RangeResult)(nil),        // 21: rover.RangeResult
+	(*Bookmark)(nil),           // 22: rover.Bookmark
+	(*BookmarkUpdate)(nil),     // 23: rover.BookmarkUpdate
+	(*BookmarkQuery)(nil),      // 24: rover.BookmarkQuery
+	(*BookmarkList)(nil),       // 25: rover.BookmarkList
+	(*TriggerRefresh)(nil),     // 26: rover.TriggerRefresh
+	(*CameraCommand)(nil),      // 27: rover.CameraCommand
+	(*CommandResult)(nil),      // 28: rover.CommandResult
+	(*Webrtc)(nil),             // 29: rover.Webrtc
+	(*Initalization)(nil),      // 30: rover.Initalization
+	(*Response)(nil),           // 31: rover.Response
+	(*Deregister)(nil),         // 32: rover.Deregister
+	(*AgentRelease)(nil),       // 33: rover.AgentRelease
+	(*AgentStatus)(nil),        // 34: rover.AgentStatus
+	(*Telemetry)(nil),          // 35: rover.Telemetry
+	(*LogRequest)(nil),         // 36: rover.LogRequest
+	(*LogRecord)(nil),          // 37: rover.LogRecord
+	(*LogBatch)(nil),           // 38: rover.LogBatch
+	(*Schedule)(nil),           // 39: rover.Schedule
+	(*MotionConfig)(nil),       // 40: rover.MotionConfig
+	(*RetentionPolicy)(nil),    // 41: rover.RetentionPolicy
+	(*UserConfig)(nil),         // 42: rover.UserConfig
+	(*Timestamp)(nil),          // 43: rover.Timestamp
 }
 var file_msgs_proto_depIdxs = []int32{
-	8,  // 0: rover.Message.webrtc:type_name -> rover.Webrtc
-	9,  // 1: rover.Message.initalization:type_name -> rover.Initalization
-	10, // 2: rover.Message.response:type_name -> rover.Response
-	2,  // 3: rover.Message.hls_request:type_name -> rover.HLSRequest
-	3,  // 4: rover.Message.hls_response:type_name -> rover.HLSResponse
-	4,  // 5: rover.Message.record_request:type_name -> rover.RecordRequest
-	6,  // 6: rover.Message.record_response:type_name -> rover.RecordResponse
-	13, // 7: rover.Message.user_config:type_name -> rover.UserConfig
-	7,  // 8: rover.Message.trigger_refresh:type_name -> rover.TriggerRefresh
-	5,  // 9: rover.RecordResponse.records:type_name -> rover.VideoRange
-	0,  // 10: rover.UserConfig.recording_type:type_name -> rover.RecordingType
-	11, // 11: rover.UserConfig.schedules:type_name -> rover.Schedule
-	12, // 12: rover.UserConfig.motion_config:type_name -> rover.MotionConfig
-	13, // [13:13] is the sub-list for method output_type
-	13, // [13:13] is the sub-list for method input_type
-	13, // [13:13] is the sub-list for extension type_name
-	13, // [13:13] is the sub-list for extension extendee
-	0,  // [0:13] is the sub-list for field type_name
+	29, // 0: rover.Message.webrtc:type_name -> rover.Webrtc
+	30, // 1: rover.Message.initalization:type_name -> rover.Initalization
+	31, // 2: rover.Message.response:type_name -> rover.Response
+	10, // 3: rover.Message.hls_request:type_name -> rover.HLSRequest
+	11, // 4: rover.Message.hls_response:type_name -> rover.HLSResponse
+	13, // 5: rover.Message.record_request:type_name -> rover.RecordRequest
+	15, // 6: rover.Message.record_response:type_name -> rover.RecordResponse
+	42, // 7: rover.Message.user_config:type_name -> rover.UserConfig
+	26, // 8: rover.Message.trigger_refresh:type_name -> rover.TriggerRefresh
+	27, // 9: rover.Message.camera_command:type_name -> rover.CameraCommand
+	28, // 10: rover.Message.command_result:type_name -> rover.CommandResult
+	32, // 11: rover.Message.deregister:type_name -> rover.Deregister
+	33, // 12: rover.Message.agent_release:type_name -> rover.AgentRelease
+	34, // 13: rover.Message.agent_status:type_name -> rover.AgentStatus
+	35, // 14: rover.Message.telemetry:type_name -> rover.Telemetry
+	36, // 15: rover.Message.log_request:type_name -> rover.LogRequest
+	38, // 16: rover.Message.log_batch:type_name -> rover.LogBatch
+	12, // 17: rover.Message.hls_ack:type_name -> rover.HLSAck
+	9,  // 18: rover.Message.rpc_error:type_name -> rover.RpcError
+	16, // 19: rover.Message.segment_request:type_name -> rover.SegmentRequest
+	18, // 20: rover.Message.segment_response:type_name -> rover.SegmentResponse
+	19, // 21: rover.Message.lock_range_request:type_name -> rover.LockRangeRequest
+	20, // 22: rover.Message.delete_range_request:type_name -> rover.DeleteRangeRequest
+	21, // 23: rover.Message.range_result:type_name -> rover.RangeResult
+	23, // 24: rover.Message.bookmark_update:type_name -> rover.BookmarkUpdate
+	24, // 25: rover.Message.bookmark_query:type_name -> rover.BookmarkQuery
+	25, // 26: rover.Message.bookmark_list:type_name -> rover.BookmarkList
+	0,  // 27: rover.RpcError.code:type_name -> rover.ErrorCode
+	1,  // 28: rover.VideoRange.trigger:type_name -> rover.RecordingTrigger
+	14, // 29: rover.RecordResponse.records:type_name -> rover.VideoRange
+	17, // 30: rover.SegmentResponse.segments:type_name -> rover.SegmentInfo
+	22, // 31: rover.BookmarkUpdate.bookmark:type_name -> rover.Bookmark
+	22, // 32: rover.BookmarkList.bookmarks:type_name -> rover.Bookmark
+	2,  // 33: rover.CameraCommand.type:type_name -> rover.CommandType
+	2,  // 34: rover.CommandResult.type:type_name -> rover.CommandType
+	3,  // 35: rover.CommandResult.status:type_name -> rover.CommandStatus
+	4,  // 36: rover.Deregister.recordings:type_name -> rover.RecordingRetention
+	5,  // 37: rover.AgentStatus.update_status:type_name -> rover.UpdateStatus
+	37, // 38: rover.LogBatch.records:type_name -> rover.LogRecord
+	6,  // 39: rover.UserConfig.recording_type:type_name -> rover.RecordingType
+	39, // 40: rover.UserConfig.schedules:type_name -> rover.Schedule
+	40, // 41: rover.UserConfig.motion_config:type_name -> rover.MotionConfig
+	41, // 42: rover.UserConfig.retention:type_name -> rover.RetentionPolicy
+	7,  // 43: rover.UserConfig.backup_mode:type_name -> rover.BackupMode
+	44, // [44:44] is the sub-list for method output_type
+	44, // [44:44] is the sub-list for method input_type
+	44, // [44:44] is the sub-list for extension type_name
+	44, // [44:44] is the sub-list for extension extendee
+	0,  // [0:44] is the sub-list for field type_name
 }
 
 func init() { file_msgs_proto_init() }
@@ -1200,14 +3917,32 @@ func file_msgs_proto_init() {
 		(*Message_RecordResponse)(nil),
 		(*Message_UserConfig)(nil),
 		(*Message_TriggerRefresh)(nil),
+		(*Message_CameraCommand)(nil),
+		(*Message_CommandResult)(nil),
+		(*Message_Deregister)(nil),
+		(*Message_AgentRelease)(nil),
+		(*Message_AgentStatus)(nil),
+		(*Message_Telemetry)(nil),
+		(*Message_LogRequest)(nil),
+		(*Message_LogBatch)(nil),
+		(*Message_HlsAck)(nil),
+		(*Message_RpcError)(nil),
+		(*Message_SegmentRequest)(nil),
+		(*Message_SegmentResponse)(nil),
+		(*Message_LockRangeRequest)(nil),
+		(*Message_DeleteRangeRequest)(nil),
+		(*Message_RangeResult)(nil),
+		(*Message_BookmarkUpdate)(nil),
+		(*Message_BookmarkQuery)(nil),
+		(*Message_BookmarkList)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_msgs_proto_rawDesc), len(file_msgs_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   14,
+			NumEnums:      8,
+			NumMessages:   36,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,202 @@
+// Package recordcrypt encrypts recordings at rest. Every recording session has
+// a random data key that seals its segments with AES-256-GCM. The data key is
+// only stored wrapped to the owner's X25519 recording key, so a camera holds
+// nothing that decrypts footage it already wrote.
+package recordcrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+const (
+	// KeyFile holds the wrapped data key in every encrypted session directory
+	KeyFile = "key.json"
+	// KeySize is the size of data keys and recording keys
+	KeySize = 32
+)
+
+// magic prefixes every sealed segment so readers can tell it from plain video
+var magic = []byte("SCREC\x00\x01\x00")
+
+var wrapInfo = []byte("sudocam recording key wrap v1")
+
+var (
+	ErrNotSealed  = errors.New("data is not a sealed segment")
+	ErrWrongKey   = errors.New("recording key does not match")
+	ErrCorrupt    = errors.New("sealed data failed authentication")
+	ErrInvalidKey = errors.New("invalid recording key")
+)
+
+// WrappedKey is a data key encrypted to a recording public key
+type WrappedKey struct {
+	KeyID      string `json:"keyId"`     // Fingerprint of the recording key it is wrapped to
+	Ephemeral  []byte `json:"ephemeral"` // Ephemeral X25519 public key
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// GenerateRecordingKey returns a new X25519 recording key pair
+func GenerateRecordingKey() (private []byte, public []byte, err error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key.Bytes(), key.PublicKey().Bytes(), nil
+}
+
+// PublicKey derives the public half of a recording key
+func PublicKey(private []byte) ([]byte, error) {
+	key, err := ecdh.X25519().NewPrivateKey(private)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	return key.PublicKey().Bytes(), nil
+}
+
+// KeyID returns a short fingerprint of a recording public key
+func KeyID(public []byte) string {
+	sum := sha256.Sum256(public)
+	return hex.EncodeToString(sum[:8])
+}
+
+// NewDataKey returns a random data key for one session
+func NewDataKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// WrapKey encrypts a data key to a recording public key
+func WrapKey(public []byte, dataKey []byte) (*WrappedKey, error) {
+	recipient, err := ecdh.X25519().NewPublicKey(public)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := wrapAEAD(shared, ephemeral.PublicKey().Bytes(), public)
+	if err != nil {
+		return nil, err
+	}
+	// Every wrapping key is used once, a zero nonce is safe
+	nonce := make([]byte, aead.NonceSize())
+
+	return &WrappedKey{
+		KeyID:      KeyID(public),
+		Ephemeral:  ephemeral.PublicKey().Bytes(),
+		Ciphertext: aead.Seal(nil, nonce, dataKey, nil),
+	}, nil
+}
+
+// UnwrapKey decrypts a data key with the recording private key
+func UnwrapKey(private []byte, wrapped *WrappedKey) ([]byte, error) {
+	key, err := ecdh.X25519().NewPrivateKey(private)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	public := key.PublicKey().Bytes()
+	if wrapped.KeyID != KeyID(public) {
+		return nil, ErrWrongKey
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(wrapped.Ephemeral)
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	shared, err := key.ECDH(ephemeral)
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+
+	aead, err := wrapAEAD(shared, wrapped.Ephemeral, public)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := aead.Open(nil, make([]byte, aead.NonceSize()), wrapped.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	return dataKey, nil
+}
+
+// wrapAEAD derives the key wrapping cipher from an X25519 shared secret
+func wrapAEAD(shared, ephemeral, public []byte) (cipher.AEAD, error) {
+	salt := append(append([]byte{}, ephemeral...), public...)
+	key, err := hkdf.Key(sha256.New, shared, salt, string(wrapInfo), KeySize)
+	if err != nil {
+		return nil, err
+	}
+	return newAEAD(key)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seal encrypts a segment. name is its path inside the recordings, e.g.
+// "<session>/segment_00001.ts", and is authenticated so sealed files can't be
+// swapped around.
+func Seal(dataKey []byte, name string, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(magic)+len(nonce)+len(plaintext)+aead.Overhead())
+	out = append(out, magic...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plaintext, []byte(name)), nil
+}
+
+// Open decrypts a segment sealed under the same name
+func Open(dataKey []byte, name string, sealed []byte) ([]byte, error) {
+	if !IsSealed(sealed) {
+		return nil, ErrNotSealed
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	sealed = sealed[len(magic):]
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrCorrupt
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCorrupt, name)
+	}
+	return plaintext, nil
+}
+
+// IsSealed reports whether data starts like a sealed segment
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
@@ -0,0 +1,178 @@
+package recordcrypt
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	dataKey, err := NewDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	const name = "20240102_030405/segment_00001.ts"
+	plaintext := bytes.Repeat([]byte{0x47, 0x40, 0x00, 0x10}, 47)
+
+	sealed, err := Seal(dataKey, name, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealed(sealed) || IsSealed(plaintext) {
+		t.Fatal("IsSealed does not tell sealed from plain data")
+	}
+	if bytes.Contains(sealed, plaintext[:16]) {
+		t.Fatal("sealed data contains the plaintext")
+	}
+	opened, err := Open(dataKey, name, sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Fatal("opened data differs from the plaintext")
+	}
+
+	// Every seal has its own nonce
+	again, _ := Seal(dataKey, name, plaintext)
+	if bytes.Equal(again, sealed) {
+		t.Fatal("sealing twice gave the same ciphertext")
+	}
+
+	// Empty segments round trip too
+	sealed, _ = Seal(dataKey, name, nil)
+	if opened, err := Open(dataKey, name, sealed); err != nil || len(opened) != 0 {
+		t.Fatalf("empty segment: %q, %v", opened, err)
+	}
+}
+
+func TestOpenTampered(t *testing.T) {
+	dataKey, _ := NewDataKey()
+	const name = "20240102_030405/segment_00001.ts"
+	sealed, err := Seal(dataKey, name, []byte("segment data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every byte after the magic is authenticated: nonce, ciphertext and tag
+	for i := len(magic); i < len(sealed); i++ {
+		tampered := bytes.Clone(sealed)
+		tampered[i] ^= 0x01
+		if _, err := Open(dataKey, name, tampered); !errors.Is(err, ErrCorrupt) {
+			t.Fatalf("byte %d flipped: %v, want ErrCorrupt", i, err)
+		}
+	}
+
+	if _, err := Open(dataKey, name, sealed[:len(sealed)-1]); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("truncated: %v, want ErrCorrupt", err)
+	}
+	if _, err := Open(dataKey, name, sealed[:len(magic)+4]); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("shorter than a nonce: %v, want ErrCorrupt", err)
+	}
+	if _, err := Open(dataKey, name, []byte("plain video")); !errors.Is(err, ErrNotSealed) {
+		t.Fatalf("plain data: %v, want ErrNotSealed", err)
+	}
+
+	otherKey, _ := NewDataKey()
+	if _, err := Open(otherKey, name, sealed); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("other data key: %v, want ErrCorrupt", err)
+	}
+	if _, err := Open(dataKey[:16], name, sealed); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("short data key: %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestOpenOtherName(t *testing.T) {
+	dataKey, _ := NewDataKey()
+	sealed, err := Seal(dataKey, "20240102_030405/segment_00001.ts", []byte("segment data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A sealed segment moved to another name or session doesn't open
+	for _, name := range []string{
+		"20240102_030405/segment_00002.ts",
+		"20240103_000000/segment_00001.ts",
+		"segment_00001.ts",
+		"",
+	} {
+		if _, err := Open(dataKey, name, sealed); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("opened as %q: %v, want ErrCorrupt", name, err)
+		}
+	}
+}
+
+func TestWrapKey(t *testing.T) {
+	private, public, err := GenerateRecordingKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if derived, err := PublicKey(private); err != nil || !bytes.Equal(derived, public) {
+		t.Fatalf("PublicKey = %x, %v, want %x", derived, err, public)
+	}
+	dataKey, _ := NewDataKey()
+
+	wrapped, err := WrapKey(public, dataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wrapped.KeyID != KeyID(public) {
+		t.Fatalf("key id %s, want %s", wrapped.KeyID, KeyID(public))
+	}
+	if bytes.Contains(wrapped.Ciphertext, dataKey) {
+		t.Fatal("wrapped key contains the data key")
+	}
+	unwrapped, err := UnwrapKey(private, wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(unwrapped, dataKey) {
+		t.Fatal("unwrapped key differs from the data key")
+	}
+
+	// Every wrap uses a new ephemeral key
+	again, _ := WrapKey(public, dataKey)
+	if bytes.Equal(again.Ephemeral, wrapped.Ephemeral) || bytes.Equal(again.Ciphertext, wrapped.Ciphertext) {
+		t.Fatal("wrapping twice gave the same result")
+	}
+
+	if _, err := WrapKey(public[:16], dataKey); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("short public key: %v, want ErrInvalidKey", err)
+	}
+	if _, err := UnwrapKey(private[:16], wrapped); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("short private key: %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestUnwrapKeyTampered(t *testing.T) {
+	private, public, _ := GenerateRecordingKey()
+	dataKey, _ := NewDataKey()
+	wrapped, err := WrapKey(public, dataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherPrivate, _, _ := GenerateRecordingKey()
+	if _, err := UnwrapKey(otherPrivate, wrapped); !errors.Is(err, ErrWrongKey) {
+		t.Fatalf("other recording key: %v, want ErrWrongKey", err)
+	}
+
+	for i := range wrapped.Ciphertext {
+		tampered := *wrapped
+		tampered.Ciphertext = bytes.Clone(wrapped.Ciphertext)
+		tampered.Ciphertext[i] ^= 0x01
+		if _, err := UnwrapKey(private, &tampered); !errors.Is(err, ErrCorrupt) {
+			t.Fatalf("ciphertext byte %d flipped: %v, want ErrCorrupt", i, err)
+		}
+	}
+
+	// The ephemeral key is bound into the wrapping key
+	tampered := *wrapped
+	_, tampered.Ephemeral, _ = GenerateRecordingKey()
+	if _, err := UnwrapKey(private, &tampered); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("other ephemeral key: %v, want ErrCorrupt", err)
+	}
+	tampered.Ephemeral = wrapped.Ephemeral[:8]
+	if _, err := UnwrapKey(private, &tampered); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("short ephemeral key: %v, want ErrCorrupt", err)
+	}
+}
@@ -0,0 +1,211 @@
+// Package rpc correlates requests sent over the websocket with their replies.
+// A request carries a request_id in the pb.Message envelope and every reply to
+// it echoes the id, so any number of requests can be in flight at once. A
+// request that fails is answered with a pb.RpcError.
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	pb "messages/msgspb"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds a Call whose context has no deadline
+const DefaultTimeout = 10 * time.Second
+
+// Error is a failed request, as sent in a pb.RpcError
+type Error struct {
+	Code    pb.ErrorCode
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is matches errors by code, so errors.Is(err, rpc.ErrNotFound) holds for
+// any not found error
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+var (
+	ErrNotFound        = &Error{Code: pb.ErrorCode_ERROR_CODE_NOT_FOUND, Message: "not found"}
+	ErrInvalidArgument = &Error{Code: pb.ErrorCode_ERROR_CODE_INVALID_ARGUMENT, Message: "invalid argument"}
+	ErrOutOfRange      = &Error{Code: pb.ErrorCode_ERROR_CODE_OUT_OF_RANGE, Message: "out of range"}
+	ErrUnavailable     = &Error{Code: pb.ErrorCode_ERROR_CODE_UNAVAILABLE, Message: "unavailable"}
+	ErrTimeout         = &Error{Code: pb.ErrorCode_ERROR_CODE_DEADLINE_EXCEEDED, Message: "timeout waiting for reply"}
+	ErrCancelled       = &Error{Code: pb.ErrorCode_ERROR_CODE_CANCELLED, Message: "request cancelled"}
+	ErrUnimplemented   = &Error{Code: pb.ErrorCode_ERROR_CODE_UNIMPLEMENTED, Message: "unimplemented"}
+	ErrInternal        = &Error{Code: pb.ErrorCode_ERROR_CODE_INTERNAL, Message: "internal error"}
+)
+
+// Errorf returns an Error with the code and a formatted message
+func Errorf(code pb.ErrorCode, format string, args ...any) error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// CodeOf returns the code a handler error is reported with, internal unless
+// it wraps an Error or a context error
+func CodeOf(err error) pb.ErrorCode {
+	var rpcErr *Error
+	switch {
+	case errors.As(err, &rpcErr):
+		return rpcErr.Code
+	case errors.Is(err, context.DeadlineExceeded):
+		return pb.ErrorCode_ERROR_CODE_DEADLINE_EXCEEDED
+	case errors.Is(err, context.Canceled):
+		return pb.ErrorCode_ERROR_CODE_CANCELLED
+	default:
+		return pb.ErrorCode_ERROR_CODE_INTERNAL
+	}
+}
+
+// Reply returns the envelope of a reply to req, the caller sets its data
+func Reply(req *pb.Message, from string) *pb.Message {
+	return &pb.Message{
+		From:      from,
+		To:        req.From,
+		RequestId: req.RequestId,
+	}
+}
+
+// ErrorReply returns the reply reporting that req failed with err
+func ErrorReply(req *pb.Message, from string, err error) *pb.Message {
+	reply := Reply(req, from)
+	reply.DataType = &pb.Message_RpcError{
+		RpcError: &pb.RpcError{Code: CodeOf(err), Message: err.Error()},
+	}
+	return reply
+}
+
+// NewRequestID returns a random request id
+func NewRequestID() string {
+	return rand.Text()
+}
+
+// Sender sends a message to its recipient
+type Sender func(msg *pb.Message) error
+
+// Table holds the requests waiting for replies
+type Table struct {
+	send  Sender
+	mutex sync.Mutex
+	calls map[string]*Call
+}
+
+// NewTable creates a table sending requests with send
+func NewTable(send Sender) *Table {
+	return &Table{
+		send:  send,
+		calls: make(map[string]*Call),
+	}
+}
+
+// Call is a request waiting for its replies
+type Call struct {
+	ID      string
+	peer    string
+	table   *Table
+	replies chan *pb.Message
+	once    sync.Once
+}
+
+// Start assigns msg a request id, registers it and sends it. Replies are
+// read with Recv, up to buffer of them are held until then and the rest are
+// dropped. The call must be closed when no more replies are expected.
+func (t *Table) Start(msg *pb.Message, buffer int) (*Call, error) {
+	call := &Call{
+		ID:      NewRequestID(),
+		peer:    msg.To,
+		table:   t,
+		replies: make(chan *pb.Message, max(buffer, 1)),
+	}
+	msg.RequestId = call.ID
+
+	t.mutex.Lock()
+	t.calls[call.ID] = call
+	t.mutex.Unlock()
+
+	if err := t.send(msg); err != nil {
+		call.Close()
+		return nil, err
+	}
+	return call, nil
+}
+
+// Call sends msg and waits for its single reply. Without a deadline on ctx
+// it gives up after DefaultTimeout.
+func (t *Table) Call(ctx context.Context, msg *pb.Message) (*pb.Message, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+
+	call, err := t.Start(msg, 1)
+	if err != nil {
+		return nil, err
+	}
+	defer call.Close()
+	return call.Recv(ctx)
+}
+
+// Deliver hands a reply to the call waiting for it and reports whether there
+// was one. Messages that aren't replies to a pending call are left to the caller.
+func (t *Table) Deliver(msg *pb.Message) bool {
+	if msg.RequestId == "" {
+		return false
+	}
+
+	t.mutex.Lock()
+	call, exists := t.calls[msg.RequestId]
+	t.mutex.Unlock()
+
+	if !exists {
+		return false
+	}
+	if call.peer != "" && msg.From != call.peer {
+		slog.Warn("Reply from unexpected peer", "request_id", msg.RequestId, "from", msg.From, "expected", call.peer)
+		return true
+	}
+
+	select {
+	case call.replies <- msg:
+	default:
+		slog.Warn("Reply buffer full, dropping reply", "request_id", msg.RequestId, "from", msg.From)
+	}
+	return true
+}
+
+// Recv waits for the next reply until ctx is done. An RpcError reply is
+// returned as an *Error.
+func (c *Call) Recv(ctx context.Context) (*pb.Message, error) {
+	select {
+	case reply := <-c.replies:
+		if failure := reply.GetRpcError(); failure != nil {
+			return nil, &Error{Code: failure.Code, Message: failure.Message}
+		}
+		return reply, nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, ErrTimeout
+		}
+		return nil, ErrCancelled
+	}
+}
+
+// Close unregisters the call, later replies are no longer delivered
+func (c *Call) Close() {
+	c.once.Do(func() {
+		c.table.mutex.Lock()
+		delete(c.table.calls, c.ID)
+		c.table.mutex.Unlock()
+	})
+}
@@ -0,0 +1,215 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	pb "messages/msgspb"
+	"testing"
+	"time"
+)
+
+// newTestTable returns a table whose requests are queued on the returned
+// channel instead of being sent
+func newTestTable() (*Table, chan *pb.Message) {
+	sent := make(chan *pb.Message, 16)
+	return NewTable(func(msg *pb.Message) error {
+		sent <- msg
+		return nil
+	}), sent
+}
+
+func request(to string, n int32) *pb.Message {
+	return &pb.Message{
+		From:     "server",
+		To:       to,
+		DataType: &pb.Message_RangeResult{RangeResult: &pb.RangeResult{Skipped: n}},
+	}
+}
+
+func pending(t *Table) int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return len(t.calls)
+}
+
+func TestCallCorrelatesReplies(t *testing.T) {
+	table, sent := newTestTable()
+
+	// The peer answers both requests in reverse order
+	go func() {
+		first, second := <-sent, <-sent
+		for _, req := range []*pb.Message{second, first} {
+			reply := Reply(req, "camera")
+			reply.DataType = &pb.Message_RangeResult{RangeResult: &pb.RangeResult{Skipped: req.GetRangeResult().Skipped * 10}}
+			if !table.Deliver(reply) {
+				t.Errorf("reply to %s not delivered", req.RequestId)
+			}
+		}
+	}()
+
+	results := make(chan error, 2)
+	for _, n := range []int32{1, 2} {
+		go func() {
+			reply, err := table.Call(context.Background(), request("camera", n))
+			if err == nil && reply.GetRangeResult().Skipped != n*10 {
+				err = fmt.Errorf("request %d answered with %d", n, reply.GetRangeResult().Skipped)
+			}
+			results <- err
+		}()
+	}
+	for range 2 {
+		if err := <-results; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := pending(table); n != 0 {
+		t.Fatalf("%d calls left pending", n)
+	}
+}
+
+func TestCallErrorReply(t *testing.T) {
+	table, sent := newTestTable()
+	go func() {
+		req := <-sent
+		table.Deliver(ErrorReply(req, "camera", Errorf(pb.ErrorCode_ERROR_CODE_NOT_FOUND, "no recording %s", "s1")))
+	}()
+
+	_, err := table.Call(context.Background(), request("camera", 1))
+	if !errors.Is(err, ErrNotFound) || err.Error() != "no recording s1" {
+		t.Fatalf("err = %v, want the camera's not found error", err)
+	}
+	if errors.Is(err, ErrInternal) {
+		t.Fatal("not found error matches internal")
+	}
+}
+
+func TestDeliverChecksPeer(t *testing.T) {
+	table, sent := newTestTable()
+	call, err := table.Start(request("camera-a", 1), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer call.Close()
+	req := <-sent
+	if req.RequestId != call.ID || call.ID == "" {
+		t.Fatalf("request id %q, call id %q", req.RequestId, call.ID)
+	}
+
+	// Another peer guessing the id is consumed but not handed to the call
+	forged := Reply(req, "camera-b")
+	if !table.Deliver(forged) {
+		t.Fatal("reply from another peer left to the caller")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if reply, err := call.Recv(ctx); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Recv = %v, %v, want a timeout", reply, err)
+	}
+
+	if !table.Deliver(Reply(req, "camera-a")) {
+		t.Fatal("reply from the peer not delivered")
+	}
+	if _, err := call.Recv(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Messages that aren't replies to a pending call
+	if table.Deliver(&pb.Message{From: "camera-a"}) {
+		t.Fatal("message without request id consumed")
+	}
+	if table.Deliver(&pb.Message{From: "camera-a", RequestId: "unknown"}) {
+		t.Fatal("reply to an unknown request consumed")
+	}
+	call.Close()
+	if table.Deliver(Reply(req, "camera-a")) {
+		t.Fatal("reply to a closed call consumed")
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	table, _ := newTestTable()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := table.Call(ctx, request("camera", 1))
+	if !errors.Is(err, ErrTimeout) || CodeOf(err) != pb.ErrorCode_ERROR_CODE_DEADLINE_EXCEEDED {
+		t.Fatalf("err = %v, want ErrTimeout", err)
+	}
+	if n := pending(table); n != 0 {
+		t.Fatalf("%d calls left pending", n)
+	}
+}
+
+func TestCallCancel(t *testing.T) {
+	table, sent := newTestTable()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-sent
+		cancel()
+	}()
+
+	_, err := table.Call(ctx, request("camera", 1))
+	if !errors.Is(err, ErrCancelled) {
+		t.Fatalf("err = %v, want ErrCancelled", err)
+	}
+	if n := pending(table); n != 0 {
+		t.Fatalf("%d calls left pending", n)
+	}
+}
+
+func TestCallSendFails(t *testing.T) {
+	table := NewTable(func(msg *pb.Message) error {
+		return fmt.Errorf("%w: %s is not connected", ErrUnavailable, msg.To)
+	})
+	if _, err := table.Call(context.Background(), request("camera", 1)); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("err = %v, want ErrUnavailable", err)
+	}
+	if n := pending(table); n != 0 {
+		t.Fatalf("%d calls left pending", n)
+	}
+}
+
+func TestStartDropsOverflow(t *testing.T) {
+	table, sent := newTestTable()
+	call, err := table.Start(request("camera", 1), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer call.Close()
+	req := <-sent
+
+	for n := range int32(3) {
+		reply := Reply(req, "camera")
+		reply.DataType = &pb.Message_RangeResult{RangeResult: &pb.RangeResult{Skipped: n}}
+		table.Deliver(reply)
+	}
+	for n := range int32(2) {
+		reply, err := call.Recv(context.Background())
+		if err != nil || reply.GetRangeResult().Skipped != n {
+			t.Fatalf("reply %d = %v, %v", n, reply, err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := call.Recv(ctx); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("reply past the buffer not dropped: %v", err)
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		code pb.ErrorCode
+	}{
+		{fmt.Errorf("reading: %w", ErrNotFound), pb.ErrorCode_ERROR_CODE_NOT_FOUND},
+		{Errorf(pb.ErrorCode_ERROR_CODE_OUT_OF_RANGE, "past the end"), pb.ErrorCode_ERROR_CODE_OUT_OF_RANGE},
+		{fmt.Errorf("reading: %w", context.DeadlineExceeded), pb.ErrorCode_ERROR_CODE_DEADLINE_EXCEEDED},
+		{context.Canceled, pb.ErrorCode_ERROR_CODE_CANCELLED},
+		{errors.New("disk full"), pb.ErrorCode_ERROR_CODE_INTERNAL},
+	} {
+		if code := CodeOf(test.err); code != test.code {
+			t.Errorf("CodeOf(%v) = %v, want %v", test.err, code, test.code)
+		}
+	}
+}
@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	pb "messages/msgspb"
+	"messages/recordcrypt"
+	"messages/rpc"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"messages/jwtmsg"
+	"server/middleware"
+	"server/models"
+	"server/storage"
+)
+
+const (
+	// maxBackupFile is the largest recording file a camera may back up
+	maxBackupFile = 64 << 20
+	// backupPlaylistFile is the playlist name cameras record, for backed up
+	// sessions it is built from the segments stored
+	backupPlaylistFile = "index.m3u8"
+	// backupDeleteTimeout bounds deleting a camera's backups
+	backupDeleteTimeout = 10 * time.Minute
+	// backupRetentionInterval is how often backups past their age are deleted,
+	// backupPruneBatch how many segments are deleted at once
+	backupRetentionInterval = time.Hour
+	backupPruneBatch        = 1000
+	// defaultRecordLimit and maxRecordLimit bound a page of backed up recordings
+	defaultRecordLimit = 50
+	maxRecordLimit     = 500
+)
+
+var (
+	// backupStore keeps the recording files cameras back up, nil when
+	// backups are disabled. Read it with currentBackupStore.
+	backupStore      storage.Store
+	backupStoreMutex sync.RWMutex
+)
+
+// SetBackupStore sets where backed up recordings are kept, nil disables backups
+func SetBackupStore(store storage.Store) {
+	backupStoreMutex.Lock()
+	defer backupStoreMutex.Unlock()
+	backupStore = store
+}
+
+// currentBackupStore returns the store backups are kept in, nil when disabled
+func currentBackupStore() storage.Store {
+	backupStoreMutex.RLock()
+	defer backupStoreMutex.RUnlock()
+	return backupStore
+}
+
+func backupKey(cameraID, name string) string {
+	return cameraID + "/" + path.Clean(name)
+}
+
+// UploadBackup stores a recording file a camera backs up, a segment or the
+// wrapped key of an encrypted session. Segments carry their index entry in
+// the query so recordings can be listed while the camera is offline. Files
+// are write-once, uploading one again is answered with 409 Conflict.
+func UploadBackup(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cameraID := r.PathValue("id")
+		claims := r.Context().Value(middleware.ContextClaimKey).(jwtmsg.AuthClaims)
+		if claims.EntityType != jwtmsg.EntityTypeCamera || claims.EntityID != cameraID {
+			http.Error(w, "Unauthorized to upload for this camera", http.StatusForbidden)
+			return
+		}
+		store := currentBackupStore()
+		if store == nil {
+			http.Error(w, "Backups are disabled", http.StatusServiceUnavailable)
+			return
+		}
+
+		name := r.PathValue("filepath")
+		session, file, ok := strings.Cut(name, "/")
+		if !ok || path.Clean(name) != name || strings.Contains(file, "/") || session == ".." {
+			http.Error(w, "Invalid file path", http.StatusBadRequest)
+			return
+		}
+		isSegment := path.Ext(file) == ".ts"
+		if !isSegment && file != recordcrypt.KeyFile {
+			http.Error(w, "Only segments and session keys are backed up", http.StatusBadRequest)
+			return
+		}
+
+		segment := models.BackupSegment{CameraID: cameraID, Session: session, File: file}
+		if isSegment {
+			query := r.URL.Query()
+			start, err := strconv.ParseInt(query.Get(jwtmsg.BackupStartParam), 10, 64)
+			if err != nil || start <= 0 {
+				http.Error(w, "Invalid start parameter", http.StatusBadRequest)
+				return
+			}
+			duration, err := strconv.ParseFloat(query.Get(jwtmsg.BackupDurationParam), 64)
+			if err != nil || duration < 0 || math.IsInf(duration, 0) || math.IsNaN(duration) {
+				http.Error(w, "Invalid duration parameter", http.StatusBadRequest)
+				return
+			}
+			trigger, _ := strconv.ParseInt(query.Get(jwtmsg.BackupTriggerParam), 10, 32)
+			segment.StartTime = start
+			segment.Duration = duration
+			segment.Trigger = pb.RecordingTrigger(trigger)
+		}
+
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBackupFile))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error reading file: %v", err), http.StatusBadRequest)
+			return
+		}
+		segment.Size = int64(len(data))
+
+		// Backups are write-once, a camera can't replace what it backed up.
+		// Segments claim their index entry before they are stored.
+		if isSegment {
+			result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&segment)
+			if result.Error != nil {
+				slog.Error("Failed to record backup", "camera_id", cameraID, "file", name, "error", result.Error)
+				http.Error(w, "Error storing file", http.StatusInternalServerError)
+				return
+			}
+			if result.RowsAffected == 0 {
+				http.Error(w, "File already backed up", http.StatusConflict)
+				return
+			}
+		} else if _, err := store.Get(r.Context(), backupKey(cameraID, name)); err == nil {
+			http.Error(w, "File already backed up", http.StatusConflict)
+			return
+		} else if !errors.Is(err, storage.ErrNotFound) {
+			slog.Error("Failed to read backup", "camera_id", cameraID, "file", name, "error", err)
+			http.Error(w, "Error storing file", http.StatusInternalServerError)
+			return
+		}
+
+		if err := store.Put(r.Context(), backupKey(cameraID, name), data); err != nil {
+			slog.Error("Failed to store backup", "camera_id", cameraID, "file", name, "error", err)
+			if isSegment {
+				// Let the camera try again
+				if err := db.Delete(&segment).Error; err != nil {
+					slog.Error("Failed to drop backup record", "camera_id", cameraID, "file", name, "error", err)
+				}
+			}
+			http.Error(w, "Error storing file", http.StatusInternalServerError)
+			return
+		}
+
+		slog.Debug("Recording backed up", "camera_id", cameraID, "file", name, "size", len(data))
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// fetchRecordingFile fetches a whole recording file from the camera, or from
+// its backup when the camera is offline or no longer has the file
+func fetchRecordingFile(ctx context.Context, cameraID, filePath string) ([]byte, error) {
+	data, err := fetchCameraFile(ctx, cameraID, filePath)
+	store := currentBackupStore()
+	if store == nil || !errors.Is(err, rpc.ErrUnavailable) && !errors.Is(err, rpc.ErrNotFound) {
+		return data, err
+	}
+	backup, backupErr := store.Get(ctx, backupKey(cameraID, filePath))
+	if backupErr != nil {
+		if !errors.Is(backupErr, storage.ErrNotFound) {
+			slog.Error("Failed to read backup", "camera_id", cameraID, "file_path", filePath, "error", backupErr)
+		}
+		return nil, err
+	}
+	return backup, nil
+}
+
+// serveBackupFile serves a recording file from the camera's backup. cause is
+// why the camera couldn't serve it, answered when there is no backup either.
+func serveBackupFile(w http.ResponseWriter, r *http.Request, db *gorm.DB, cameraID, userID, filePath string, cause error) {
+	store := currentBackupStore()
+	if store == nil {
+		writeCameraError(w, cause)
+		return
+	}
+
+	var data []byte
+	var err error
+	if name := path.Clean(filePath); path.Base(name) == backupPlaylistFile {
+		data, err = backupPlaylist(db, cameraID, path.Dir(name))
+	} else {
+		data, err = store.Get(r.Context(), backupKey(cameraID, name))
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		writeCameraError(w, cause)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to read backup", "camera_id", cameraID, "file_path", filePath, "error", err)
+		http.Error(w, "Failed to read backup", http.StatusInternalServerError)
+		return
+	}
+
+	hlsCache.put(cameraID, filePath, data)
+	serveRecordingFile(w, r, cameraID, userID, filePath, data)
+}
+
+// backupPlaylist builds the playlist of a session's backed up segments, gaps
+// where segments weren't backed up are marked as discontinuities
+func backupPlaylist(db *gorm.DB, cameraID, session string) ([]byte, error) {
+	var segments []models.BackupSegment
+	err := db.Where("camera_id = ? AND session = ?", cameraID, session).Order("start_time").Find(&segments).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("%w: no backup of session %s", storage.ErrNotFound, session)
+	}
+
+	target := 1.0
+	for _, segment := range segments {
+		target = max(target, math.Ceil(segment.Duration))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:%d\n#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-PLAYLIST-TYPE:VOD\n", int(target))
+	for i, segment := range segments {
+		if i > 0 && segment.StartTime-segments[i-1].End() > time.Second.Milliseconds() {
+			b.WriteString("#EXT-X-DISCONTINUITY\n")
+		}
+		fmt.Fprintf(&b, "#EXT-X-PROGRAM-DATE-TIME:%s\n#EXTINF:%.6f,\n%s\n",
+			time.UnixMilli(segment.StartTime).Format("2006-01-02T15:04:05.000-0700"), segment.Duration, segment.File)
+	}
+	b.WriteString("#EXT-X-ENDLIST\n")
+	return []byte(b.String()), nil
+}
+
+// listBackupRecordings answers a record request from the camera's backed up
+// segments, paginated like the camera's own index
+func listBackupRecordings(db *gorm.DB, cameraID string, request *pb.RecordRequest) (*pb.RecordResponse, error) {
+	var recordings []struct {
+		Session   string
+		StartTime int64
+		EndTime   int64
+		Duration  float64
+		Size      int64
+		Segments  int32
+		Trigger   pb.RecordingTrigger
+	}
+	const endTime = "MAX(start_time + CAST(duration * 1000 AS INTEGER))"
+	query := db.Model(&models.BackupSegment{}).
+		Select("session, MIN(start_time) AS start_time, "+endTime+" AS end_time, SUM(duration) AS duration, SUM(size) AS size, COUNT(*) AS segments, MIN(`trigger`) AS `trigger`").
+		Where("camera_id = ?", cameraID).
+		Group("session")
+	if request.StartTime > 0 {
+		query = query.Having(endTime+" >= ?", request.StartTime)
+	}
+	if request.EndTime > 0 {
+		query = query.Having("MIN(start_time) <= ?", request.EndTime)
+	}
+	if err := query.Scan(&recordings).Error; err != nil {
+		return nil, err
+	}
+
+	// Newest first, like the camera answers
+	sort.Slice(recordings, func(i, j int) bool {
+		if recordings[i].StartTime != recordings[j].StartTime {
+			return recordings[i].StartTime > recordings[j].StartTime
+		}
+		return recordings[i].Session > recordings[j].Session
+	})
+
+	total := len(recordings)
+	offset := min(max(int(request.Offset), 0), total)
+	limit := int(request.Limit)
+	if limit <= 0 || limit > maxRecordLimit {
+		limit = defaultRecordLimit
+	}
+	page := recordings[offset:min(offset+limit, total)]
+
+	response := &pb.RecordResponse{Total: int32(total)}
+	for _, recording := range page {
+		response.Records = append(response.Records, &pb.VideoRange{
+			FileName:  recording.Session,
+			StartTime: recording.StartTime,
+			EndTime:   recording.EndTime,
+			Duration:  recording.Duration,
+			Size:      recording.Size,
+			Segments:  recording.Segments,
+			Trigger:   recording.Trigger,
+		})
+	}
+	if offset+len(page) < total {
+		response.NextOffset = int32(offset + len(page))
+	}
+	return response, nil
+}
+
+// deleteBackups removes every backed up recording of a camera from store
+func deleteBackups(db *gorm.DB, store storage.Store, cameraID string) {
+	if store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), backupDeleteTimeout)
+	defer cancel()
+
+	if err := store.Delete(ctx, cameraID); err != nil {
+		slog.Error("Failed to delete backups", "camera_id", cameraID, "error", err)
+		return
+	}
+	if err := db.Where("camera_id = ?", cameraID).Delete(&models.BackupSegment{}).Error; err != nil {
+		slog.Error("Failed to delete backup records", "camera_id", cameraID, "error", err)
+		return
+	}
+	slog.Info("Deleted camera backups", "camera_id", cameraID)
+}
+
+// deleteBackupFiles removes backed up segments from store, files are
+// <session>/<file>
+func deleteBackupFiles(ctx context.Context, db *gorm.DB, store storage.Store, cameraID string, files []string) {
+	if store == nil || len(files) == 0 {
+		return
+	}
+
+	sessions := make(map[string]bool)
+	for _, name := range files {
+		session, file, ok := strings.Cut(name, "/")
+		if !ok {
+			continue
+		}
+		sessions[session] = true
+		if err := store.Delete(ctx, backupKey(cameraID, name)); err != nil {
+			slog.Error("Failed to delete backup", "camera_id", cameraID, "file", name, "error", err)
+			continue
+		}
+		err := db.Where("camera_id = ? AND session = ? AND file = ?", cameraID, session, file).Delete(&models.BackupSegment{}).Error
+		if err != nil {
+			slog.Error("Failed to delete backup record", "camera_id", cameraID, "file", name, "error", err)
+		}
+	}
+
+	// Sessions without segments left take their key with them
+	for session := range sessions {
+		var count int64
+		if err := db.Model(&models.BackupSegment{}).Where("camera_id = ? AND session = ?", cameraID, session).Count(&count).Error; err != nil || count > 0 {
+			continue
+		}
+		if err := store.Delete(ctx, backupKey(cameraID, session)); err != nil {
+			slog.Error("Failed to delete backup", "camera_id", cameraID, "session", session, "error", err)
+		}
+	}
+}
+
+// StartBackupRetention deletes backed up segments older than maxAge now and
+// every backupRetentionInterval, maxAge 0 keeps them
+func StartBackupRetention(db *gorm.DB, maxAge time.Duration) {
+	if maxAge <= 0 {
+		return
+	}
+	go func() {
+		for {
+			deleted, err := pruneBackups(db, time.Now().Add(-maxAge))
+			if err != nil {
+				slog.Error("Failed to delete old backups", "error", err)
+			}
+			if deleted > 0 {
+				slog.Info("Deleted old backups", "segments", deleted, "max_age", maxAge)
+			}
+			time.Sleep(backupRetentionInterval)
+		}
+	}()
+}
+
+// pruneBackups deletes the backed up segments that ended before a time and
+// returns how many were deleted
+func pruneBackups(db *gorm.DB, before time.Time) (int, error) {
+	store := currentBackupStore()
+	if store == nil {
+		return 0, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), backupDeleteTimeout)
+	defer cancel()
+	expired := func() *gorm.DB {
+		return db.Model(&models.BackupSegment{}).
+			Where("start_time + CAST(duration * 1000 AS INTEGER) < ?", before.UnixMilli())
+	}
+
+	deleted := 0
+	for {
+		var segments []models.BackupSegment
+		if err := expired().Select("camera_id", "session", "file").Order("start_time").Limit(backupPruneBatch).Find(&segments).Error; err != nil {
+			return deleted, err
+		}
+		if len(segments) == 0 {
+			return deleted, nil
+		}
+
+		var left, remaining int64
+		if err := expired().Count(&left).Error; err != nil {
+			return deleted, err
+		}
+		files := make(map[string][]string)
+		for _, segment := range segments {
+			files[segment.CameraID] = append(files[segment.CameraID], segment.Session+"/"+segment.File)
+		}
+		for cameraID, names := range files {
+			deleteBackupFiles(ctx, db, store, cameraID, names)
+		}
+		if err := expired().Count(&remaining).Error; err != nil {
+			return deleted, err
+		}
+
+		// Segments the store failed to delete are tried again next pass
+		deleted += int(left - remaining)
+		if remaining == 0 || remaining == left {
+			return deleted, nil
+		}
+	}
+}
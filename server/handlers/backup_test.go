@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	pb "messages/msgspb"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	gorilla "github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+
+	"messages/jwtmsg"
+	"messages/recordcrypt"
+	"messages/rpc"
+	"server/middleware"
+	"server/models"
+	"server/storage"
+	"server/websocket"
+)
+
+const backupCameraID = "9b1f6c2e-0000-4000-8000-000000000002"
+
+// useBackupStore backs up to a local store for the test and returns it
+func useBackupStore(t *testing.T, db *gorm.DB) storage.Store {
+	t.Helper()
+	if err := db.AutoMigrate(&models.BackupSegment{}); err != nil {
+		t.Fatal(err)
+	}
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := currentBackupStore()
+	SetBackupStore(store)
+	t.Cleanup(func() { SetBackupStore(previous) })
+	return store
+}
+
+// addBackup stores a backed up segment and its index entry
+func addBackup(t *testing.T, db *gorm.DB, store storage.Store, session, file string, start int64, duration float64) {
+	t.Helper()
+	name := session + "/" + file
+	if err := store.Put(context.Background(), backupKey(backupCameraID, name), []byte(name)); err != nil {
+		t.Fatal(err)
+	}
+	segment := models.BackupSegment{CameraID: backupCameraID, Session: session, File: file, StartTime: start, Duration: duration}
+	if err := db.Create(&segment).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// countBackups returns how many segments the camera's backup index holds
+func countBackups(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(&models.BackupSegment{}).Where("camera_id = ?", backupCameraID).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+// connectCamera connects a stand-in for the camera over the websocket, it
+// answers requests with reply
+func connectCamera(t *testing.T, db *gorm.DB, cameraID string, reply func(req *pb.Message) *pb.Message) {
+	t.Helper()
+	key := []byte("test key")
+	middleware.SetJWTKey(key)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwtmsg.AuthClaims{
+		EntityType:       jwtmsg.EntityTypeCamera,
+		EntityID:         cameraID,
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	}).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(websocket.HandleWebSocket(db))
+	conn, _, err := gorilla.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"?auth="+token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		for websocket.IsConnected(cameraID) {
+			time.Sleep(time.Millisecond)
+		}
+		server.Close()
+	})
+
+	go func() {
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			req := &pb.Message{}
+			if proto.Unmarshal(data, req) != nil || req.RequestId == "" {
+				continue
+			}
+			answer, _ := proto.Marshal(reply(req))
+			conn.WriteMessage(gorilla.BinaryMessage, answer)
+		}
+	}()
+	for !websocket.IsConnected(cameraID) {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func deleteRange(db *gorm.DB, start, end int64) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/cameras/%s/recordings?start=%d&end=%d", backupCameraID, start, end), nil)
+	r.SetPathValue("id", backupCameraID)
+	r = r.WithContext(context.WithValue(r.Context(), middleware.ContextUserKey, "user"))
+	w := httptest.NewRecorder()
+	DeleteRecordingRange(db)(w, r)
+	return w
+}
+
+func TestDeleteRecordingRangeKeepsLockedBackups(t *testing.T) {
+	db := openTestDB(t)
+	store := useBackupStore(t, db)
+	camera := models.Camera{ID: backupCameraID, UserID: "user"}
+	if err := db.Create(&camera).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	addBackup(t, db, store, "s1", "0.ts", 1000, 2) // Locked on the camera
+	addBackup(t, db, store, "s1", "1.ts", 3000, 2)
+	addBackup(t, db, store, "s2", "0.ts", 10000, 2)
+
+	// Offline, the camera's locks aren't known and nothing is deleted
+	if w := deleteRange(db, 1000, 9000); w.Code == http.StatusOK {
+		t.Fatal("range of an offline camera deleted")
+	}
+	if n := countBackups(t, db); n != 3 {
+		t.Fatalf("%d backed up segments left, want 3", n)
+	}
+
+	connectCamera(t, db, backupCameraID, func(req *pb.Message) *pb.Message {
+		reply := rpc.Reply(req, backupCameraID)
+		reply.DataType = &pb.Message_RangeResult{RangeResult: &pb.RangeResult{Files: []string{"s1/1.ts"}, Skipped: 1}}
+		return reply
+	})
+	w := deleteRange(db, 1000, 9000)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var result pb.RangeResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 1 || result.Skipped != 1 {
+		t.Fatalf("result %v", &result)
+	}
+
+	if _, err := store.Get(context.Background(), backupKey(backupCameraID, "s1/1.ts")); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("deleted segment still backed up: %v", err)
+	}
+	for _, name := range []string{"s1/0.ts", "s2/0.ts"} {
+		if _, err := store.Get(context.Background(), backupKey(backupCameraID, name)); err != nil {
+			t.Fatalf("backup of %s the camera kept deleted: %v", name, err)
+		}
+	}
+	if n := countBackups(t, db); n != 2 {
+		t.Fatalf("%d backed up segments left, want 2", n)
+	}
+}
+
+// uploadBackup uploads a file as the camera
+func uploadBackup(db *gorm.DB, name, body string) *httptest.ResponseRecorder {
+	target := jwtmsg.BackupPath(backupCameraID, name)
+	if strings.HasSuffix(name, ".ts") {
+		target += "?start=1000&duration=2&trigger=1"
+	}
+	r := httptest.NewRequest(http.MethodPut, target, strings.NewReader(body))
+	r.SetPathValue("id", backupCameraID)
+	r.SetPathValue("filepath", name)
+	claims := jwtmsg.AuthClaims{EntityType: jwtmsg.EntityTypeCamera, EntityID: backupCameraID}
+	r = r.WithContext(context.WithValue(r.Context(), middleware.ContextClaimKey, claims))
+	w := httptest.NewRecorder()
+	UploadBackup(db)(w, r)
+	return w
+}
+
+func TestUploadBackupWriteOnce(t *testing.T) {
+	db := openTestDB(t)
+	store := useBackupStore(t, db)
+
+	for _, name := range []string{"s1/0.ts", "s1/" + recordcrypt.KeyFile} {
+		if w := uploadBackup(db, name, "original"); w.Code != http.StatusCreated {
+			t.Fatalf("%s: status %d: %s", name, w.Code, w.Body)
+		}
+		if w := uploadBackup(db, name, "replaced"); w.Code != http.StatusConflict {
+			t.Fatalf("%s uploaded again: status %d, want 409", name, w.Code)
+		}
+		data, err := store.Get(context.Background(), backupKey(backupCameraID, name))
+		if err != nil || string(data) != "original" {
+			t.Fatalf("%s = %q, %v, want the first upload", name, data, err)
+		}
+	}
+	if n := countBackups(t, db); n != 1 {
+		t.Fatalf("%d backed up segments, want 1", n)
+	}
+}
+
+func TestPruneBackups(t *testing.T) {
+	db := openTestDB(t)
+	store := useBackupStore(t, db)
+
+	now := time.Now()
+	old := now.Add(-48 * time.Hour).UnixMilli()
+	addBackup(t, db, store, "old", "0.ts", old, 2)
+	addBackup(t, db, store, "old", "1.ts", old+2000, 2)
+	if err := store.Put(context.Background(), backupKey(backupCameraID, "old/"+recordcrypt.KeyFile), []byte("key")); err != nil {
+		t.Fatal(err)
+	}
+	addBackup(t, db, store, "new", "0.ts", now.Add(-time.Hour).UnixMilli(), 2)
+
+	deleted, err := pruneBackups(db, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 2 {
+		t.Fatalf("deleted %d segments, want 2", deleted)
+	}
+	if n := countBackups(t, db); n != 1 {
+		t.Fatalf("%d backed up segments left, want 1", n)
+	}
+	for _, name := range []string{"old/0.ts", "old/1.ts", "old/" + recordcrypt.KeyFile} {
+		if _, err := store.Get(context.Background(), backupKey(backupCameraID, name)); !errors.Is(err, storage.ErrNotFound) {
+			t.Fatalf("%s still stored: %v", name, err)
+		}
+	}
+	if _, err := store.Get(context.Background(), backupKey(backupCameraID, "new/0.ts")); err != nil {
+		t.Fatalf("recent segment deleted: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"crypto/ed25519"
 	"encoding/json"
 	"log/slog"
 	"net/http"
@@ -27,7 +28,8 @@ type CameraAddRequest struct {
 
 // DeleteCameraRequest represents the request body for camera deletion endpoint
 type DeleteCameraRequest struct {
-	CameraUUID string `json:"camera_uuid"`
+	CameraUUID       string `json:"camera_uuid"`
+	DeleteRecordings bool   `json:"delete_recordings"` // Otherwise the camera keeps its recordings
 }
 
 // UpdateCameraRequest represents the request body for camera update endpoint
@@ -44,7 +46,9 @@ func NewUserConfig() pb.UserConfig {
 	}
 }
 
-func HandleGenerateCamera(jwtKey []byte) http.HandlerFunc {
+// HandleGenerateCamera creates a CameraAdd token signed with the provisioning
+// key, which the camera verifies before joining Wi-Fi or registering
+func HandleGenerateCamera(provisioningKey ed25519.PrivateKey) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -69,28 +73,51 @@ func HandleGenerateCamera(jwtKey []byte) http.HandlerFunc {
 		expirationTime := time.Now().Add(2 * time.Hour)
 
 		data := &jwtmsg.CameraAdd{
-			UserID:       userID,
-			ServerURL:    serverURL,
-			WifiNetwork:  req.WifiNetwork,
-			WifiPassword: req.WifiPassword,
+			UserID:    userID,
+			ServerURL: serverURL,
 			RegisteredClaims: jwt.RegisteredClaims{
 				ExpiresAt: jwt.NewNumericDate(expirationTime),
 			},
 		}
 
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, data)
-		tokenString, err := token.SignedString(jwtKey)
+		// The Wi-Fi credentials are sealed with a one-time code that the UI only
+		// shows on request, in place of the setup QR code, so a photo of the QR
+		// code doesn't leak the password
+		var wifiCode string
+		if req.WifiNetwork != "" {
+			code, err := jwtmsg.GenerateShortCode()
+			if err != nil {
+				http.Error(w, "Error creating Wi-Fi code", http.StatusInternalServerError)
+				return
+			}
+
+			sealed, err := jwtmsg.SealWifiCredentials(jwtmsg.WifiCredentials{
+				Network:  req.WifiNetwork,
+				Password: req.WifiPassword,
+			}, code)
+			if err != nil {
+				slog.Error("Failed to seal Wi-Fi credentials", slog.Any("error", err))
+				http.Error(w, "Error creating token", http.StatusInternalServerError)
+				return
+			}
+
+			data.WifiSealed = sealed
+			wifiCode = code
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, data)
+		tokenString, err := token.SignedString(provisioningKey)
 		if err != nil {
 			http.Error(w, "Error creating token", http.StatusInternalServerError)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"code": tokenString})
+		json.NewEncoder(w).Encode(map[string]string{"code": tokenString, "wifiCode": wifiCode})
 	}
 }
 
-func HandleRegisterCamera(db *gorm.DB, jwtKey []byte) http.HandlerFunc {
+func HandleRegisterCamera(db *gorm.DB, jwtKey []byte, provisioningKey ed25519.PublicKey) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -104,58 +131,92 @@ func HandleRegisterCamera(db *gorm.DB, jwtKey []byte) http.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.ParseWithClaims(register.Token, &jwtmsg.CameraAdd{}, func(token *jwt.Token) (interface{}, error) {
-			return jwtKey, nil
-		})
-		if err != nil {
-			slog.Error("Failed to parse JWT", slog.Any("error", err))
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-			return
-		}
+		// Cameras either present a signed CameraAdd token or a claim code
+		var userID, friendlyName string
+		if register.ClaimCode == "" {
+			token, err := jwt.ParseWithClaims(register.Token, &jwtmsg.CameraAdd{}, func(token *jwt.Token) (interface{}, error) {
+				return provisioningKey, nil
+			}, jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}), jwt.WithExpirationRequired())
+			if err != nil {
+				slog.Error("Failed to parse JWT", slog.Any("error", err))
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
 
-		claims, ok := token.Claims.(*jwtmsg.CameraAdd)
-		if !ok {
-			slog.Error("Failed to parse claims")
-			http.Error(w, "Invalid token claims", http.StatusUnauthorized)
-			return
+			claims, ok := token.Claims.(*jwtmsg.CameraAdd)
+			if !ok {
+				slog.Error("Failed to parse claims")
+				http.Error(w, "Invalid token claims", http.StatusUnauthorized)
+				return
+			}
+			userID = claims.UserID
+			friendlyName = claims.FriendlyName
 		}
 
-		// Create the camera with the friendly name from the claims
-		camera := models.Camera{
-			ID:     register.CameraUUID,
-			UserID: claims.UserID,
-			Name:   claims.FriendlyName,
-			// Location:     "Default", // You can set a default location or leave it null
-			Config:   NewUserConfig(),
-			IsOnline: false,
+		if report := register.Connectivity; report != nil {
+			slog.Info("Camera connectivity report",
+				"camera_id", register.CameraUUID,
+				"network", report.Network,
+				"connected", report.Connected,
+				"ip", report.IPAddress,
+				"server_reachable", report.ServerReachable,
+				"error", report.Error)
 		}
 
-		if err := db.Create(&camera).Error; err != nil {
+		// A claim code is only used up together with creating the camera, a
+		// failed registration leaves it for the camera to try again
+		var camera models.Camera
+		var claimErr error
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if register.ClaimCode != "" {
+				claim, err := redeemClaim(tx, register.ClaimCode)
+				if err != nil {
+					claimErr = err
+					return err
+				}
+				userID = claim.UserID
+				friendlyName = claim.FriendlyName
+			}
+
+			// Create the camera with the friendly name from the claims
+			camera = models.Camera{
+				ID:     register.CameraUUID,
+				UserID: userID,
+				Name:   friendlyName,
+				// Location:     "Default", // You can set a default location or leave it null
+				Config:   NewUserConfig(),
+				IsOnline: false,
+			}
+			return tx.Create(&camera).Error
+		})
+		if claimErr != nil {
+			slog.Error("Failed to redeem claim code", slog.Any("error", claimErr))
+			http.Error(w, "Invalid claim code", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
 			slog.Error("Failed to create camera", slog.Any("error", err))
 			http.Error(w, "Error registering camera", http.StatusInternalServerError)
 			return
 		}
-		auth_claims := &jwtmsg.AuthClaims{
-			EntityID:         register.CameraUUID,
-			EntityType:       jwtmsg.EntityTypeCamera,
-			RegisteredClaims: jwt.RegisteredClaims{},
-		}
-
-		token = jwt.NewWithClaims(jwt.SigningMethodHS256, auth_claims)
-		tokenString, err := token.SignedString(jwtKey)
+		tokens, err := issueCameraTokens(db, camera.ID, "", false, jwtKey)
 		if err != nil {
+			slog.Error("Failed to issue camera tokens", slog.Any("error", err))
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
 		}
 
+		tokens.FriendlyName = camera.Name
+
 		//Send Refresh to User if connected
-		websocket.SendRefreshToClient(claims.UserID)
+		websocket.SendRefreshToClient(userID)
 		w.Header().Add(
 			"Authorization",
-			"Bearer "+tokenString,
+			"Bearer "+tokens.Token,
 		)
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(map[string]string{"message": "Camera registered successfully"})
+		json.NewEncoder(w).Encode(tokens)
 	}
 }
 
@@ -202,9 +263,17 @@ func UpdateCamera(db *gorm.DB) http.HandlerFunc {
 		if req.Name != nil {
 			camera.Name = *req.Name
 		}
+		if retention := req.Config.GetRetention(); retention != nil {
+			if retention.MaxAgeDays < 0 || retention.MaxBytes < 0 || retention.MinFreeBytes < 0 {
+				http.Error(w, "Retention limits must not be negative", http.StatusBadRequest)
+				return
+			}
+		}
 		if req.Config != nil {
 			proto.Reset(&camera.Config)
 			proto.Merge(&camera.Config, req.Config)
+			// The recording key comes from the owner, never from the stored config
+			camera.Config.RecordingKey = nil
 		}
 
 		if err := db.Save(&camera).Error; err != nil {
@@ -212,15 +281,7 @@ func UpdateCamera(db *gorm.DB) http.HandlerFunc {
 			http.Error(w, "Error updating camera", http.StatusInternalServerError)
 			return
 		}
-		err := websocket.SendMessageToClient(camera.ID, &pb.Message{
-			DataType: &pb.Message_UserConfig{
-				UserConfig: &camera.Config,
-			},
-		})
-
-		if err != nil {
-			slog.Error("Failed to send config update to camera", slog.Any("error", err))
-		}
+		sendCameraUserConfig(db, &camera)
 
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(map[string]string{
@@ -269,12 +330,36 @@ func DeleteCamera(db *gorm.DB) http.HandlerFunc {
 			return
 		}
 
-		// Delete the camera
-		if err := db.Delete(&camera).Error; err != nil {
+		// The row is gone so the camera can't refresh anymore. It is told to
+		// wipe itself until it acknowledges, whether it is connected or not.
+		deregistration := models.PendingDeregistration{
+			CameraID:         camera.ID,
+			Reason:           "Camera deleted",
+			DeleteRecordings: req.DeleteRecordings,
+			RefreshTokenHash: camera.RefreshTokenHash,
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Delete(&camera).Error; err != nil {
+				return err
+			}
+			return tx.Save(&deregistration).Error
+		})
+		if err != nil {
 			slog.Error("Failed to delete camera", slog.Any("error", err))
 			http.Error(w, "Error deleting camera", http.StatusInternalServerError)
 			return
 		}
+		hlsCache.purge(camera.ID, "")
+		if req.DeleteRecordings {
+			go deleteBackups(db, currentBackupStore(), camera.ID)
+		}
+
+		if websocket.IsConnected(camera.ID) {
+			if err := websocket.SendMessageToClient(camera.ID, deregistration.Message()); err != nil {
+				slog.Error("Failed to send deregistration", "camera_id", camera.ID, "error", err)
+			}
+			websocket.DisconnectClient(camera.ID, "Camera deleted")
+		}
 
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(map[string]string{
@@ -315,14 +400,21 @@ func GetCameraConfig(db *gorm.DB) http.HandlerFunc {
 		}
 
 		config := &models.Camera{}
-		if err := db.Model(&models.Camera{}).Where("id = ?", providedCameraID).Select("config").Find(&config).Error; err != nil {
+		if err := db.Model(&models.Camera{}).Where("id = ?", providedCameraID).Select("config", "user_id").Find(&config).Error; err != nil {
+			slog.Error("Error fetching camera config", "error", err)
+			http.Error(w, "Error fetching camera config", http.StatusInternalServerError)
+			return
+		}
+
+		userConfig, err := cameraUserConfig(db, config)
+		if err != nil {
 			slog.Error("Error fetching camera config", "error", err)
 			http.Error(w, "Error fetching camera config", http.StatusInternalServerError)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(&config.Config)
+		json.NewEncoder(w).Encode(userConfig)
 	}
 
 }
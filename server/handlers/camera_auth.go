@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"messages/jwtmsg"
+	"server/middleware"
+	"server/models"
+	"server/websocket"
+)
+
+const (
+	// cameraTokenTTL is how long a camera access token is valid before it must be refreshed
+	cameraTokenTTL = time.Hour
+	// refreshGracePeriod is how long a rotated refresh token can still be
+	// exchanged, so a camera that lost the response to its refresh can retry
+	refreshGracePeriod = 2 * time.Minute
+)
+
+var errRefreshTokenReused = errors.New("refresh token already used")
+
+// generateRefreshToken returns a random opaque refresh token
+func generateRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// authenticateDeregistration checks a deleted camera's refresh token against
+// the one it had when it was deleted
+func authenticateDeregistration(deregistration *models.PendingDeregistration, refreshToken string) bool {
+	hash := hashRefreshToken(refreshToken)
+	return deregistration.RefreshTokenHash != "" &&
+		subtle.ConstantTimeCompare([]byte(hash), []byte(deregistration.RefreshTokenHash)) == 1
+}
+
+// issueCameraTokens signs a short-lived access token and rotates the camera's
+// refresh token. currentHash must match the stored hash so concurrent
+// refreshes can't both rotate. The replaced token stays valid for
+// refreshGracePeriod, except on a retry with the previous token, which only
+// replaces the token the camera never received.
+func issueCameraTokens(db *gorm.DB, cameraID string, currentHash string, retry bool, jwtKey []byte) (*jwtmsg.CameraTokens, error) {
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(cameraTokenTTL)
+	claims := &jwtmsg.AuthClaims{
+		EntityID:   cameraID,
+		EntityType: jwtmsg.EntityTypeCamera,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	updates := map[string]interface{}{"refresh_token_hash": hashRefreshToken(refreshToken)}
+	if !retry {
+		updates["previous_refresh_token_hash"] = currentHash
+		updates["refresh_token_rotated_at"] = now
+	}
+	result := db.Model(&models.Camera{}).
+		Where("id = ? AND refresh_token_hash = ?", cameraID, currentHash).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errRefreshTokenReused
+	}
+
+	return &jwtmsg.CameraTokens{
+		Token:        tokenString,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt.Unix(),
+	}, nil
+}
+
+// HandleRefreshCameraToken exchanges a camera's refresh token for new credentials.
+// Deleted or revoked cameras get 410 Gone and have to be provisioned again.
+func HandleRefreshCameraToken(db *gorm.DB, jwtKey []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req jwtmsg.RefreshCameraToken
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if req.CameraUUID == "" || req.RefreshToken == "" {
+			http.Error(w, "Camera UUID and refresh token are required", http.StatusBadRequest)
+			return
+		}
+
+		var camera models.Camera
+		if err := db.Where("id = ?", req.CameraUUID).First(&camera).Error; err != nil {
+			// A camera deleted while offline learns what to wipe from the 410
+			// body, and only the camera itself may learn it
+			var deregistration models.PendingDeregistration
+			if db.Where("camera_id = ?", req.CameraUUID).First(&deregistration).Error == nil {
+				if !authenticateDeregistration(&deregistration, req.RefreshToken) {
+					slog.Warn("Invalid refresh token for deleted camera", "camera_id", req.CameraUUID)
+					http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+					return
+				}
+				slog.Info("Deregistration delivered on refresh", "camera_id", req.CameraUUID)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusGone)
+				json.NewEncoder(w).Encode(jwtmsg.Deregistration{
+					Reason:           deregistration.Reason,
+					DeleteRecordings: deregistration.DeleteRecordings,
+				})
+				return
+			}
+
+			slog.Warn("Refresh for unknown camera", "camera_id", req.CameraUUID)
+			http.Error(w, "Camera must be provisioned again", http.StatusGone)
+			return
+		}
+
+		if camera.Revoked || camera.RefreshTokenHash == "" {
+			slog.Warn("Refresh for revoked camera", "camera_id", camera.ID)
+			http.Error(w, "Camera must be provisioned again", http.StatusGone)
+			return
+		}
+
+		hash := hashRefreshToken(req.RefreshToken)
+		retry := false
+		switch {
+		case subtle.ConstantTimeCompare([]byte(hash), []byte(camera.RefreshTokenHash)) == 1:
+		case camera.PreviousRefreshTokenHash != "" &&
+			subtle.ConstantTimeCompare([]byte(hash), []byte(camera.PreviousRefreshTokenHash)) == 1 &&
+			time.Since(camera.RefreshTokenRotatedAt) < refreshGracePeriod:
+			// The camera never got the tokens of its last refresh
+			slog.Info("Refresh retried with the previous token", "camera_id", camera.ID)
+			retry = true
+		default:
+			slog.Warn("Invalid refresh token", "camera_id", camera.ID)
+			http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+			return
+		}
+
+		tokens, err := issueCameraTokens(db, camera.ID, camera.RefreshTokenHash, retry, jwtKey)
+		if errors.Is(err, errRefreshTokenReused) {
+			http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to issue camera tokens", "camera_id", camera.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		slog.Info("Camera credentials refreshed", "camera_id", camera.ID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tokens)
+	}
+}
+
+// HandleAcknowledgeDeregistration drops the pending deregistration of a
+// deleted camera once the camera confirms it wiped itself
+func HandleAcknowledgeDeregistration(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req jwtmsg.RefreshCameraToken
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		var deregistration models.PendingDeregistration
+		if err := db.Where("camera_id = ?", req.CameraUUID).First(&deregistration).Error; err != nil {
+			http.Error(w, "No pending deregistration", http.StatusNotFound)
+			return
+		}
+		if !authenticateDeregistration(&deregistration, req.RefreshToken) {
+			slog.Warn("Invalid refresh token for deregistration ack", "camera_id", req.CameraUUID)
+			http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+			return
+		}
+
+		if err := db.Delete(&deregistration).Error; err != nil {
+			slog.Error("Failed to delete deregistration", "camera_id", req.CameraUUID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		slog.Info("Deregistration acknowledged", "camera_id", req.CameraUUID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// HandleUnregisterCamera deletes a camera that is being factory reset, along
+// with its backups. The camera authenticates with its refresh token.
+func HandleUnregisterCamera(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req jwtmsg.RefreshCameraToken
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		var camera models.Camera
+		if err := db.Where("id = ?", req.CameraUUID).First(&camera).Error; err != nil {
+			http.Error(w, "Camera not found", http.StatusGone)
+			return
+		}
+		hash := hashRefreshToken(req.RefreshToken)
+		if camera.RefreshTokenHash == "" || subtle.ConstantTimeCompare([]byte(hash), []byte(camera.RefreshTokenHash)) != 1 {
+			slog.Warn("Invalid refresh token for unregister", "camera_id", camera.ID)
+			http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+			return
+		}
+
+		if err := db.Delete(&camera).Error; err != nil {
+			slog.Error("Failed to delete camera", "camera_id", camera.ID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		hlsCache.purge(camera.ID, "")
+		go deleteBackups(db, currentBackupStore(), camera.ID)
+
+		slog.Info("Camera unregistered itself", "camera_id", camera.ID)
+		websocket.SendRefreshToClient(camera.UserID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// RevokeCamera revokes a camera's credentials and disconnects it, forcing it to be provisioned again
+func RevokeCamera(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cameraID := r.PathValue("id")
+		userID := r.Context().Value(middleware.ContextUserKey).(string)
+
+		var camera models.Camera
+		if err := db.Where("id = ?", cameraID).First(&camera).Error; err != nil {
+			http.Error(w, "Camera not found", http.StatusNotFound)
+			return
+		}
+
+		if camera.UserID != userID {
+			slog.Warn("Unauthorized revoke attempt",
+				"requester_id", userID,
+				"camera_owner_id", camera.UserID)
+			http.Error(w, "Unauthorized to revoke this camera", http.StatusForbidden)
+			return
+		}
+
+		if err := revokeCamera(db, camera.ID); err != nil {
+			slog.Error("Failed to revoke camera", "camera_id", camera.ID, "error", err)
+			http.Error(w, "Error revoking camera", http.StatusInternalServerError)
+			return
+		}
+
+		websocket.SendRefreshToClient(userID)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{
+			"status":  "success",
+			"message": "Camera revoked successfully",
+		})
+	}
+}
+
+// revokeCamera invalidates the camera's refresh token and drops its connection
+func revokeCamera(db *gorm.DB, cameraID string) error {
+	err := db.Model(&models.Camera{}).
+		Where("id = ?", cameraID).
+		Updates(map[string]interface{}{
+			"revoked":                     true,
+			"refresh_token_hash":          "",
+			"previous_refresh_token_hash": "",
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	websocket.DisconnectClient(cameraID, "Camera credentials revoked")
+	return nil
+}
+
+// CameraValidator returns a check used by the auth middleware to reject
+// tokens of cameras that were deleted or revoked before the token expired
+func CameraValidator(db *gorm.DB) func(cameraID string) error {
+	return func(cameraID string) error {
+		var camera models.Camera
+		if err := db.Select("id", "revoked").Where("id = ?", cameraID).First(&camera).Error; err != nil {
+			return fmt.Errorf("camera not found: %w", err)
+		}
+		if camera.Revoked {
+			return errors.New("camera revoked")
+		}
+		return nil
+	}
+}
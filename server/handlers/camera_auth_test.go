@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"messages/jwtmsg"
+	"server/middleware"
+	"server/models"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.Camera{}, &models.PendingClaim{}, &models.PendingDeregistration{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func refresh(t *testing.T, handler http.HandlerFunc, cameraID, token string) (int, jwtmsg.CameraTokens) {
+	t.Helper()
+	body, _ := json.Marshal(jwtmsg.RefreshCameraToken{CameraUUID: cameraID, RefreshToken: token})
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, "/api/cameras/token/refresh", bytes.NewReader(body)))
+	var tokens jwtmsg.CameraTokens
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&tokens); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w.Code, tokens
+}
+
+func TestRefreshCameraTokenRetry(t *testing.T) {
+	db := openTestDB(t)
+	camera := models.Camera{ID: "9b1f6c2e-0000-4000-8000-000000000001", RefreshTokenHash: hashRefreshToken("first")}
+	if err := db.Create(&camera).Error; err != nil {
+		t.Fatal(err)
+	}
+	handler := HandleRefreshCameraToken(db, []byte("key"))
+
+	code, lost := refresh(t, handler, camera.ID, "first")
+	if code != http.StatusOK {
+		t.Fatalf("refresh: status %d", code)
+	}
+
+	// The response was lost, the camera retries with the token it still has
+	code, retried := refresh(t, handler, camera.ID, "first")
+	if code != http.StatusOK {
+		t.Fatalf("retry within grace period: status %d", code)
+	}
+	if code, _ := refresh(t, handler, camera.ID, lost.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("token replaced by the retry: status %d, want 401", code)
+	}
+
+	code, next := refresh(t, handler, camera.ID, retried.RefreshToken)
+	if code != http.StatusOK {
+		t.Fatalf("refresh with retried token: status %d", code)
+	}
+
+	// Once the grace period is over the replaced token is dead
+	db.Model(&models.Camera{}).Where("id = ?", camera.ID).Update("refresh_token_rotated_at", time.Now().Add(-2*refreshGracePeriod))
+	if code, _ := refresh(t, handler, camera.ID, retried.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("previous token after grace period: status %d, want 401", code)
+	}
+	if code, _ := refresh(t, handler, camera.ID, next.RefreshToken); code != http.StatusOK {
+		t.Errorf("current token: status %d", code)
+	}
+}
+
+func TestDeregistrationUntilAcknowledged(t *testing.T) {
+	db := openTestDB(t)
+	camera := models.Camera{ID: "9b1f6c2e-0000-4000-8000-000000000001", UserID: "user", RefreshTokenHash: hashRefreshToken("token")}
+	if err := db.Create(&camera).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := json.Marshal(DeleteCameraRequest{CameraUUID: camera.ID, DeleteRecordings: true})
+	r := httptest.NewRequest(http.MethodDelete, "/api/cameras/delete", bytes.NewReader(body))
+	r = r.WithContext(context.WithValue(r.Context(), middleware.ContextUserKey, "user"))
+	w := httptest.NewRecorder()
+	DeleteCamera(db)(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete: status %d", w.Code)
+	}
+
+	handler := HandleRefreshCameraToken(db, []byte("key"))
+	if code, _ := refresh(t, handler, camera.ID, "guess"); code != http.StatusUnauthorized {
+		t.Fatalf("refresh with a wrong token: status %d, want 401", code)
+	}
+
+	// Repeated until the camera acknowledges
+	for i := 0; i < 2; i++ {
+		body, _ := json.Marshal(jwtmsg.RefreshCameraToken{CameraUUID: camera.ID, RefreshToken: "token"})
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, "/api/cameras/token/refresh", bytes.NewReader(body)))
+		var deregistration jwtmsg.Deregistration
+		if w.Code != http.StatusGone || json.NewDecoder(w.Body).Decode(&deregistration) != nil || !deregistration.DeleteRecordings {
+			t.Fatalf("refresh %d of deleted camera: status %d", i, w.Code)
+		}
+	}
+
+	ack := func(token string) int {
+		body, _ := json.Marshal(jwtmsg.RefreshCameraToken{CameraUUID: camera.ID, RefreshToken: token})
+		w := httptest.NewRecorder()
+		HandleAcknowledgeDeregistration(db)(w, httptest.NewRequest(http.MethodPost, jwtmsg.DeregistrationAckPath, bytes.NewReader(body)))
+		return w.Code
+	}
+	if code := ack("guess"); code != http.StatusUnauthorized {
+		t.Fatalf("ack with a wrong token: status %d, want 401", code)
+	}
+	if code := ack("token"); code != http.StatusNoContent {
+		t.Fatalf("ack: status %d, want 204", code)
+	}
+
+	var count int64
+	db.Model(&models.PendingDeregistration{}).Where("camera_id = ?", camera.ID).Count(&count)
+	if count != 0 {
+		t.Fatal("deregistration kept after the ack")
+	}
+}
+
+func TestUnregisterCamera(t *testing.T) {
+	db := openTestDB(t)
+	camera := models.Camera{ID: "9b1f6c2e-0000-4000-8000-000000000001", UserID: "user", RefreshTokenHash: hashRefreshToken("token")}
+	if err := db.Create(&camera).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	unregister := func(token string) int {
+		body, _ := json.Marshal(jwtmsg.RefreshCameraToken{CameraUUID: camera.ID, RefreshToken: token})
+		w := httptest.NewRecorder()
+		HandleUnregisterCamera(db)(w, httptest.NewRequest(http.MethodPost, jwtmsg.UnregisterPath, bytes.NewReader(body)))
+		return w.Code
+	}
+	if code := unregister("guess"); code != http.StatusUnauthorized {
+		t.Fatalf("unregister with a wrong token: status %d, want 401", code)
+	}
+	if code := unregister("token"); code != http.StatusNoContent {
+		t.Fatalf("unregister: status %d, want 204", code)
+	}
+	if err := db.First(&models.Camera{}, "id = ?", camera.ID).Error; err == nil {
+		t.Fatal("camera kept after unregister")
+	}
+	if code := unregister("token"); code != http.StatusGone {
+		t.Fatalf("second unregister: status %d, want 410", code)
+	}
+}
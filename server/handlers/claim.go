@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+
+	"messages/jwtmsg"
+	"server/middleware"
+	"server/models"
+)
+
+// claimTTL is how long a claim code can be redeemed after it's created
+const claimTTL = 30 * time.Minute
+
+var errInvalidClaim = errors.New("claim code is invalid, expired or already used")
+
+// CreateClaimRequest represents the request body for the claim code endpoint
+type CreateClaimRequest struct {
+	FriendlyName string `json:"friendly_name"`
+}
+
+// HandleCreateClaim stores a pending claim for the user and returns a short
+// code, which is easier to scan or type than a full CameraAdd token
+func HandleCreateClaim(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateClaimRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if req.FriendlyName == "" {
+			http.Error(w, "Camera name is required", http.StatusBadRequest)
+			return
+		}
+
+		code, err := jwtmsg.GenerateShortCode()
+		if err != nil {
+			http.Error(w, "Error creating claim code", http.StatusInternalServerError)
+			return
+		}
+
+		userID := r.Context().Value(middleware.ContextUserKey).(string)
+		claim := models.PendingClaim{
+			Code:         jwtmsg.NormalizeShortCode(code),
+			UserID:       userID,
+			FriendlyName: req.FriendlyName,
+			ExpiresAt:    time.Now().Add(claimTTL),
+		}
+		if err := db.Create(&claim).Error; err != nil {
+			slog.Error("Failed to create claim", slog.Any("error", err))
+			http.Error(w, "Error creating claim code", http.StatusInternalServerError)
+			return
+		}
+
+		// Drop claims nobody redeemed so the table doesn't grow forever
+		db.Where("expires_at < ?", time.Now()).Delete(&models.PendingClaim{})
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"claimCode": code,
+			"qr":        jwtmsg.ClaimURL(os.Getenv("SERVER_URL"), code),
+			"serverUrl": os.Getenv("SERVER_URL"),
+			"expiresAt": claim.ExpiresAt.Unix(),
+		})
+	}
+}
+
+// redeemClaim marks the claim as used and returns it. The update only matches
+// an unused, unexpired claim, so two cameras racing for a code can't both win.
+func redeemClaim(db *gorm.DB, code string) (*models.PendingClaim, error) {
+	code = jwtmsg.NormalizeShortCode(code)
+
+	result := db.Model(&models.PendingClaim{}).
+		Where("code = ? AND used = ? AND expires_at > ?", code, false, time.Now()).
+		Update("used", true)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return nil, errInvalidClaim
+	}
+
+	var claim models.PendingClaim
+	if err := db.First(&claim, "code = ?", code).Error; err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
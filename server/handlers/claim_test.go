@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"messages/jwtmsg"
+	"server/models"
+)
+
+func register(t *testing.T, handler http.HandlerFunc, cameraID, code string) int {
+	t.Helper()
+	body, _ := json.Marshal(jwtmsg.RegisterCamera{CameraUUID: cameraID, ClaimCode: code})
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, "/api/cameras/register", bytes.NewReader(body)))
+	return w.Code
+}
+
+func TestRegisterWithClaimCode(t *testing.T) {
+	db := openTestDB(t)
+	claim := models.PendingClaim{Code: "ABCD2345", UserID: "user", FriendlyName: "Porch", ExpiresAt: time.Now().Add(claimTTL)}
+	if err := db.Create(&claim).Error; err != nil {
+		t.Fatal(err)
+	}
+	existing := models.Camera{ID: "9b1f6c2e-0000-4000-8000-000000000001", UserID: "other"}
+	if err := db.Create(&existing).Error; err != nil {
+		t.Fatal(err)
+	}
+	publicKey, _, _ := ed25519.GenerateKey(nil)
+	handler := HandleRegisterCamera(db, []byte("key"), publicKey)
+
+	// Creating the camera fails, the code must stay usable
+	if code := register(t, handler, existing.ID, "abcd-2345"); code != http.StatusInternalServerError {
+		t.Fatalf("register with a taken camera id: status %d, want 500", code)
+	}
+	var stored models.PendingClaim
+	db.First(&stored, "code = ?", claim.Code)
+	if stored.Used {
+		t.Fatal("claim used up by a failed registration")
+	}
+
+	cameraID := "9b1f6c2e-0000-4000-8000-000000000002"
+	if code := register(t, handler, cameraID, "abcd-2345"); code != http.StatusCreated {
+		t.Fatalf("register: status %d, want 201", code)
+	}
+	var camera models.Camera
+	if err := db.First(&camera, "id = ?", cameraID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if camera.UserID != "user" || camera.Name != "Porch" {
+		t.Fatalf("camera not created from the claim: user %q, name %q", camera.UserID, camera.Name)
+	}
+
+	// A code works once
+	if code := register(t, handler, "9b1f6c2e-0000-4000-8000-000000000003", "abcd-2345"); code != http.StatusUnauthorized {
+		t.Fatalf("second use of the claim: status %d, want 401", code)
+	}
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	pb "messages/msgspb"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"server/middleware"
+	"server/models"
+	"server/websocket"
+)
+
+// maxCommandOutput caps how much command output is stored per result
+const maxCommandOutput = 64 * 1024
+
+// SendCommandRequest represents the request body for the camera command endpoint
+type SendCommandRequest struct {
+	Command string `json:"command"`
+}
+
+// commandTypes maps the API command names to the protobuf command types
+var commandTypes = map[string]pb.CommandType{
+	"reboot":         pb.CommandType_COMMAND_TYPE_REBOOT,
+	"restart_agent":  pb.CommandType_COMMAND_TYPE_RESTART_AGENT,
+	"restart_stream": pb.CommandType_COMMAND_TYPE_RESTART_STREAM,
+	"factory_reset":  pb.CommandType_COMMAND_TYPE_FACTORY_RESET,
+	"resync_config":  pb.CommandType_COMMAND_TYPE_RESYNC_CONFIG,
+	"diagnostics":    pb.CommandType_COMMAND_TYPE_DIAGNOSTICS,
+}
+
+// RegisterCommandResultHandler stores command results reported by cameras
+func RegisterCommandResultHandler(db *gorm.DB) {
+	websocket.RegisterMessageHandler("commandResult", func(msg *pb.Message) {
+		result := msg.GetCommandResult()
+		if result == nil {
+			return
+		}
+
+		output := result.Output
+		if len(output) > maxCommandOutput {
+			output = output[:maxCommandOutput]
+		}
+
+		now := time.Now()
+		update := db.Model(&models.CameraCommand{}).
+			Where("id = ? AND camera_id = ?", result.Id, msg.From).
+			Updates(map[string]interface{}{
+				"status":       result.Status,
+				"output":       output,
+				"completed_at": &now,
+			})
+		if update.Error != nil {
+			slog.Error("Failed to store command result", "command_id", result.Id, "error", update.Error)
+			return
+		}
+		if update.RowsAffected == 0 {
+			slog.Warn("Command result for unknown command", "command_id", result.Id, "camera_id", msg.From)
+			return
+		}
+
+		slog.Info("Command result received",
+			"camera_id", msg.From,
+			"command_id", result.Id,
+			"type", result.Type.String(),
+			"status", result.Status.String())
+
+		var command models.CameraCommand
+		if err := db.Select("user_id").Where("id = ?", result.Id).First(&command).Error; err == nil {
+			websocket.SendRefreshToClient(command.UserID)
+		}
+	})
+}
+
+// getOwnedCamera loads the camera in the path and checks it belongs to the user
+func getOwnedCamera(db *gorm.DB, w http.ResponseWriter, r *http.Request) (*models.Camera, bool) {
+	cameraID := r.PathValue("id")
+	userID := r.Context().Value(middleware.ContextUserKey).(string)
+
+	var camera models.Camera
+	if err := db.Where("id = ?", cameraID).First(&camera).Error; err != nil {
+		http.Error(w, "Camera not found", http.StatusNotFound)
+		return nil, false
+	}
+
+	if camera.UserID != userID {
+		slog.Warn("Unauthorized camera access attempt",
+			"requester_id", userID,
+			"camera_owner_id", camera.UserID)
+		http.Error(w, "Unauthorized to access this camera", http.StatusForbidden)
+		return nil, false
+	}
+	return &camera, true
+}
+
+// SendCameraCommand sends a maintenance command to an online camera owned by the user
+func SendCameraCommand(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req SendCommandRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		commandType, ok := commandTypes[req.Command]
+		if !ok {
+			http.Error(w, "Unknown command", http.StatusBadRequest)
+			return
+		}
+
+		camera, ok := getOwnedCamera(db, w, r)
+		if !ok {
+			return
+		}
+
+		if !websocket.IsConnected(camera.ID) {
+			http.Error(w, "Camera is offline", http.StatusConflict)
+			return
+		}
+
+		command := models.CameraCommand{
+			ID:       uuid.NewString(),
+			CameraID: camera.ID,
+			UserID:   camera.UserID,
+			Type:     commandType,
+			Status:   pb.CommandStatus_COMMAND_STATUS_PENDING,
+		}
+		if err := db.Create(&command).Error; err != nil {
+			slog.Error("Failed to store command", "error", err)
+			http.Error(w, "Error sending command", http.StatusInternalServerError)
+			return
+		}
+
+		err := websocket.SendMessageToClient(camera.ID, &pb.Message{
+			To:   camera.ID,
+			From: "server",
+			DataType: &pb.Message_CameraCommand{
+				CameraCommand: &pb.CameraCommand{
+					Id:   command.ID,
+					Type: commandType,
+				},
+			},
+		})
+		if err != nil {
+			slog.Error("Failed to send command", "camera_id", camera.ID, "error", err)
+			db.Model(&command).Updates(map[string]interface{}{
+				"status": pb.CommandStatus_COMMAND_STATUS_FAILED,
+				"output": "failed to send command to camera",
+			})
+			http.Error(w, "Error sending command", http.StatusInternalServerError)
+			return
+		}
+
+		slog.Info("Command sent", "camera_id", camera.ID, "command_id", command.ID, "type", commandType.String())
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(command)
+	}
+}
+
+// ListCameraCommands returns the most recent commands sent to a camera
+func ListCameraCommands(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		camera, ok := getOwnedCamera(db, w, r)
+		if !ok {
+			return
+		}
+
+		var commands []models.CameraCommand
+		if err := db.Where("camera_id = ?", camera.ID).Order("created_at desc").Limit(50).Find(&commands).Error; err != nil {
+			slog.Error("Failed to list commands", "camera_id", camera.ID, "error", err)
+			http.Error(w, "Error listing commands", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(commands)
+	}
+}
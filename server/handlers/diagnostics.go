@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	pb "messages/msgspb"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"messages/jwtmsg"
+	"server/middleware"
+	"server/models"
+)
+
+const (
+	// maxDiagnosticsChunk is the largest chunk accepted in one request
+	maxDiagnosticsChunk = 1024 * 1024
+	// maxDiagnosticsBundle is the largest bundle stored per command
+	maxDiagnosticsBundle = 64 * 1024 * 1024
+)
+
+// UploadDiagnosticChunk appends a chunk to a diagnostic bundle. Chunks must
+// arrive in order, the stored size is returned in every response so the camera
+// can resume after a failure. The bundle is listed once the final chunk arrives.
+func UploadDiagnosticChunk(db *gorm.DB, dir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cameraID := r.PathValue("id")
+		claims := r.Context().Value(middleware.ContextClaimKey).(jwtmsg.AuthClaims)
+		if claims.EntityType != jwtmsg.EntityTypeCamera || claims.EntityID != cameraID {
+			http.Error(w, "Unauthorized to upload for this camera", http.StatusForbidden)
+			return
+		}
+
+		bundleID, err := uuid.Parse(r.PathValue("bundle"))
+		if err != nil {
+			http.Error(w, "Invalid bundle ID", http.StatusBadRequest)
+			return
+		}
+
+		// Only bundles a user asked for are accepted
+		var command models.CameraCommand
+		err = db.Where("id = ? AND camera_id = ? AND type = ?", bundleID.String(), cameraID, pb.CommandType_COMMAND_TYPE_DIAGNOSTICS).
+			First(&command).Error
+		if err != nil {
+			http.Error(w, "No diagnostics requested", http.StatusNotFound)
+			return
+		}
+
+		var existing int64
+		db.Model(&models.DiagnosticBundle{}).Where("id = ?", command.ID).Count(&existing)
+		if existing > 0 {
+			http.Error(w, "Bundle already uploaded", http.StatusConflict)
+			return
+		}
+
+		offset, err := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+		if err != nil || offset < 0 {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+		final := r.URL.Query().Get("final") == "true"
+
+		cameraDir := filepath.Join(dir, cameraID)
+		if err := os.MkdirAll(cameraDir, 0700); err != nil {
+			slog.Error("Failed to create diagnostics directory", "error", err)
+			http.Error(w, "Error storing bundle", http.StatusInternalServerError)
+			return
+		}
+		path := filepath.Join(cameraDir, command.ID+".tar.gz")
+		partPath := path + ".part"
+
+		f, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			slog.Error("Failed to open bundle", "error", err)
+			http.Error(w, "Error storing bundle", http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+
+		info, err := f.Stat()
+		if err != nil {
+			http.Error(w, "Error storing bundle", http.StatusInternalServerError)
+			return
+		}
+		stored := info.Size()
+
+		if offset != stored {
+			w.Header().Set(jwtmsg.DiagnosticsOffsetHeader, strconv.FormatInt(stored, 10))
+			http.Error(w, "Unexpected offset", http.StatusConflict)
+			return
+		}
+
+		if _, err := f.Seek(stored, io.SeekStart); err != nil {
+			http.Error(w, "Error storing bundle", http.StatusInternalServerError)
+			return
+		}
+		written, err := io.Copy(f, io.LimitReader(r.Body, maxDiagnosticsChunk+1))
+		stored += written
+		if err == nil && written > maxDiagnosticsChunk {
+			err = errors.New("chunk too large")
+		}
+		if err == nil && stored > maxDiagnosticsBundle {
+			err = errors.New("bundle too large")
+		}
+		if err != nil {
+			// Drop the partial chunk so the camera can resend it
+			f.Truncate(stored - written)
+			w.Header().Set(jwtmsg.DiagnosticsOffsetHeader, strconv.FormatInt(stored-written, 10))
+			http.Error(w, fmt.Sprintf("Error storing chunk: %v", err), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set(jwtmsg.DiagnosticsOffsetHeader, strconv.FormatInt(stored, 10))
+
+		if !final {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		if err := f.Close(); err != nil {
+			http.Error(w, "Error storing bundle", http.StatusInternalServerError)
+			return
+		}
+		if err := os.Rename(partPath, path); err != nil {
+			slog.Error("Failed to finish bundle", "error", err)
+			http.Error(w, "Error storing bundle", http.StatusInternalServerError)
+			return
+		}
+
+		bundle := models.DiagnosticBundle{
+			ID:       command.ID,
+			CameraID: cameraID,
+			Size:     stored,
+			Path:     path,
+		}
+		if err := db.Create(&bundle).Error; err != nil {
+			slog.Error("Failed to store bundle", "error", err)
+			http.Error(w, "Error storing bundle", http.StatusInternalServerError)
+			return
+		}
+
+		slog.Info("Diagnostic bundle stored", "camera_id", cameraID, "bundle_id", bundle.ID, "size", stored)
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// ListDiagnosticBundles lists the diagnostic bundles of an owned camera, newest first
+func ListDiagnosticBundles(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		camera, ok := getOwnedCamera(db, w, r)
+		if !ok {
+			return
+		}
+
+		var bundles []models.DiagnosticBundle
+		if err := db.Where("camera_id = ?", camera.ID).Order("created_at desc").Find(&bundles).Error; err != nil {
+			slog.Error("Failed to list bundles", "camera_id", camera.ID, "error", err)
+			http.Error(w, "Error listing bundles", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(bundles)
+	}
+}
+
+// DownloadDiagnosticBundle serves a diagnostic bundle of an owned camera
+func DownloadDiagnosticBundle(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		camera, ok := getOwnedCamera(db, w, r)
+		if !ok {
+			return
+		}
+
+		var bundle models.DiagnosticBundle
+		if err := db.Where("id = ? AND camera_id = ?", r.PathValue("bundle"), camera.ID).First(&bundle).Error; err != nil {
+			http.Error(w, "Bundle not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "diagnostics-"+bundle.ID+".tar.gz"))
+		http.ServeFile(w, r, bundle.Path)
+	}
+}
@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	pb "messages/msgspb"
+	"messages/recordcrypt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"server/remux"
+	"server/websocket"
+)
+
+const (
+	// maxExportDuration is the longest clip that can be exported at once
+	maxExportDuration = time.Hour
+	// exportTimeout bounds how long a job may take to fetch and remux
+	exportTimeout = 30 * time.Minute
+	// exportTTL is how long a finished export can be downloaded
+	exportTTL = time.Hour
+)
+
+// Export job states
+const (
+	ExportRunning = "running"
+	ExportDone    = "done"
+	ExportFailed  = "failed"
+)
+
+var errNoRecordings = errors.New("no recordings in range")
+
+// ExportJob remuxes the recordings of a time range into one MP4
+type ExportJob struct {
+	ID          string     `json:"id"`
+	CameraID    string     `json:"cameraId"`
+	Start       time.Time  `json:"start"`
+	End         time.Time  `json:"end"`
+	Status      string     `json:"status"`
+	Progress    float64    `json:"progress"` // Fraction of segments processed
+	Error       string     `json:"error,omitempty"`
+	Size        int64      `json:"size,omitempty"`
+	DownloadURL string     `json:"downloadUrl,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+
+	userID string
+	path   string
+}
+
+var (
+	exportJobs     = make(map[string]*ExportJob)
+	exportJobMutex sync.Mutex
+)
+
+// ExportClip starts exporting an owned camera's recordings between the start
+// and end query parameters, Unix milliseconds, as a single MP4. The clip is
+// widened to the key frames around the range. The job is polled at its status
+// URL until it has a download link.
+func ExportClip(db *gorm.DB, dir string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		camera, ok := getOwnedCamera(db, w, r)
+		if !ok {
+			return
+		}
+
+		start, end, err := parseTimeRange(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if end.Sub(start) > maxExportDuration {
+			http.Error(w, fmt.Sprintf("Exports are limited to %s", maxExportDuration), http.StatusBadRequest)
+			return
+		}
+
+		if !websocket.IsConnected(camera.ID) {
+			http.Error(w, "Camera is offline", http.StatusServiceUnavailable)
+			return
+		}
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			slog.Error("Failed to create export directory", "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		job := &ExportJob{
+			ID:       uuid.NewString(),
+			CameraID: camera.ID,
+			Start:    start,
+			End:      end,
+			Status:   ExportRunning,
+			userID:   camera.UserID,
+		}
+		job.path = filepath.Join(dir, job.ID+".mp4")
+
+		exportJobMutex.Lock()
+		exportJobs[job.ID] = job
+		snapshot := *job
+		exportJobMutex.Unlock()
+
+		go runExport(job, dir)
+
+		slog.Info("Export started", "camera_id", camera.ID, "job_id", job.ID, "start", start, "end", end)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", exportURL(job))
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(snapshot)
+	}
+}
+
+// GetExportJob returns the progress of an export
+func GetExportJob(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, ok := getExportJob(db, w, r)
+		if !ok {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(job)
+	}
+}
+
+// DownloadExport serves a finished export
+func DownloadExport(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, ok := getExportJob(db, w, r)
+		if !ok {
+			return
+		}
+		if job.Status != ExportDone {
+			http.Error(w, "Export is not finished", http.StatusConflict)
+			return
+		}
+
+		name := fmt.Sprintf("%s_%s.mp4", job.CameraID, job.Start.UTC().Format("2006-01-02_15-04-05"))
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+		http.ServeFile(w, r, job.path)
+	}
+}
+
+// getExportJob returns a copy of the export in the path of an owned camera
+func getExportJob(db *gorm.DB, w http.ResponseWriter, r *http.Request) (ExportJob, bool) {
+	camera, ok := getOwnedCamera(db, w, r)
+	if !ok {
+		return ExportJob{}, false
+	}
+
+	exportJobMutex.Lock()
+	defer exportJobMutex.Unlock()
+	job, exists := exportJobs[r.PathValue("job")]
+	if !exists || job.CameraID != camera.ID {
+		http.Error(w, "Export not found", http.StatusNotFound)
+		return ExportJob{}, false
+	}
+	return *job, true
+}
+
+func exportURL(job *ExportJob) string {
+	return fmt.Sprintf("/api/cameras/%s/exports/%s", job.CameraID, job.ID)
+}
+
+// runExport runs a job and keeps its result for exportTTL
+func runExport(job *ExportJob, dir string) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	size, err := exportClip(ctx, job, dir)
+
+	exportJobMutex.Lock()
+	if err != nil {
+		job.Status = ExportFailed
+		job.Error = err.Error()
+		os.Remove(job.path)
+	} else {
+		job.Status = ExportDone
+		job.Progress = 1
+		job.Size = size
+		job.DownloadURL = exportURL(job) + "/download"
+	}
+	expires := time.Now().Add(exportTTL)
+	job.ExpiresAt = &expires
+	exportJobMutex.Unlock()
+
+	if err != nil {
+		slog.Error("Export failed", "camera_id", job.CameraID, "job_id", job.ID, "error", err)
+	} else {
+		slog.Info("Export finished", "camera_id", job.CameraID, "job_id", job.ID, "size", size)
+	}
+
+	time.AfterFunc(exportTTL, func() {
+		exportJobMutex.Lock()
+		delete(exportJobs, job.ID)
+		exportJobMutex.Unlock()
+		os.Remove(job.path)
+	})
+}
+
+// exportClip fetches the segments covering the job's range one at a time and
+// remuxes their frames into the job's MP4, returning its size
+func exportClip(ctx context.Context, job *ExportJob, dir string) (int64, error) {
+	reply, err := websocket.Call(ctx, job.CameraID, &pb.Message{
+		DataType: &pb.Message_SegmentRequest{
+			SegmentRequest: &pb.SegmentRequest{
+				StartTime: job.Start.UnixMilli(),
+				EndTime:   job.End.UnixMilli(),
+			},
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list segments: %w", err)
+	}
+	segments := reply.GetSegmentResponse().GetSegments()
+	if len(segments) == 0 {
+		return 0, errNoRecordings
+	}
+
+	mdat, err := os.CreateTemp(dir, job.ID+"-*.mdat")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(mdat.Name())
+	defer mdat.Close()
+
+	writer := remux.NewWriter(mdat)
+	trim := &clipTrimmer{start: job.Start.UnixMilli(), end: job.End.UnixMilli(), writer: writer}
+	for i, segment := range segments {
+		data, err := fetchExportSegment(ctx, job, segment.FileName)
+		if err != nil {
+			return 0, fmt.Errorf("failed to fetch %s: %w", segment.FileName, err)
+		}
+		frames, err := remux.ReadTS(data)
+		if err != nil {
+			// One damaged segment shouldn't fail the whole clip
+			slog.Warn("Skipping unreadable segment", "camera_id", job.CameraID, "segment", segment.FileName, "error", err)
+			continue
+		}
+		if len(frames) > 0 {
+			first := frames[0].PTS
+			for _, frame := range frames {
+				if err := trim.add(frame, frameTime(segment.StartTime, first, frame.PTS)); err != nil {
+					return 0, err
+				}
+			}
+		}
+
+		exportJobMutex.Lock()
+		job.Progress = float64(i+1) / float64(len(segments))
+		exportJobMutex.Unlock()
+
+		if trim.done {
+			break
+		}
+	}
+	if writer.Frames() == 0 {
+		return 0, errNoRecordings
+	}
+
+	tmp := job.path + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp)
+	if err := writer.Finish(out); err != nil {
+		out.Close()
+		return 0, err
+	}
+	info, err := out.Stat()
+	if err == nil {
+		err = out.Close()
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), os.Rename(tmp, job.path)
+}
+
+// fetchExportSegment returns a segment in plain, from the cache when possible.
+// Encrypted segments need the owner's recordings unlocked.
+func fetchExportSegment(ctx context.Context, job *ExportJob, filePath string) ([]byte, error) {
+	data, ok := hlsCache.get(job.CameraID, filePath)
+	if !ok {
+		var err error
+		if data, err = fetchRecordingFile(ctx, job.CameraID, filePath); err != nil {
+			return nil, err
+		}
+		hlsCache.put(job.CameraID, filePath, data)
+	}
+	if recordcrypt.IsSealed(data) {
+		return openSegment(ctx, job.CameraID, job.userID, filePath, data)
+	}
+	return data, nil
+}
+
+// frameTime returns when a frame was recorded in Unix milliseconds, from the
+// start of its segment and the PTS of the segment's first frame. PTS wraps at
+// 33 bits.
+func frameTime(segmentStart, firstPTS, pts int64) int64 {
+	return segmentStart + ((pts-firstPTS)&(1<<33-1))/90
+}
+
+// clipTrimmer passes the frames between start and end to the writer. The clip
+// starts at the last key frame at or before start and ends before the first
+// key frame at or after end, so it never needs re-encoding.
+type clipTrimmer struct {
+	start, end int64 // Unix milliseconds
+	writer     *remux.Writer
+	gop        []remux.AccessUnit // Frames since the last key frame before start
+	started    bool
+	done       bool
+}
+
+func (c *clipTrimmer) add(frame remux.AccessUnit, at int64) error {
+	switch {
+	case c.done:
+		return nil
+	case c.started:
+		if at >= c.end && frame.Key() {
+			c.done = true
+			return nil
+		}
+		return c.writer.WriteFrame(frame)
+	}
+
+	if frame.Key() {
+		c.gop = c.gop[:0]
+	} else if len(c.gop) == 0 {
+		return nil // Not decodable without the key frame before it
+	}
+	c.gop = append(c.gop, frame)
+	if at < c.start {
+		return nil
+	}
+
+	c.started = true
+	for _, frame := range c.gop {
+		if err := c.writer.WriteFrame(frame); err != nil {
+			return err
+		}
+	}
+	c.gop = nil
+	return nil
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"server/remux"
+)
+
+// fixtureFrames reads the frames of a remux test segment, ten frames 1/30 s
+// apart starting with a key frame
+func fixtureFrames(t *testing.T, name string) []remux.AccessUnit {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "remux", "testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, err := remux.ReadTS(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return frames
+}
+
+// trimSegments feeds segments of frames starting at the given Unix
+// milliseconds through a trimmer and returns it
+func trimSegments(t *testing.T, start, end int64, starts []int64, segments ...[]remux.AccessUnit) *clipTrimmer {
+	t.Helper()
+	mdat, err := os.Create(filepath.Join(t.TempDir(), "mdat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { mdat.Close() })
+
+	trim := &clipTrimmer{start: start, end: end, writer: remux.NewWriter(mdat)}
+	for i, frames := range segments {
+		for _, frame := range frames {
+			if err := trim.add(frame, frameTime(starts[i], frames[0].PTS, frame.PTS)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return trim
+}
+
+func TestClipTrimmer(t *testing.T) {
+	frames := fixtureFrames(t, "segment.ts")
+	starts := []int64{10000, 11000, 12000}
+
+	for _, test := range []struct {
+		name       string
+		start, end int64
+		segments   [][]remux.AccessUnit
+		frames     int
+		done       bool
+	}{
+		// From the key frame before start to the key frame at end
+		{"mid GOP", 11050, 12000, [][]remux.AccessUnit{frames, frames, frames}, 10, true},
+		// The end falls inside a GOP, the rest of it is kept
+		{"end inside GOP", 9000, 10100, [][]remux.AccessUnit{frames, frames, frames}, 10, true},
+		// Frames before the first key frame can't be decoded
+		{"no key frame", 0, 20000, [][]remux.AccessUnit{frames[3:], frames}, 10, false},
+		{"after the recordings", 13000, 14000, [][]remux.AccessUnit{frames, frames, frames}, 0, false},
+	} {
+		trim := trimSegments(t, test.start, test.end, starts, test.segments...)
+		if trim.writer.Frames() != test.frames || trim.done != test.done {
+			t.Errorf("%s: %d frames, done %v, want %d, %v", test.name, trim.writer.Frames(), trim.done, test.frames, test.done)
+		}
+	}
+}
+
+func TestFrameTimeWrap(t *testing.T) {
+	// DTS and PTS wrap past 2^33 within the segment
+	frames := fixtureFrames(t, "wrap.ts")
+	for i, frame := range frames {
+		if at, want := frameTime(10000, frames[0].PTS, frame.PTS), 10000+int64(i)*100/3; at != want {
+			t.Errorf("frame %d at %d, want %d", i, at, want)
+		}
+	}
+
+	trim := trimSegments(t, 10100, 20000, []int64{10000}, frames)
+	if trim.writer.Frames() != len(frames) {
+		t.Fatalf("%d frames across the wrap, want %d", trim.writer.Frames(), len(frames))
+	}
+}
+
+func TestParseTimeRange(t *testing.T) {
+	for _, test := range []struct {
+		query string
+		ok    bool
+	}{
+		{"start=1700000000000&end=1700000060000", true},
+		{"start=1700000000000&end=1700000000001", true},
+		{"start=1700000000000", false},
+		{"end=1700000060000", false},
+		{"start=0&end=1700000060000", false},
+		{"start=-5&end=1700000060000", false},
+		{"start=yesterday&end=1700000060000", false},
+		{"start=1.5&end=1700000060000", false},
+		{"start=1700000000000&end=1700000000000", false},
+		{"start=1700000060000&end=1700000000000", false},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/api/cameras/x/recordings?"+test.query, nil)
+		start, end, err := parseTimeRange(r)
+		if (err == nil) != test.ok {
+			t.Errorf("%s: err = %v, want ok %v", test.query, err, test.ok)
+			continue
+		}
+		if test.ok && (start.UnixMilli() != 1700000000000 || !end.After(start)) {
+			t.Errorf("%s: %v to %v", test.query, start, end)
+		}
+		if !test.ok && (!start.IsZero() || !end.IsZero()) {
+			t.Errorf("%s: times returned with an error", test.query)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	pb "messages/msgspb"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"server/models"
+	"server/websocket"
+)
+
+// telemetryRetention is how much telemetry history is kept per camera
+const telemetryRetention = 24 * time.Hour
+
+// HealthResponse is returned by the camera health endpoint
+type HealthResponse struct {
+	IsOnline bool                     `json:"isOnline"`
+	LastSeen *time.Time               `json:"lastSeen"`
+	Latest   *models.TelemetrySample  `json:"latest"`
+	History  []models.TelemetrySample `json:"history"`
+}
+
+// RegisterTelemetryHandler stores telemetry samples sent by cameras
+func RegisterTelemetryHandler(db *gorm.DB) {
+	websocket.RegisterMessageHandler("telemetry", func(msg *pb.Message) {
+		telemetry := msg.GetTelemetry()
+		if telemetry == nil {
+			return
+		}
+
+		sample := models.TelemetrySample{
+			CameraID:        msg.From,
+			Timestamp:       time.Unix(telemetry.Timestamp, 0),
+			UptimeSeconds:   telemetry.UptimeSeconds,
+			LoadAverage:     telemetry.LoadAverage,
+			CPUUsage:        telemetry.CpuUsage,
+			SocTemperature:  telemetry.SocTemperature,
+			DiskUsedBytes:   telemetry.DiskUsedBytes,
+			DiskTotalBytes:  telemetry.DiskTotalBytes,
+			RecordingsBytes: telemetry.RecordingsBytes,
+			StreamFPS:       telemetry.StreamFps,
+			StreamBitrate:   telemetry.StreamBitrate,
+			Viewers:         telemetry.Viewers,
+
+			LockedBytes:           telemetry.LockedBytes,
+			RetentionDeletedBytes: telemetry.RetentionDeletedBytes,
+		}
+		if telemetry.OldestRecording > 0 {
+			oldest := time.Unix(telemetry.OldestRecording, 0)
+			sample.OldestRecording = &oldest
+		}
+		if err := db.Create(&sample).Error; err != nil {
+			slog.Error("Failed to store telemetry", "camera_id", msg.From, "error", err)
+			return
+		}
+
+		now := time.Now()
+		db.Model(&models.Camera{}).Where("id = ?", msg.From).Update("last_seen", &now)
+
+		// Keep a rolling window per camera
+		db.Where("camera_id = ? AND timestamp < ?", msg.From, now.Add(-telemetryRetention)).
+			Delete(&models.TelemetrySample{})
+	})
+}
+
+// GetCameraHealth returns the latest telemetry and the recent history of an owned camera.
+// The history window can be narrowed with ?since=<unix seconds>.
+func GetCameraHealth(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		camera, ok := getOwnedCamera(db, w, r)
+		if !ok {
+			return
+		}
+
+		since := time.Now().Add(-telemetryRetention)
+		if value := r.URL.Query().Get("since"); value != "" {
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				http.Error(w, "Invalid since parameter", http.StatusBadRequest)
+				return
+			}
+			since = time.Unix(seconds, 0)
+		}
+
+		var history []models.TelemetrySample
+		err := db.Where("camera_id = ? AND timestamp >= ?", camera.ID, since).
+			Order("timestamp asc").
+			Find(&history).Error
+		if err != nil {
+			slog.Error("Failed to load telemetry", "camera_id", camera.ID, "error", err)
+			http.Error(w, "Error loading health", http.StatusInternalServerError)
+			return
+		}
+
+		response := HealthResponse{
+			IsOnline: camera.IsOnline,
+			LastSeen: camera.LastSeen,
+			History:  history,
+		}
+		if len(history) > 0 {
+			response.Latest = &history[len(history)-1]
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
@@ -1,81 +1,26 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	pb "messages/msgspb"
+	"messages/recordcrypt"
+	"messages/rpc"
 	"net/http"
+	"path"
 	"server/middleware"
 	"server/models"
 	"server/websocket"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"gorm.io/gorm"
 )
 
-// Create a map to store response channels for camera HLS requests
-var (
-	hlsResponseChannels     = make(map[string]chan []byte)
-	hlsResponseChannelMutex sync.Mutex
-
-	recordResponseChannels     = make(map[string]chan pb.RecordResponse)
-	recordResponseChannelMutex sync.Mutex
-)
-
-// RegisterHLSResponseHandler registers a handler for HLS responses from cameras
-func RegisterHLSResponseHandler() {
-	websocket.RegisterMessageHandler("hlsResponse", func(msg *pb.Message) {
-		if msg.GetHlsResponse() == nil {
-			return
-		}
-
-		cameraID := msg.From
-		hlsResponseChannelMutex.Lock()
-		defer hlsResponseChannelMutex.Unlock()
-
-		// Check if there's a channel waiting for this response
-		if ch, exists := hlsResponseChannels[cameraID+msg.GetHlsResponse().FileName]; exists {
-			// Send the data to the channel
-			select {
-			case ch <- msg.GetHlsResponse().Data:
-				// Data sent successfully
-			default:
-				// Channel not ready, this shouldn't happen
-				slog.Warn("HLS response channel not ready for camera", "camera_id", cameraID)
-			}
-		} else {
-			slog.Warn("Received HLS response but no channel exists", "camera_id", cameraID)
-		}
-	})
-
-	websocket.RegisterMessageHandler("recordResponse", func(msg *pb.Message) {
-		if msg.GetRecordResponse() == nil {
-			return
-		}
-
-		cameraID := msg.From
-		recordResponseChannelMutex.Lock()
-		defer recordResponseChannelMutex.Unlock()
-
-		// Check if there's a channel waiting for this response
-		if ch, exists := recordResponseChannels[cameraID+strconv.Itoa(int(msg.GetRecordResponse().Id))]; exists {
-			// Send the data to the channel
-			select {
-			case ch <- *msg.GetRecordResponse():
-				// Data sent successfully
-			default:
-				// Channel not ready, this shouldn't happen
-				slog.Warn("Record response channel not ready for camera", "camera_id", cameraID)
-			}
-		} else {
-			slog.Warn("Received Record response but no channel exists", "camera_id", cameraID)
-		}
-	})
-}
-
 // ServeHLSContent handles requests for HLS content from cameras
 func ServeHLSContent(db *gorm.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -100,71 +45,213 @@ func ServeHLSContent(db *gorm.DB) http.HandlerFunc {
 			return
 		}
 
-		// Check if camera is online
+		// Set appropriate content type based on file extension
+		contentType := "video/mp2t"
+		if strings.HasSuffix(filePath, ".m3u8") {
+			contentType = "application/vnd.apple.mpegurl"
+		}
+		w.Header().Set("Content-Type", contentType)
+
+		// Set CORS headers to allow cross-origin requests
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+
+		// Segments never change, playlists grow while recording
+		switch cacheTTL(filePath) {
+		case segmentCacheTTL:
+			w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d, immutable", int(segmentCacheTTL.Seconds())))
+		case playlistCacheTTL:
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+
+		// Cached files play even while the camera is offline
+		if data, ok := hlsCache.get(cameraID, filePath); ok {
+			serveRecordingFile(w, r, cameraID, userID, filePath, data)
+			return
+		}
+
+		// Offline cameras play from their backup
 		if !camera.IsOnline {
-			slog.Error("Camera is offline", "camera_id", cameraID)
-			http.Error(w, "Camera is offline", http.StatusServiceUnavailable)
+			serveBackupFile(w, r, db, cameraID, userID, filePath, rpc.ErrUnavailable)
 			return
 		}
 
-		// Create a channel to receive the HLS response
-		responseChan := make(chan []byte, 1)
-		channelID := cameraID + filePath
-		// Register the channel to receive the response
-		hlsResponseChannelMutex.Lock()
-		hlsResponseChannels[channelID] = responseChan
-		hlsResponseChannelMutex.Unlock()
-
-		// Clean up the channel when done
-		defer func() {
-			hlsResponseChannelMutex.Lock()
-			delete(hlsResponseChannels, channelID)
-			hlsResponseChannelMutex.Unlock()
-			close(responseChan)
-		}()
-
-		// Send the request to the camera
-		err := websocket.SendMessageToClient(cameraID, &pb.Message{
-			From: "server",
-			To:   cameraID,
-			DataType: &pb.Message_HlsRequest{
-				HlsRequest: &pb.HLSRequest{
-					FileName: filePath,
-				},
-			},
-		})
+		offset, length, ranged, err := parseRange(r.Header.Get("Range"))
+		if err != nil {
+			http.Error(w, "Invalid range", http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
 
+		var chunk *pb.HLSResponse
+		transfer, err := startCameraTransfer(r.Context(), cameraID, filePath, offset, length)
+		if err == nil {
+			defer transfer.close()
+			chunk, err = transfer.next()
+		}
+		if errors.Is(err, rpc.ErrNotFound) || errors.Is(err, rpc.ErrUnavailable) {
+			// Deleted from the camera or it just went away, the backup may still have it
+			serveBackupFile(w, r, db, cameraID, userID, filePath, err)
+			return
+		}
 		if err != nil {
-			slog.Error("Failed to send HLS request to camera", "camera_id", cameraID, "error", err)
-			http.Error(w, "Failed to communicate with camera", http.StatusInternalServerError)
+			writeCameraError(w, err)
+			return
+		}
+
+		// Encrypted segments are decrypted with the key the owner unlocked, that
+		// needs the whole segment so ranges are served from the plaintext
+		if chunk.Sealed {
+			transfer.cancel()
+			serveSealedSegment(w, r, cameraID, userID, filePath)
 			return
 		}
 
-		// Wait for the response with a timeout
-		select {
-		case data := <-responseChan:
-			// Set appropriate content type based on file extension
-			contentType := "video/mp2t"
-			if strings.HasSuffix(filePath, ".m3u8") {
-				contentType = "application/vnd.apple.mpegurl"
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Length", strconv.FormatInt(chunk.Length, 10))
+		if ranged {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d",
+				chunk.Offset, chunk.Offset+chunk.Length-1, chunk.TotalSize))
+			w.WriteHeader(http.StatusPartialContent)
+		}
+
+		// Whole files are kept for the next viewer
+		var body []byte
+		caching := !ranged && hlsCache.accepts(filePath, chunk.TotalSize)
+		if caching {
+			body = make([]byte, 0, chunk.TotalSize)
+		}
+
+		// Stream chunks as they arrive, acknowledging each so the camera
+		// keeps sending
+		flusher, _ := w.(http.Flusher)
+		for {
+			if _, err := w.Write(chunk.Data); err != nil {
+				slog.Warn("HLS client went away", "camera_id", cameraID, "file_path", filePath)
+				transfer.cancel()
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
 			}
-			w.Header().Set("Content-Type", contentType)
+			if caching {
+				body = append(body, chunk.Data...)
+			}
+			if chunk.Final {
+				if caching && int64(len(body)) == chunk.TotalSize {
+					hlsCache.put(cameraID, filePath, body)
+				}
+				return
+			}
+			transfer.ack(chunk.Offset + int64(len(chunk.Data)))
+
+			// Headers are sent, a failed transfer can only cut the response short
+			if chunk, err = transfer.next(); err != nil {
+				if !errors.Is(err, rpc.ErrCancelled) {
+					slog.Error("HLS transfer failed", "camera_id", cameraID, "file_path", filePath, "error", err)
+				}
+				transfer.cancel()
+				return
+			}
+		}
+	}
+}
+
+// writeCameraError answers with the status matching a failed request to a camera
+func writeCameraError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, rpc.ErrCancelled):
+		// The client went away, nobody reads the answer
+	case errors.Is(err, rpc.ErrTimeout):
+		http.Error(w, "Timeout waiting for camera response", http.StatusGatewayTimeout)
+	case errors.Is(err, rpc.ErrUnavailable):
+		http.Error(w, "Camera is offline", http.StatusServiceUnavailable)
+	case errors.Is(err, rpc.ErrNotFound):
+		http.Error(w, "File not found", http.StatusNotFound)
+	case errors.Is(err, rpc.ErrOutOfRange):
+		http.Error(w, "Range not satisfiable", http.StatusRequestedRangeNotSatisfiable)
+	case errors.Is(err, rpc.ErrInvalidArgument):
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+	default:
+		http.Error(w, "Failed to communicate with camera", http.StatusBadGateway)
+	}
+}
+
+// serveSealedSegment fetches a whole encrypted segment, caches it sealed and
+// serves it decrypted, including any requested range
+func serveSealedSegment(w http.ResponseWriter, r *http.Request, cameraID, userID, filePath string) {
+	data, err := fetchRecordingFile(r.Context(), cameraID, filePath)
+	if err != nil {
+		writeCameraError(w, err)
+		return
+	}
+	hlsCache.put(cameraID, filePath, data)
+	serveRecordingFile(w, r, cameraID, userID, filePath, data)
+}
 
-			// Set CORS headers to allow cross-origin requests
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+// serveRecordingFile serves a whole recording file, decrypting it first if it
+// is sealed
+func serveRecordingFile(w http.ResponseWriter, r *http.Request, cameraID, userID, filePath string, data []byte) {
+	if !recordcrypt.IsSealed(data) {
+		http.ServeContent(w, r, path.Base(filePath), time.Time{}, bytes.NewReader(data))
+		return
+	}
+
+	data, err := openSegment(r.Context(), cameraID, userID, filePath, data)
+	if errors.Is(err, errRecordingsLocked) {
+		http.Error(w, "Recordings are encrypted, unlock them with your recording key", http.StatusLocked)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to decrypt segment", "camera_id", cameraID, "file_path", filePath, "error", err)
+		http.Error(w, "Failed to decrypt recording", http.StatusBadGateway)
+		return
+	}
 
-			w.Write(data)
+	http.ServeContent(w, r, path.Base(filePath), time.Time{}, bytes.NewReader(data))
+}
 
-		case <-time.After(10 * time.Second):
-			// Timeout waiting for camera response
-			slog.Error("Timeout waiting for HLS response from camera", "camera_id", cameraID)
-			http.Error(w, "Timeout waiting for camera response", http.StatusGatewayTimeout)
+// parseRecordQuery applies the start, end, offset and limit query parameters
+// to a record request. start and end are Unix milliseconds.
+func parseRecordQuery(r *http.Request, request *pb.RecordRequest) error {
+	query := r.URL.Query()
+	for _, param := range []struct {
+		name  string
+		value *int64
+	}{
+		{"start", &request.StartTime},
+		{"end", &request.EndTime},
+	} {
+		if value := query.Get(param.name); value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || parsed < 0 {
+				return fmt.Errorf("invalid %s parameter", param.name)
+			}
+			*param.value = parsed
 		}
 	}
+	for _, param := range []struct {
+		name  string
+		value *int32
+	}{
+		{"offset", &request.Offset},
+		{"limit", &request.Limit},
+	} {
+		if value := query.Get(param.name); value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 32)
+			if err != nil || parsed < 0 {
+				return fmt.Errorf("invalid %s parameter", param.name)
+			}
+			*param.value = int32(parsed)
+		}
+	}
+	if request.StartTime > 0 && request.EndTime > 0 && request.EndTime < request.StartTime {
+		return errors.New("end must not be before start")
+	}
+	return nil
 }
 
-// ServeHLSContent handles requests for HLS content from cameras
+// VideoList queries a camera's recording index, optionally limited to a time
+// range and paginated with offset and limit
 func VideoList(db *gorm.DB) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract camera ID and file path from the URL
@@ -187,60 +274,49 @@ func VideoList(db *gorm.DB) http.HandlerFunc {
 			return
 		}
 
-		// Check if camera is online
+		request := &pb.RecordRequest{}
+		json.NewDecoder(r.Body).Decode(&request)
+		if err := parseRecordQuery(r, request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Offline cameras list what they backed up
 		if !camera.IsOnline {
-			slog.Error("Camera is offline", "camera_id", cameraID)
-			http.Error(w, "Camera is offline", http.StatusServiceUnavailable)
+			if currentBackupStore() == nil {
+				slog.Error("Camera is offline", "camera_id", cameraID)
+				http.Error(w, "Camera is offline", http.StatusServiceUnavailable)
+				return
+			}
+			records, err := listBackupRecordings(db, cameraID, request)
+			if err != nil {
+				slog.Error("Failed to list backed up recordings", "camera_id", cameraID, "error", err)
+				http.Error(w, "Error listing recordings", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(records)
 			return
 		}
-		request := &pb.RecordRequest{}
-		json.NewDecoder(r.Body).Decode(&request)
 
-		// Create a channel to receive the Record response
-		responseChan := make(chan pb.RecordResponse, 1)
-		id := time.Now().Unix()
-		request.Id = id
-
-		channelID := cameraID + strconv.Itoa(int(id))
-		// Register the channel to receive the response
-		recordResponseChannelMutex.Lock()
-		recordResponseChannels[channelID] = responseChan
-		recordResponseChannelMutex.Unlock()
-
-		// Clean up the channel when done
-		defer func() {
-			recordResponseChannelMutex.Lock()
-			delete(recordResponseChannels, channelID)
-			recordResponseChannelMutex.Unlock()
-			close(responseChan)
-		}()
-
-		// Send the request to the camera
-		err := websocket.SendMessageToClient(cameraID, &pb.Message{
-			From: "server",
-			To:   cameraID,
+		reply, err := websocket.Call(r.Context(), cameraID, &pb.Message{
 			DataType: &pb.Message_RecordRequest{
 				RecordRequest: request,
 			},
 		})
-
 		if err != nil {
-			slog.Error("Failed to send Record request to camera", "camera_id", cameraID, "error", err)
-			http.Error(w, "Failed to communicate with camera", http.StatusInternalServerError)
+			slog.Error("Record request to camera failed", "camera_id", cameraID, "error", err)
+			writeCameraError(w, err)
 			return
 		}
-
-		// Wait for the response with a timeout
-		select {
-		case data := <-responseChan:
-			// Set appropriate content type based on file extension
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(data)
-
-		case <-time.After(10 * time.Second):
-			// Timeout waiting for camera response
-			slog.Error("Timeout waiting for HLS response from camera", "camera_id", cameraID)
-			http.Error(w, "Timeout waiting for camera response", http.StatusGatewayTimeout)
+		records := reply.GetRecordResponse()
+		if records == nil {
+			slog.Error("Unexpected reply to record request", "camera_id", cameraID)
+			http.Error(w, "Failed to communicate with camera", http.StatusBadGateway)
+			return
 		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(records)
 	}
 }
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"container/list"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultSegmentCacheBytes bounds the memory cached recording files use
+	defaultSegmentCacheBytes = 256 << 20
+	// segmentCacheTTL is how long segments are cached, they never change once written
+	segmentCacheTTL = 24 * time.Hour
+	// playlistCacheTTL is how long playlists are cached, a live one grows every segment
+	playlistCacheTTL = 2 * time.Second
+)
+
+// hlsCache keeps recording files fetched from cameras so viewers playing or
+// seeking through the same recording don't fetch it over the camera's uplink
+// again. Encrypted segments are cached sealed and decrypted per request.
+var hlsCache = newSegmentCache(defaultSegmentCacheBytes)
+
+// SetSegmentCacheSize sets how many bytes of recording files are cached in
+// memory, 0 disables the cache
+func SetSegmentCacheSize(maxBytes int64) {
+	hlsCache.resize(maxBytes)
+}
+
+// cachedFile is a whole recording file of a camera
+type cachedFile struct {
+	key     string
+	data    []byte
+	expires time.Time
+}
+
+// segmentCache is an LRU cache of recording files bounded by their total size
+type segmentCache struct {
+	mutex    sync.Mutex
+	maxBytes int64
+	size     int64
+	entries  map[string]*list.Element
+	order    *list.List // Most recently used first
+}
+
+func newSegmentCache(maxBytes int64) *segmentCache {
+	return &segmentCache{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// cacheTTL returns how long a recording file may be cached, 0 if it isn't
+func cacheTTL(filePath string) time.Duration {
+	switch path.Ext(filePath) {
+	case ".ts", ".m4s":
+		return segmentCacheTTL
+	case ".m3u8":
+		return playlistCacheTTL
+	default:
+		return 0
+	}
+}
+
+func cacheKey(cameraID, filePath string) string {
+	return cameraID + "/" + path.Clean(filePath)
+}
+
+// accepts reports whether a file of the size would be cached, no single file
+// may take more than an eighth of the cache
+func (c *segmentCache) accepts(filePath string, size int64) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return cacheTTL(filePath) > 0 && size <= c.maxBytes/8
+}
+
+// get returns a cached file that hasn't expired
+func (c *segmentCache) get(cameraID, filePath string) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, exists := c.entries[cacheKey(cameraID, filePath)]
+	if !exists {
+		return nil, false
+	}
+	file := element.Value.(*cachedFile)
+	if time.Now().After(file.expires) {
+		c.remove(element)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return file.data, true
+}
+
+// put caches a whole file, evicting the least recently used files over the limit
+func (c *segmentCache) put(cameraID, filePath string, data []byte) {
+	ttl := cacheTTL(filePath)
+	if ttl == 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	size := int64(len(data))
+	if size > c.maxBytes/8 {
+		return
+	}
+
+	key := cacheKey(cameraID, filePath)
+	if element, exists := c.entries[key]; exists {
+		c.remove(element)
+	}
+	c.entries[key] = c.order.PushFront(&cachedFile{
+		key:     key,
+		data:    data,
+		expires: time.Now().Add(ttl),
+	})
+	c.size += size
+	c.evict()
+}
+
+// purge drops a camera's cached file or directory name, "" drops all of its files
+func (c *segmentCache) purge(cameraID, name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	dir := cameraID
+	if name != "" {
+		dir = cacheKey(cameraID, name)
+	}
+	for key, element := range c.entries {
+		if key == dir || strings.HasPrefix(key, dir+"/") {
+			c.remove(element)
+		}
+	}
+}
+
+func (c *segmentCache) resize(maxBytes int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.maxBytes = max(maxBytes, 0)
+	c.evict()
+}
+
+// evict drops the least recently used files until the cache fits
+func (c *segmentCache) evict() {
+	for c.size > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *segmentCache) remove(element *list.Element) {
+	file := c.order.Remove(element).(*cachedFile)
+	delete(c.entries, file.key)
+	c.size -= int64(len(file.data))
+}
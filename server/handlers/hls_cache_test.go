@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func cached(c *segmentCache, cameraID, filePath string) bool {
+	_, ok := c.get(cameraID, filePath)
+	return ok
+}
+
+func TestSegmentCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	// Files of 10 bytes, the most a cache of 80 takes, eight fit
+	c := newSegmentCache(80)
+	for i := range 8 {
+		c.put("cam", fmt.Sprintf("s1/%d.ts", i), make([]byte, 10))
+	}
+
+	// Reading 0 makes 1 the least recently used
+	if !cached(c, "cam", "s1/0.ts") {
+		t.Fatal("0.ts not cached")
+	}
+	c.put("cam", "s1/8.ts", make([]byte, 10))
+	if cached(c, "cam", "s1/1.ts") {
+		t.Fatal("1.ts kept, it was used least recently")
+	}
+	for _, name := range []string{"s1/0.ts", "s1/2.ts", "s1/8.ts"} {
+		if !cached(c, "cam", name) {
+			t.Fatalf("%s evicted", name)
+		}
+	}
+
+	// Shrinking evicts from the back too, 3 was used least recently now
+	c.resize(60)
+	for i, want := range []bool{true, false, true, false, false, true, true, true, true} {
+		if got := cached(c, "cam", fmt.Sprintf("s1/%d.ts", i)); got != want {
+			t.Errorf("%d.ts cached %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestSegmentCacheSize(t *testing.T) {
+	c := newSegmentCache(800)
+
+	for _, test := range []struct {
+		name string
+		do   func()
+		size int64
+	}{
+		{"put", func() { c.put("cam", "s1/0.ts", make([]byte, 100)) }, 100},
+		{"put another camera", func() { c.put("other", "s1/0.ts", make([]byte, 50)) }, 150},
+		{"replace", func() { c.put("cam", "s1/0.ts", make([]byte, 30)) }, 80},
+		{"same file by another path", func() { c.put("cam", "s1/./0.ts", make([]byte, 40)) }, 90},
+		{"larger than an eighth", func() { c.put("cam", "s1/1.ts", make([]byte, 101)) }, 90},
+		{"not a recording file", func() { c.put("cam", "s1/meta.json", make([]byte, 10)) }, 90},
+		{"playlist", func() { c.put("cam", "s1/playlist.m3u8", make([]byte, 20)) }, 110},
+		{"purge session", func() { c.purge("cam", "s1") }, 50},
+		{"purge camera", func() { c.purge("other", "") }, 0},
+	} {
+		test.do()
+		if c.size != test.size || entryBytes(c) != test.size {
+			t.Errorf("%s: size %d, entries hold %d, want %d", test.name, c.size, entryBytes(c), test.size)
+		}
+	}
+	if len(c.entries) != 0 || c.order.Len() != 0 {
+		t.Fatalf("%d entries left after purging everything", len(c.entries))
+	}
+}
+
+// entryBytes adds up the cached files
+func entryBytes(c *segmentCache) int64 {
+	var total int64
+	for element := c.order.Front(); element != nil; element = element.Next() {
+		total += int64(len(element.Value.(*cachedFile).data))
+	}
+	return total
+}
+
+func TestSegmentCacheTTL(t *testing.T) {
+	c := newSegmentCache(800)
+	c.put("cam", "s1/0.ts", make([]byte, 10))
+	c.put("cam", "s1/playlist.m3u8", make([]byte, 10))
+
+	for _, test := range []struct {
+		filePath string
+		ttl      time.Duration
+	}{
+		{"s1/0.ts", segmentCacheTTL},
+		{"s1/playlist.m3u8", playlistCacheTTL},
+	} {
+		file := c.entries[cacheKey("cam", test.filePath)].Value.(*cachedFile)
+		if left := time.Until(file.expires); left > test.ttl || left < test.ttl-time.Second {
+			t.Errorf("%s expires in %v, want %v", test.filePath, left, test.ttl)
+		}
+	}
+
+	// An expired file is a miss and no longer counted
+	c.entries[cacheKey("cam", "s1/playlist.m3u8")].Value.(*cachedFile).expires = time.Now().Add(-time.Millisecond)
+	if cached(c, "cam", "s1/playlist.m3u8") {
+		t.Fatal("expired playlist served")
+	}
+	if !cached(c, "cam", "s1/0.ts") || c.size != 10 || len(c.entries) != 1 {
+		t.Fatalf("size %d with %d entries after expiry, want 10 and 1", c.size, len(c.entries))
+	}
+}
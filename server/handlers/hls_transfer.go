@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	pb "messages/msgspb"
+	"messages/rpc"
+	"strconv"
+	"strings"
+	"time"
+
+	"server/websocket"
+)
+
+const (
+	// hlsChunkTimeout is the longest wait for the next chunk of a transfer
+	hlsChunkTimeout = 10 * time.Second
+	// hlsChunkBuffer is how many chunks a transfer buffers, at least the
+	// camera's window of unacknowledged chunks
+	hlsChunkBuffer = 16
+	// maxFetchSize bounds files fetched into memory, e.g. sealed segments
+	maxFetchSize = 64 << 20
+)
+
+var (
+	errInvalidRange = errors.New("range not satisfiable")
+	errFileTooLarge = errors.New("file too large to fetch")
+)
+
+// cameraTransfer receives the chunks of one file requested from a camera
+type cameraTransfer struct {
+	ctx      context.Context
+	cameraID string
+	call     *rpc.Call
+}
+
+// startCameraTransfer asks a camera to stream [offset, offset+length) of a
+// recording file. A negative offset counts from the end, length 0 reads to the
+// end. The transfer gives up once ctx is done.
+func startCameraTransfer(ctx context.Context, cameraID, filePath string, offset, length int64) (*cameraTransfer, error) {
+	call, err := websocket.StartCall(cameraID, &pb.Message{
+		DataType: &pb.Message_HlsRequest{
+			HlsRequest: &pb.HLSRequest{
+				FileName: filePath,
+				Offset:   offset,
+				Length:   length,
+			},
+		},
+	}, hlsChunkBuffer)
+	if err != nil {
+		slog.Error("Failed to send HLS request to camera", "camera_id", cameraID, "error", err)
+		return nil, err
+	}
+	return &cameraTransfer{ctx: ctx, cameraID: cameraID, call: call}, nil
+}
+
+// next waits for the next chunk, camera side errors are returned as *rpc.Error
+func (t *cameraTransfer) next() (*pb.HLSResponse, error) {
+	ctx, cancel := context.WithTimeout(t.ctx, hlsChunkTimeout)
+	defer cancel()
+
+	reply, err := t.call.Recv(ctx)
+	if errors.Is(err, rpc.ErrTimeout) {
+		slog.Error("Timeout waiting for HLS chunk from camera", "camera_id", t.cameraID, "request_id", t.call.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	chunk := reply.GetHlsResponse()
+	if chunk == nil {
+		return nil, fmt.Errorf("unexpected reply to HLS request: %T", reply.DataType)
+	}
+	return chunk, nil
+}
+
+// ack tells the camera everything before offset arrived, opening its window
+func (t *cameraTransfer) ack(offset int64) {
+	t.send(&pb.HLSAck{Id: t.call.ID, Offset: offset})
+}
+
+// cancel stops the camera sending the rest of the transfer
+func (t *cameraTransfer) cancel() {
+	t.send(&pb.HLSAck{Id: t.call.ID, Cancel: true})
+}
+
+func (t *cameraTransfer) send(ack *pb.HLSAck) {
+	err := websocket.SendMessageToClient(t.cameraID, &pb.Message{
+		From:     "server",
+		To:       t.cameraID,
+		DataType: &pb.Message_HlsAck{HlsAck: ack},
+	})
+	if err != nil {
+		slog.Error("Failed to send HLS ack", "camera_id", t.cameraID, "error", err)
+	}
+}
+
+// close drops chunks still on their way
+func (t *cameraTransfer) close() {
+	t.call.Close()
+}
+
+// fetchCameraFile transfers a whole file from a camera's recordings into memory
+func fetchCameraFile(ctx context.Context, cameraID string, filePath string) ([]byte, error) {
+	t, err := startCameraTransfer(ctx, cameraID, filePath, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer t.close()
+
+	var data []byte
+	for {
+		chunk, err := t.next()
+		if err != nil {
+			t.cancel()
+			return nil, err
+		}
+		if chunk.TotalSize > maxFetchSize {
+			t.cancel()
+			return nil, errFileTooLarge
+		}
+		if data == nil {
+			data = make([]byte, 0, chunk.TotalSize)
+		}
+		data = append(data, chunk.Data...)
+		if chunk.Final {
+			return data, nil
+		}
+		t.ack(chunk.Offset + int64(len(chunk.Data)))
+	}
+}
+
+// parseRange parses a single range Range header into a camera request
+// offset and length. Multiple ranges aren't supported.
+func parseRange(header string) (offset int64, length int64, ok bool, err error) {
+	if header == "" {
+		return 0, 0, false, nil
+	}
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false, errInvalidRange
+	}
+	startText, endText, found := strings.Cut(spec, "-")
+	if !found {
+		return 0, 0, false, errInvalidRange
+	}
+
+	if startText == "" {
+		// Suffix range, the last n bytes
+		n, err := strconv.ParseInt(endText, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false, errInvalidRange
+		}
+		return -n, 0, true, nil
+	}
+
+	start, err := strconv.ParseInt(startText, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, false, errInvalidRange
+	}
+	if endText == "" {
+		return start, 0, true, nil
+	}
+	end, err := strconv.ParseInt(endText, 10, 64)
+	if err != nil || end < start {
+		return 0, 0, false, errInvalidRange
+	}
+	return start, end - start + 1, true, nil
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	pb "messages/msgspb"
+	"messages/rpc"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"server/websocket"
+)
+
+// logSnapshotTimeout is how long a download waits for the camera to send its buffer
+const logSnapshotTimeout = 15 * time.Second
+
+// logBatchBuffer is how many batches a log request buffers
+const logBatchBuffer = 64
+
+// logEntry is a log record as sent to the browser
+type logEntry struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+	Attrs   string    `json:"attrs,omitempty"`
+}
+
+func newLogEntry(record *pb.LogRecord) logEntry {
+	return logEntry{
+		Time:    time.UnixMilli(record.Time),
+		Level:   slog.Level(record.Level).String(),
+		Message: record.Message,
+		Attrs:   record.Attrs,
+	}
+}
+
+// StreamCameraLogs returns an owned camera's logs. By default the buffered
+// records are downloaded as text, with ?follow=true they are streamed live as
+// server-sent events. ?level=warn filters by level and ?minutes=10 limits how
+// far back the buffer is read.
+func StreamCameraLogs(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		camera, ok := getOwnedCamera(db, w, r)
+		if !ok {
+			return
+		}
+
+		query := r.URL.Query()
+		follow := query.Get("follow") == "true"
+
+		var level slog.Level
+		if value := query.Get("level"); value != "" {
+			if err := level.UnmarshalText([]byte(value)); err != nil {
+				http.Error(w, "Invalid level", http.StatusBadRequest)
+				return
+			}
+		}
+
+		var since int64
+		if value := query.Get("minutes"); value != "" {
+			minutes, err := strconv.Atoi(value)
+			if err != nil || minutes <= 0 {
+				http.Error(w, "Invalid minutes", http.StatusBadRequest)
+				return
+			}
+			since = time.Now().Add(-time.Duration(minutes) * time.Minute).Unix()
+		}
+
+		flusher, canFlush := w.(http.Flusher)
+		if follow && !canFlush {
+			http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+			return
+		}
+
+		if !websocket.IsConnected(camera.ID) {
+			http.Error(w, "Camera is offline", http.StatusConflict)
+			return
+		}
+
+		call, err := websocket.StartCall(camera.ID, &pb.Message{
+			DataType: &pb.Message_LogRequest{
+				LogRequest: &pb.LogRequest{
+					Follow:   follow,
+					Since:    since,
+					MinLevel: int32(level),
+				},
+			},
+		}, logBatchBuffer)
+		if err != nil {
+			slog.Error("Failed to request logs", "camera_id", camera.ID, "error", err)
+			http.Error(w, "Error requesting logs", http.StatusInternalServerError)
+			return
+		}
+		defer call.Close()
+
+		if follow {
+			defer websocket.SendMessageToClient(camera.ID, &pb.Message{
+				From: "server",
+				To:   camera.ID,
+				DataType: &pb.Message_LogRequest{
+					LogRequest: &pb.LogRequest{Id: call.ID, Stop: true},
+				},
+			})
+
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("Connection", "keep-alive")
+			w.WriteHeader(http.StatusOK)
+			flusher.Flush()
+
+			for {
+				batch, err := nextLogBatch(r.Context(), call)
+				if err != nil {
+					if !errors.Is(err, rpc.ErrCancelled) {
+						slog.Warn("Camera log stream ended", "camera_id", camera.ID, "error", err)
+					}
+					return
+				}
+				for _, record := range batch.Records {
+					data, _ := json.Marshal(newLogEntry(record))
+					fmt.Fprintf(w, "data: %s\n\n", data)
+				}
+				flusher.Flush()
+				if batch.Done {
+					return
+				}
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", camera.ID+".log"))
+
+		ctx, cancel := context.WithTimeout(r.Context(), logSnapshotTimeout)
+		defer cancel()
+		for {
+			batch, err := nextLogBatch(ctx, call)
+			if errors.Is(err, rpc.ErrTimeout) {
+				slog.Warn("Timed out waiting for camera logs", "camera_id", camera.ID)
+			}
+			if err != nil {
+				return
+			}
+			for _, record := range batch.Records {
+				entry := newLogEntry(record)
+				fmt.Fprintf(w, "%s %-5s %s %s\n", entry.Time.Format(time.RFC3339Nano), entry.Level, entry.Message, entry.Attrs)
+			}
+			if batch.Done {
+				return
+			}
+		}
+	}
+}
+
+// nextLogBatch waits for the next batch answering a log request
+func nextLogBatch(ctx context.Context, call *rpc.Call) (*pb.LogBatch, error) {
+	reply, err := call.Recv(ctx)
+	if err != nil {
+		return nil, err
+	}
+	batch := reply.GetLogBatch()
+	if batch == nil {
+		return nil, fmt.Errorf("unexpected reply to log request: %T", reply.DataType)
+	}
+	return batch, nil
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"messages/jwtmsg"
+)
+
+// LoadProvisioningKey loads the Ed25519 key used to sign CameraAdd tokens,
+// generating and saving a new one if the file doesn't exist yet
+func LoadProvisioningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return generateProvisioningKey(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provisioning key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("provisioning key is not PEM encoded")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse provisioning key: %w", err)
+	}
+
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("provisioning key is not an Ed25519 key")
+	}
+	return privateKey, nil
+}
+
+func generateProvisioningKey(path string) (ed25519.PrivateKey, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate provisioning key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal provisioning key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to save provisioning key: %w", err)
+	}
+
+	slog.Info("Generated new provisioning key", "path", path)
+	return privateKey, nil
+}
+
+// HandleProvisioningKey serves the public key cameras use to verify CameraAdd tokens
+func HandleProvisioningKey(publicKey ed25519.PublicKey) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		json.NewEncoder(w).Encode(jwtmsg.ProvisioningKey{
+			Algorithm: "EdDSA",
+			PublicKey: base64.StdEncoding.EncodeToString(publicKey),
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	pb "messages/msgspb"
+	"messages/recordcrypt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+
+	"server/middleware"
+	"server/models"
+	"server/websocket"
+)
+
+// recordingUnlockTTL is how long an unlocked recording key is kept in memory
+const recordingUnlockTTL = time.Hour
+
+var errRecordingsLocked = errors.New("recordings are locked")
+
+type unlockedKey struct {
+	private []byte
+	expires time.Time
+}
+
+var (
+	// Recording private keys users unlocked, by user ID. They are never stored.
+	unlockedKeys = make(map[string]unlockedKey)
+	// Unwrapped session data keys, by user ID, camera ID and session
+	sessionKeys       = make(map[string][]byte)
+	recordingKeyMutex sync.Mutex
+)
+
+// RecordingKeyRequest sets the user's recording public key, base64 encoded.
+// Without one the server generates a key pair and returns the private key once.
+type RecordingKeyRequest struct {
+	PublicKey string `json:"publicKey"`
+}
+
+type RecordingKeyResponse struct {
+	PublicKey  string `json:"publicKey"`
+	PrivateKey string `json:"privateKey,omitempty"` // Only when generated, it is not kept
+	KeyID      string `json:"keyId"`
+}
+
+// UnlockRecordingsRequest carries the user's base64 recording private key
+type UnlockRecordingsRequest struct {
+	PrivateKey string `json:"privateKey"`
+}
+
+// SetRecordingKey sets the public key new recordings are encrypted to and
+// sends it to the user's cameras. Sessions recorded under a previous key
+// still need that key to play.
+func SetRecordingKey(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middleware.ContextUserKey).(string)
+
+		var req RecordingKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		var response RecordingKeyResponse
+		var public []byte
+		if req.PublicKey == "" {
+			private, generated, err := recordcrypt.GenerateRecordingKey()
+			if err != nil {
+				slog.Error("Failed to generate recording key", "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			public = generated
+			response.PrivateKey = base64.StdEncoding.EncodeToString(private)
+		} else {
+			decoded, err := base64.StdEncoding.DecodeString(req.PublicKey)
+			if err != nil || len(decoded) != recordcrypt.KeySize {
+				http.Error(w, "Invalid public key", http.StatusBadRequest)
+				return
+			}
+			public = decoded
+		}
+		response.PublicKey = base64.StdEncoding.EncodeToString(public)
+		response.KeyID = recordcrypt.KeyID(public)
+
+		if err := db.Model(&models.User{}).Where("id = ?", userID).Update("recording_key", response.PublicKey).Error; err != nil {
+			slog.Error("Failed to store recording key", "user_id", userID, "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		lockRecordings(userID)
+
+		var cameras []models.Camera
+		if err := db.Where("user_id = ?", userID).Find(&cameras).Error; err != nil {
+			slog.Error("Failed to list cameras", "user_id", userID, "error", err)
+		}
+		for i := range cameras {
+			sendCameraUserConfig(db, &cameras[i])
+		}
+
+		slog.Info("Recording key set", "user_id", userID, "key_id", response.KeyID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// UnlockRecordings keeps the user's recording private key in memory for
+// recordingUnlockTTL so encrypted segments can be played
+func UnlockRecordings(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middleware.ContextUserKey).(string)
+
+		var req UnlockRecordingsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		private, err := base64.StdEncoding.DecodeString(req.PrivateKey)
+		if err != nil {
+			http.Error(w, "Invalid private key", http.StatusBadRequest)
+			return
+		}
+		public, err := recordcrypt.PublicKey(private)
+		if err != nil {
+			http.Error(w, "Invalid private key", http.StatusBadRequest)
+			return
+		}
+
+		var user models.User
+		if err := db.Select("id", "recording_key").Where("id = ?", userID).First(&user).Error; err != nil {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		// Keys of older sessions may differ, only warn when it isn't the current one
+		if user.RecordingKey != base64.StdEncoding.EncodeToString(public) {
+			slog.Warn("Unlocking recordings with a key that isn't the current one", "user_id", userID)
+		}
+
+		expires := time.Now().Add(recordingUnlockTTL)
+		recordingKeyMutex.Lock()
+		unlockedKeys[userID] = unlockedKey{private: private, expires: expires}
+		recordingKeyMutex.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"keyId":     recordcrypt.KeyID(public),
+			"expiresAt": expires,
+		})
+	}
+}
+
+// LockRecordings forgets the user's unlocked key and every data key it opened
+func LockRecordings() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value(middleware.ContextUserKey).(string)
+		lockRecordings(userID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func lockRecordings(userID string) {
+	recordingKeyMutex.Lock()
+	defer recordingKeyMutex.Unlock()
+
+	if unlocked, ok := unlockedKeys[userID]; ok {
+		clear(unlocked.private)
+		delete(unlockedKeys, userID)
+	}
+	for id, key := range sessionKeys {
+		if strings.HasPrefix(id, userID+"/") {
+			clear(key)
+			delete(sessionKeys, id)
+		}
+	}
+}
+
+// openSegment decrypts a sealed segment of a camera with the session data key,
+// unwrapping it with the user's unlocked key on first use
+func openSegment(ctx context.Context, cameraID, userID, filePath string, sealed []byte) ([]byte, error) {
+	name := path.Clean(filePath)
+	session, _, ok := strings.Cut(name, "/")
+	if !ok {
+		return nil, fmt.Errorf("segment %s is not in a session", filePath)
+	}
+	cacheID := userID + "/" + cameraID + "/" + session
+
+	recordingKeyMutex.Lock()
+	dataKey := sessionKeys[cacheID]
+	unlocked, isUnlocked := unlockedKeys[userID]
+	if isUnlocked && time.Now().After(unlocked.expires) {
+		recordingKeyMutex.Unlock()
+		lockRecordings(userID)
+		return nil, errRecordingsLocked
+	}
+	recordingKeyMutex.Unlock()
+
+	if dataKey == nil {
+		if !isUnlocked {
+			return nil, errRecordingsLocked
+		}
+
+		data, err := fetchRecordingFile(ctx, cameraID, path.Join(session, recordcrypt.KeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch session key: %w", err)
+		}
+		var wrapped recordcrypt.WrappedKey
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, fmt.Errorf("failed to decode session key: %w", err)
+		}
+		dataKey, err = recordcrypt.UnwrapKey(unlocked.private, &wrapped)
+		if errors.Is(err, recordcrypt.ErrWrongKey) {
+			return nil, fmt.Errorf("%w: session was recorded with key %s", errRecordingsLocked, wrapped.KeyID)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		recordingKeyMutex.Lock()
+		sessionKeys[cacheID] = dataKey
+		recordingKeyMutex.Unlock()
+	}
+
+	return recordcrypt.Open(dataKey, name, sealed)
+}
+
+// cameraUserConfig returns the config sent to a camera, its stored config
+// with the owner's recording key added
+func cameraUserConfig(db *gorm.DB, camera *models.Camera) (*pb.UserConfig, error) {
+	config := proto.Clone(&camera.Config).(*pb.UserConfig)
+
+	var user models.User
+	if err := db.Select("id", "recording_key").Where("id = ?", camera.UserID).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("failed to load camera owner: %w", err)
+	}
+	if user.RecordingKey != "" {
+		key, err := base64.StdEncoding.DecodeString(user.RecordingKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recording key: %w", err)
+		}
+		config.RecordingKey = key
+	}
+	return config, nil
+}
+
+// sendCameraUserConfig pushes the camera's config if it is connected
+func sendCameraUserConfig(db *gorm.DB, camera *models.Camera) {
+	config, err := cameraUserConfig(db, camera)
+	if err != nil {
+		slog.Error("Failed to build camera config", "camera_id", camera.ID, "error", err)
+		return
+	}
+	err = websocket.SendMessageToClient(camera.ID, &pb.Message{
+		DataType: &pb.Message_UserConfig{
+			UserConfig: config,
+		},
+	})
+	if err != nil {
+		slog.Error("Failed to send config update to camera", "camera_id", camera.ID, "error", err)
+	}
+}
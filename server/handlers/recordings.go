@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	pb "messages/msgspb"
+	"messages/rpc"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"server/websocket"
+)
+
+const (
+	// maxBookmarkName and maxBookmarkNote bound the text of a bookmark
+	maxBookmarkName = 200
+	maxBookmarkNote = 4000
+)
+
+// parseTimeRange reads the start and end query parameters, Unix milliseconds
+func parseTimeRange(r *http.Request) (time.Time, time.Time, error) {
+	var times [2]int64
+	for i, name := range []string{"start", "end"} {
+		value, err := strconv.ParseInt(r.URL.Query().Get(name), 10, 64)
+		if err != nil || value <= 0 {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid %s parameter", name)
+		}
+		times[i] = value
+	}
+	start, end := time.UnixMilli(times[0]), time.UnixMilli(times[1])
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end must be after start")
+	}
+	return start, end, nil
+}
+
+// LockRecordingRange keeps an owned camera's recordings between the start and
+// end query parameters from its retention policy and from deletion
+func LockRecordingRange(db *gorm.DB) http.HandlerFunc {
+	return lockRecordingRange(db, true)
+}
+
+// UnlockRecordingRange lets the retention policy delete an owned camera's
+// recordings between the start and end query parameters again
+func UnlockRecordingRange(db *gorm.DB) http.HandlerFunc {
+	return lockRecordingRange(db, false)
+}
+
+func lockRecordingRange(db *gorm.DB, locked bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		camera, ok := getOwnedCamera(db, w, r)
+		if !ok {
+			return
+		}
+		start, end, err := parseTimeRange(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		reply, err := websocket.Call(r.Context(), camera.ID, &pb.Message{
+			DataType: &pb.Message_LockRangeRequest{
+				LockRangeRequest: &pb.LockRangeRequest{
+					StartTime: start.UnixMilli(),
+					EndTime:   end.UnixMilli(),
+					Locked:    locked,
+				},
+			},
+		})
+		if err != nil {
+			slog.Error("Lock request to camera failed", "camera_id", camera.ID, "error", err)
+			writeCameraError(w, err)
+			return
+		}
+		result := reply.GetRangeResult()
+		if result == nil {
+			slog.Error("Unexpected reply to lock request", "camera_id", camera.ID)
+			http.Error(w, "Failed to communicate with camera", http.StatusBadGateway)
+			return
+		}
+
+		slog.Info("Recordings lock changed", "camera_id", camera.ID, "start", start, "end", end, "locked", locked, "segments", len(result.Files))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// DeleteRecordingRange deletes an owned camera's recordings between the start
+// and end query parameters, on the camera and in its backup. Locked segments
+// and the session being recorded are kept and counted as skipped. Only the
+// camera knows its locks, so nothing is deleted while it is offline.
+func DeleteRecordingRange(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		camera, ok := getOwnedCamera(db, w, r)
+		if !ok {
+			return
+		}
+		start, end, err := parseTimeRange(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		reply, err := websocket.Call(r.Context(), camera.ID, &pb.Message{
+			DataType: &pb.Message_DeleteRangeRequest{
+				DeleteRangeRequest: &pb.DeleteRangeRequest{
+					StartTime: start.UnixMilli(),
+					EndTime:   end.UnixMilli(),
+				},
+			},
+		})
+		if err != nil {
+			slog.Error("Delete request to camera failed", "camera_id", camera.ID, "error", err)
+			writeCameraError(w, err)
+			return
+		}
+		result := reply.GetRangeResult()
+		if result == nil {
+			slog.Error("Unexpected reply to delete request", "camera_id", camera.ID)
+			http.Error(w, "Failed to communicate with camera", http.StatusBadGateway)
+			return
+		}
+
+		// Trimmed playlists and deleted segments must not be served from cache
+		sessions := make(map[string]bool)
+		for _, file := range result.Files {
+			session, _, _ := strings.Cut(file, "/")
+			if !sessions[session] {
+				sessions[session] = true
+				hlsCache.purge(camera.ID, session)
+			}
+		}
+		// The camera already deleted them, finish even if the client leaves
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), backupDeleteTimeout)
+		defer cancel()
+		deleteBackupFiles(ctx, db, currentBackupStore(), camera.ID, result.Files)
+
+		slog.Info("Recordings deleted", "camera_id", camera.ID, "start", start, "end", end, "segments", len(result.Files), "skipped", result.Skipped)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// ListBookmarks searches an owned camera's bookmarks, newest first. q holds
+// words that must all appear in the name or note, start and end limit the
+// time and limit the number returned.
+func ListBookmarks(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		camera, ok := getOwnedCamera(db, w, r)
+		if !ok {
+			return
+		}
+
+		query := &pb.BookmarkQuery{Text: r.URL.Query().Get("q")}
+		record := &pb.RecordRequest{}
+		if err := parseRecordQuery(r, record); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		query.StartTime, query.EndTime, query.Limit = record.StartTime, record.EndTime, record.Limit
+
+		callBookmarks(w, r, camera.ID, &pb.Message{
+			DataType: &pb.Message_BookmarkQuery{BookmarkQuery: query},
+		})
+	}
+}
+
+// CreateBookmark adds a bookmark to an owned camera's recordings, the body is
+// the bookmark without its id
+func CreateBookmark(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		camera, ok := getOwnedCamera(db, w, r)
+		if !ok {
+			return
+		}
+		bookmark, ok := decodeBookmark(w, r)
+		if !ok {
+			return
+		}
+		bookmark.Id = uuid.NewString()
+		bookmark.CreatedAt = time.Now().UnixMilli()
+
+		callBookmarks(w, r, camera.ID, &pb.Message{
+			DataType: &pb.Message_BookmarkUpdate{BookmarkUpdate: &pb.BookmarkUpdate{Bookmark: bookmark}},
+		})
+	}
+}
+
+// UpdateBookmark replaces the name, note and time of a bookmark
+func UpdateBookmark(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		camera, ok := getOwnedCamera(db, w, r)
+		if !ok {
+			return
+		}
+		bookmark, ok := decodeBookmark(w, r)
+		if !ok {
+			return
+		}
+		bookmark.Id = r.PathValue("bookmark")
+		bookmark.CreatedAt = 0 // The camera keeps the original
+
+		callBookmarks(w, r, camera.ID, &pb.Message{
+			DataType: &pb.Message_BookmarkUpdate{BookmarkUpdate: &pb.BookmarkUpdate{Bookmark: bookmark}},
+		})
+	}
+}
+
+// DeleteBookmark removes a bookmark, the recordings stay
+func DeleteBookmark(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		camera, ok := getOwnedCamera(db, w, r)
+		if !ok {
+			return
+		}
+		_, err := websocket.Call(r.Context(), camera.ID, &pb.Message{
+			DataType: &pb.Message_BookmarkUpdate{BookmarkUpdate: &pb.BookmarkUpdate{
+				Bookmark: &pb.Bookmark{Id: r.PathValue("bookmark")},
+				Delete:   true,
+			}},
+		})
+		if errors.Is(err, rpc.ErrNotFound) {
+			http.Error(w, "Bookmark not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.Error("Bookmark request to camera failed", "camera_id", camera.ID, "error", err)
+			writeCameraError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// decodeBookmark reads and validates a bookmark from the request body
+func decodeBookmark(w http.ResponseWriter, r *http.Request) (*pb.Bookmark, bool) {
+	bookmark := &pb.Bookmark{}
+	if err := json.NewDecoder(r.Body).Decode(bookmark); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+	bookmark.Name = strings.TrimSpace(bookmark.Name)
+	switch {
+	case bookmark.Name == "" || len(bookmark.Name) > maxBookmarkName:
+		http.Error(w, fmt.Sprintf("Name must be 1 to %d characters", maxBookmarkName), http.StatusBadRequest)
+	case len(bookmark.Note) > maxBookmarkNote:
+		http.Error(w, fmt.Sprintf("Note must be at most %d characters", maxBookmarkNote), http.StatusBadRequest)
+	case bookmark.StartTime <= 0 || bookmark.EndTime != 0 && bookmark.EndTime < bookmark.StartTime:
+		http.Error(w, "Invalid bookmark time", http.StatusBadRequest)
+	default:
+		return bookmark, true
+	}
+	return nil, false
+}
+
+// callBookmarks sends a bookmark request to the camera and answers with the
+// bookmark list it replies
+func callBookmarks(w http.ResponseWriter, r *http.Request, cameraID string, msg *pb.Message) {
+	reply, err := websocket.Call(r.Context(), cameraID, msg)
+	if err != nil {
+		slog.Error("Bookmark request to camera failed", "camera_id", cameraID, "error", err)
+		writeCameraError(w, err)
+		return
+	}
+	list := reply.GetBookmarkList()
+	if list == nil {
+		slog.Error("Unexpected reply to bookmark request", "camera_id", cameraID)
+		http.Error(w, "Failed to communicate with camera", http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"messages/jwtmsg"
+	pb "messages/msgspb"
+	"server/models"
+	"server/websocket"
+)
+
+// releaseBinaryPrefix names release binaries as <dir>/<version>/sudocam-<arch>,
+// each with its signature in sudocam-<arch>.sig
+const releaseBinaryPrefix = "sudocam-"
+
+// RolloutRequest represents the request body for the camera update endpoint
+type RolloutRequest struct {
+	Version string `json:"version"` // Latest release for the camera's arch when empty
+}
+
+// LoadReleases scans the release directory for builds signed offline by
+// release.sh. The server can't sign releases itself, it only passes the
+// signature on to cameras, which check it against the key they were built with.
+func LoadReleases(db *gorm.DB, dir string) error {
+	versions, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		slog.Info("No release directory, OTA updates disabled", "path", dir)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read release directory: %w", err)
+	}
+
+	for _, version := range versions {
+		if !version.IsDir() {
+			continue
+		}
+
+		files, err := os.ReadDir(filepath.Join(dir, version.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read release %s: %w", version.Name(), err)
+		}
+
+		for _, file := range files {
+			arch, ok := strings.CutPrefix(file.Name(), releaseBinaryPrefix)
+			if !ok || file.IsDir() || arch == "" || strings.HasSuffix(arch, jwtmsg.ReleaseSignatureSuffix) {
+				continue
+			}
+
+			release, err := loadRelease(filepath.Join(dir, version.Name(), file.Name()), version.Name(), arch)
+			if errors.Is(err, os.ErrNotExist) {
+				slog.Warn("Skipping unsigned release", "version", version.Name(), "arch", arch)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			// Keep the time a release was first published, rollouts pick the
+			// newest one by it
+			err = db.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "version"}, {Name: "arch"}},
+				DoUpdates: clause.AssignmentColumns([]string{"sha256", "size", "signature", "path"}),
+			}).Create(release).Error
+			if err != nil {
+				return fmt.Errorf("failed to store release %s/%s: %w", release.Version, release.Arch, err)
+			}
+			slog.Info("Release loaded", "version", release.Version, "arch", release.Arch, "size", release.Size)
+		}
+	}
+	return nil
+}
+
+// loadRelease hashes a release binary and reads its signature
+func loadRelease(path, version, arch string) (*models.Release, error) {
+	encoded, err := os.ReadFile(path + jwtmsg.ReleaseSignatureSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release signature: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("invalid signature for release %s/%s", version, arch)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open release: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat release: %w", err)
+	}
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash release: %w", err)
+	}
+
+	return &models.Release{
+		Version:   version,
+		Arch:      arch,
+		SHA256:    hex.EncodeToString(hash.Sum(nil)),
+		Size:      size,
+		Signature: signature,
+		Path:      path,
+		CreatedAt: info.ModTime(),
+	}, nil
+}
+
+// releaseMessage builds the message telling a camera to install the release
+func releaseMessage(cameraID string, release *models.Release) *pb.Message {
+	return &pb.Message{
+		From: "server",
+		To:   cameraID,
+		DataType: &pb.Message_AgentRelease{
+			AgentRelease: &pb.AgentRelease{
+				Version:   release.Version,
+				Arch:      release.Arch,
+				Url:       jwtmsg.ReleaseDownloadPath(release.Version, release.Arch),
+				Sha256:    release.SHA256,
+				Size:      release.Size,
+				Signature: release.Signature,
+			},
+		},
+	}
+}
+
+// ListReleases returns the hosted agent releases, newest first
+func ListReleases(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var releases []models.Release
+		if err := db.Order("created_at desc").Find(&releases).Error; err != nil {
+			slog.Error("Failed to list releases", "error", err)
+			http.Error(w, "Error listing releases", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(releases)
+	}
+}
+
+// DownloadRelease serves a release binary to cameras
+func DownloadRelease(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var release models.Release
+		err := db.Where("version = ? AND arch = ?", r.PathValue("version"), r.PathValue("arch")).First(&release).Error
+		if err != nil {
+			http.Error(w, "Release not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeFile(w, r, release.Path)
+	}
+}
+
+// RolloutUpdate targets an owned camera at a release and notifies it if it's online
+func RolloutUpdate(db *gorm.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req RolloutRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		camera, ok := getOwnedCamera(db, w, r)
+		if !ok {
+			return
+		}
+
+		if camera.Arch == "" {
+			http.Error(w, "Camera hasn't reported its agent version yet", http.StatusConflict)
+			return
+		}
+
+		query := db.Where("arch = ?", camera.Arch)
+		if req.Version != "" {
+			query = query.Where("version = ?", req.Version)
+		}
+		var release models.Release
+		if err := query.Order("created_at desc").First(&release).Error; err != nil {
+			http.Error(w, "Release not found", http.StatusNotFound)
+			return
+		}
+
+		err := db.Model(camera).Updates(map[string]interface{}{
+			"target_version": release.Version,
+			"update_status":  pb.UpdateStatus_UPDATE_STATUS_PENDING,
+			"update_error":   "",
+		}).Error
+		if err != nil {
+			slog.Error("Failed to start rollout", "camera_id", camera.ID, "error", err)
+			http.Error(w, "Error starting update", http.StatusInternalServerError)
+			return
+		}
+
+		if websocket.IsConnected(camera.ID) {
+			if err := websocket.SendMessageToClient(camera.ID, releaseMessage(camera.ID, &release)); err != nil {
+				slog.Error("Failed to send release", "camera_id", camera.ID, "error", err)
+			}
+		}
+
+		slog.Info("Rollout started", "camera_id", camera.ID, "version", release.Version)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{
+			"status":  "success",
+			"message": "Update to " + release.Version + " started",
+		})
+	}
+}
+
+// RegisterAgentStatusHandler tracks the agent version and update progress
+// reported by cameras, and resends a pending rollout when a camera connects
+func RegisterAgentStatusHandler(db *gorm.DB) {
+	websocket.RegisterMessageHandler("agentStatus", func(msg *pb.Message) {
+		status := msg.GetAgentStatus()
+		if status == nil {
+			return
+		}
+
+		var camera models.Camera
+		if err := db.Where("id = ?", msg.From).First(&camera).Error; err != nil {
+			slog.Error("Agent status from unknown camera", "camera_id", msg.From)
+			return
+		}
+
+		updateStatus := camera.UpdateStatus
+		updateError := camera.UpdateError
+		switch {
+		case camera.TargetVersion != "" && status.Version == camera.TargetVersion:
+			updateStatus = pb.UpdateStatus_UPDATE_STATUS_SUCCEEDED
+			updateError = ""
+		case status.UpdateStatus != pb.UpdateStatus_UPDATE_STATUS_UNSPECIFIED && status.TargetVersion == camera.TargetVersion:
+			updateStatus = status.UpdateStatus
+			updateError = status.Error
+		}
+
+		err := db.Model(&camera).Updates(map[string]interface{}{
+			"firmware_version": status.Version,
+			"arch":             status.Arch,
+			"update_status":    updateStatus,
+			"update_error":     updateError,
+		}).Error
+		if err != nil {
+			slog.Error("Failed to store agent status", "camera_id", camera.ID, "error", err)
+			return
+		}
+
+		slog.Info("Agent status",
+			"camera_id", camera.ID,
+			"version", status.Version,
+			"update_status", updateStatus.String(),
+			"target_version", camera.TargetVersion)
+		websocket.SendRefreshToClient(camera.UserID)
+
+		// A camera that was offline when the rollout started gets it now
+		if updateStatus != pb.UpdateStatus_UPDATE_STATUS_PENDING {
+			return
+		}
+		var release models.Release
+		if err := db.Where("version = ? AND arch = ?", camera.TargetVersion, status.Arch).First(&release).Error; err != nil {
+			slog.Error("Pending release not found", "camera_id", camera.ID, "version", camera.TargetVersion)
+			return
+		}
+		if err := websocket.SendMessageToClient(camera.ID, releaseMessage(camera.ID, &release)); err != nil {
+			slog.Error("Failed to send release", "camera_id", camera.ID, "error", err)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"messages/jwtmsg"
+	"server/models"
+)
+
+// writeRelease writes a release binary, signed when key is set
+func writeRelease(t *testing.T, dir, version, arch string, key ed25519.PrivateKey, modTime time.Time) {
+	t.Helper()
+	path := filepath.Join(dir, version, releaseBinaryPrefix+arch)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	binary := []byte("agent " + version)
+	if err := os.WriteFile(path, binary, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if key == nil {
+		return
+	}
+	digest := sha256.Sum256(binary)
+	signature := ed25519.Sign(key, jwtmsg.ReleaseSigningPayload(version, arch, hex.EncodeToString(digest[:]), int64(len(binary))))
+	if err := os.WriteFile(path+jwtmsg.ReleaseSignatureSuffix, []byte(base64.StdEncoding.EncodeToString(signature)+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadReleases(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&models.Release{}); err != nil {
+		t.Fatal(err)
+	}
+	publicKey, key, _ := ed25519.GenerateKey(rand.Reader)
+
+	dir := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	writeRelease(t, dir, "v1.0.0", "arm", key, old)
+	writeRelease(t, dir, "v1.1.0", "arm", key, old.Add(time.Hour))
+	writeRelease(t, dir, "v1.2.0", "arm", nil, old.Add(2*time.Hour))
+
+	if err := LoadReleases(db, dir); err != nil {
+		t.Fatal(err)
+	}
+	// Loading again, as on every start, must not make old releases look new
+	// even when their files were touched
+	now := time.Now()
+	os.Chtimes(filepath.Join(dir, "v1.0.0", releaseBinaryPrefix+"arm"), now, now)
+	if err := LoadReleases(db, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	var releases []models.Release
+	if err := db.Order("created_at desc").Find(&releases).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(releases) != 2 {
+		t.Fatalf("loaded %d releases, want 2 without the unsigned one", len(releases))
+	}
+	if releases[0].Version != "v1.1.0" || !releases[1].CreatedAt.Equal(old) {
+		t.Fatalf("unexpected order: %s %v, %s %v", releases[0].Version, releases[0].CreatedAt, releases[1].Version, releases[1].CreatedAt)
+	}
+
+	release := releases[0]
+	payload := jwtmsg.ReleaseSigningPayload(release.Version, release.Arch, release.SHA256, release.Size)
+	if !ed25519.Verify(publicKey, payload, release.Signature) {
+		t.Fatal("stored signature doesn't verify")
+	}
+}
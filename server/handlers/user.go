@@ -15,6 +15,8 @@ type SafeUserResponse struct {
 	Id    string `json:"id"`
 	Name  string `json:"name"`
 	Email string `json:"email"`
+	// RecordingKey is the public key recordings are encrypted to
+	RecordingKey string `json:"recordingKey,omitempty"`
 	// Add other non-sensitive fields as needed
 }
 
@@ -39,6 +41,8 @@ func Me(db *gorm.DB) http.HandlerFunc {
 			Id:    user.ID,
 			Name:  user.Name,
 			Email: user.Email,
+
+			RecordingKey: user.RecordingKey,
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
 	"log/slog"
+	"messages/jwtmsg"
+	"messages/s3"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	"gorm.io/driver/sqlite"
@@ -14,6 +21,7 @@ import (
 	"server/handlers"
 	"server/middleware"
 	"server/models"
+	"server/storage"
 	"server/websocket"
 )
 
@@ -23,7 +31,7 @@ func setupDatabase() (*gorm.DB, error) {
 		return nil, err
 	}
 
-	err = db.AutoMigrate(&models.User{}, &models.Camera{})
+	err = db.AutoMigrate(&models.User{}, &models.Camera{}, &models.PendingClaim{}, &models.CameraCommand{}, &models.PendingDeregistration{}, &models.Release{}, &models.TelemetrySample{}, &models.DiagnosticBundle{}, &models.BackupSegment{})
 	if err != nil {
 		return nil, err
 	}
@@ -31,9 +39,43 @@ func setupDatabase() (*gorm.DB, error) {
 	return db, nil
 }
 
-func setupRoutes(db *gorm.DB) {
+func setupRoutes(db *gorm.DB, provisioningKey ed25519.PrivateKey) {
 	jwtKey := []byte(os.Getenv("JWT_SECRET"))
 
+	diagnosticsDir := os.Getenv("DIAGNOSTICS_DIR")
+	if diagnosticsDir == "" {
+		diagnosticsDir = "data/diagnostics"
+	}
+
+	exportDir := os.Getenv("EXPORT_DIR")
+	if exportDir == "" {
+		exportDir = "data/exports"
+	}
+
+	if store, err := backupStore(); err != nil {
+		slog.Error("Failed to set up backup storage, backups disabled", "error", err)
+	} else {
+		handlers.SetBackupStore(store)
+	}
+
+	if value := os.Getenv("BACKUP_RETENTION_DAYS"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			slog.Error("Invalid BACKUP_RETENTION_DAYS", "value", value, "error", err)
+		} else {
+			handlers.StartBackupRetention(db, time.Duration(days)*24*time.Hour)
+		}
+	}
+
+	if value := os.Getenv("HLS_CACHE_BYTES"); value != "" {
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			slog.Error("Invalid HLS_CACHE_BYTES", "value", value, "error", err)
+		} else {
+			handlers.SetSegmentCacheSize(size)
+		}
+	}
+
 	// Auth routes
 	http.HandleFunc("/api/signup", handlers.HandleSignup(db))
 	http.HandleFunc("/api/login", handlers.HandleLogin(db, jwtKey))
@@ -43,9 +85,25 @@ func setupRoutes(db *gorm.DB) {
 	// User routes
 	http.HandleFunc("/api/users/cameras", middleware.AuthMiddleware(handlers.UsersCameras(db), false))
 	http.HandleFunc("/api/users/me", middleware.AuthMiddleware(handlers.Me(db), false))
+	http.HandleFunc("PUT /api/users/recording-key", middleware.AuthMiddleware(handlers.SetRecordingKey(db), false))
+	http.HandleFunc("POST /api/users/recording-key/unlock", middleware.AuthMiddleware(handlers.UnlockRecordings(db), false))
+	http.HandleFunc("DELETE /api/users/recording-key/unlock", middleware.AuthMiddleware(handlers.LockRecordings(), false))
 	// Camera routes
-	http.HandleFunc("/api/cameras/generate", middleware.AuthMiddleware(handlers.HandleGenerateCamera(jwtKey), false))
-	http.HandleFunc("/api/cameras/register", handlers.HandleRegisterCamera(db, jwtKey))
+	http.HandleFunc("/api/cameras/generate", middleware.AuthMiddleware(handlers.HandleGenerateCamera(provisioningKey), false))
+	http.HandleFunc("POST /api/cameras/claim", middleware.AuthMiddleware(handlers.HandleCreateClaim(db), false))
+	http.HandleFunc("/api/cameras/register", middleware.RateLimit(handlers.HandleRegisterCamera(db, jwtKey, provisioningKey.Public().(ed25519.PublicKey)), 10, time.Minute))
+	http.HandleFunc("POST /api/cameras/token/refresh", handlers.HandleRefreshCameraToken(db, jwtKey))
+	http.HandleFunc("POST "+jwtmsg.DeregistrationAckPath, handlers.HandleAcknowledgeDeregistration(db))
+	http.HandleFunc("POST "+jwtmsg.UnregisterPath, handlers.HandleUnregisterCamera(db))
+	http.HandleFunc("POST /api/cameras/{id}/revoke", middleware.AuthMiddleware(handlers.RevokeCamera(db), false))
+	http.HandleFunc("POST /api/cameras/{id}/commands", middleware.AuthMiddleware(handlers.SendCameraCommand(db), false))
+	http.HandleFunc("GET /api/cameras/{id}/commands", middleware.AuthMiddleware(handlers.ListCameraCommands(db), false))
+	http.HandleFunc("POST /api/cameras/{id}/update", middleware.AuthMiddleware(handlers.RolloutUpdate(db), false))
+	http.HandleFunc("GET /api/cameras/{id}/health", middleware.AuthMiddleware(handlers.GetCameraHealth(db), false))
+	http.HandleFunc("GET /api/cameras/{id}/logs", middleware.AuthMiddleware(handlers.StreamCameraLogs(db), false))
+	http.HandleFunc("PUT /api/cameras/{id}/diagnostics/{bundle}/chunks", middleware.AuthMiddleware(handlers.UploadDiagnosticChunk(db, diagnosticsDir), true))
+	http.HandleFunc("GET /api/cameras/{id}/diagnostics", middleware.AuthMiddleware(handlers.ListDiagnosticBundles(db), false))
+	http.HandleFunc("GET /api/cameras/{id}/diagnostics/{bundle}", middleware.AuthMiddleware(handlers.DownloadDiagnosticBundle(db), false))
 	http.HandleFunc("/api/cameras/delete", middleware.AuthMiddleware(handlers.DeleteCamera(db), false))
 	http.HandleFunc("/api/cameras/update", middleware.AuthMiddleware(handlers.UpdateCamera(db), false))
 	http.HandleFunc("GET /api/cameras/{id}/config", middleware.AuthMiddleware(handlers.GetCameraConfig(db), true))
@@ -53,6 +111,24 @@ func setupRoutes(db *gorm.DB) {
 	// HLS video content route
 	http.HandleFunc("GET /api/cameras/{id}/video/{filepath...}", middleware.AuthMiddleware(handlers.ServeHLSContent(db), false))
 	http.HandleFunc("GET /api/cameras/{id}/list", middleware.AuthMiddleware(handlers.VideoList(db), false))
+	http.HandleFunc("PUT /api/cameras/{id}/backup/{filepath...}", middleware.AuthMiddleware(handlers.UploadBackup(db), true))
+	http.HandleFunc("GET /api/cameras/{id}/export", middleware.AuthMiddleware(handlers.ExportClip(db, exportDir), false))
+	http.HandleFunc("GET /api/cameras/{id}/exports/{job}", middleware.AuthMiddleware(handlers.GetExportJob(db), false))
+	http.HandleFunc("GET /api/cameras/{id}/exports/{job}/download", middleware.AuthMiddleware(handlers.DownloadExport(db), false))
+	http.HandleFunc("POST /api/cameras/{id}/recordings/lock", middleware.AuthMiddleware(handlers.LockRecordingRange(db), false))
+	http.HandleFunc("POST /api/cameras/{id}/recordings/unlock", middleware.AuthMiddleware(handlers.UnlockRecordingRange(db), false))
+	http.HandleFunc("DELETE /api/cameras/{id}/recordings", middleware.AuthMiddleware(handlers.DeleteRecordingRange(db), false))
+	http.HandleFunc("GET /api/cameras/{id}/bookmarks", middleware.AuthMiddleware(handlers.ListBookmarks(db), false))
+	http.HandleFunc("POST /api/cameras/{id}/bookmarks", middleware.AuthMiddleware(handlers.CreateBookmark(db), false))
+	http.HandleFunc("PUT /api/cameras/{id}/bookmarks/{bookmark}", middleware.AuthMiddleware(handlers.UpdateBookmark(db), false))
+	http.HandleFunc("DELETE /api/cameras/{id}/bookmarks/{bookmark}", middleware.AuthMiddleware(handlers.DeleteBookmark(db), false))
+
+	// Agent releases
+	http.HandleFunc("GET /api/releases", middleware.AuthMiddleware(handlers.ListReleases(db), false))
+	http.HandleFunc("GET /api/releases/{version}/{arch}/download", middleware.AuthMiddleware(handlers.DownloadRelease(db), true))
+
+	// Public key cameras use to verify provisioning tokens
+	http.HandleFunc("GET "+jwtmsg.ProvisioningKeyPath, handlers.HandleProvisioningKey(provisioningKey.Public().(ed25519.PublicKey)))
 
 	// WebSocket route
 	http.HandleFunc("/api/ws", websocket.HandleWebSocket(db))
@@ -64,6 +140,41 @@ func setupRoutes(db *gorm.DB) {
 	http.Handle("/", handlers.SpaHandlerWithBasePath("ui/dist", "index.html", "/"))
 }
 
+// backupStore creates the storage recordings are backed up to, set by
+// BACKUP_STORAGE: "local" (the default) keeps them in BACKUP_DIR, "s3" in an
+// S3-compatible bucket and "off" disables backups
+func backupStore() (storage.Store, error) {
+	switch kind := os.Getenv("BACKUP_STORAGE"); kind {
+	case "", "local":
+		dir := os.Getenv("BACKUP_DIR")
+		if dir == "" {
+			dir = "data/backups"
+		}
+		return storage.NewLocal(dir)
+	case "s3":
+		store, err := storage.NewS3(s3.Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := store.Check(ctx); err != nil {
+			slog.Warn("Backup bucket is not reachable yet", "error", err)
+		}
+		return store, nil
+	case "off":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown BACKUP_STORAGE %q", kind)
+	}
+}
+
 func startServer() {
 	// Create shutdown channel
 	shutdownChan := make(chan os.Signal, 1)
@@ -94,15 +205,50 @@ func main() {
 	jwtKey := []byte(os.Getenv("JWT_SECRET"))
 	middleware.SetJWTKey(jwtKey)
 
+	// Setup tokens and claim codes tell cameras where to register, without
+	// it they would point nowhere
+	if os.Getenv("SERVER_URL") == "" {
+		slog.Error("SERVER_URL must be set to the address cameras reach the server at")
+		os.Exit(1)
+	}
+
+	if err := middleware.SetTrustedProxies(os.Getenv("TRUSTED_PROXIES")); err != nil {
+		slog.Error("Invalid TRUSTED_PROXIES", "error", err)
+		os.Exit(1)
+	}
+
 	db, err := setupDatabase()
 	if err != nil {
 		slog.Error("Failed to setup database", "error", err)
 		os.Exit(1)
 	}
 
-	// Register the HLS response handler
-	handlers.RegisterHLSResponseHandler()
+	middleware.SetCameraValidator(handlers.CameraValidator(db))
+
+	keyPath := os.Getenv("PROVISIONING_KEY_FILE")
+	if keyPath == "" {
+		keyPath = "data/provisioning_ed25519.pem"
+	}
+	provisioningKey, err := handlers.LoadProvisioningKey(keyPath)
+	if err != nil {
+		slog.Error("Failed to load provisioning key", "error", err)
+		os.Exit(1)
+	}
+
+	releaseDir := os.Getenv("RELEASE_DIR")
+	if releaseDir == "" {
+		releaseDir = "data/releases"
+	}
+	if err := handlers.LoadReleases(db, releaseDir); err != nil {
+		slog.Error("Failed to load releases", "error", err)
+		os.Exit(1)
+	}
+
+	// Register handlers for messages cameras send on their own
+	handlers.RegisterCommandResultHandler(db)
+	handlers.RegisterAgentStatusHandler(db)
+	handlers.RegisterTelemetryHandler(db)
 
-	setupRoutes(db)
+	setupRoutes(db, provisioningKey)
 	startServer()
 }
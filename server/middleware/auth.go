@@ -14,10 +14,18 @@ import (
 
 var jwtKey []byte
 
+// cameraValidator rejects camera tokens that are still signed but no longer valid
+var cameraValidator func(cameraID string) error
+
 func SetJWTKey(key []byte) {
 	jwtKey = key
 }
 
+// SetCameraValidator sets the check run for every authenticated camera request
+func SetCameraValidator(validator func(cameraID string) error) {
+	cameraValidator = validator
+}
+
 type ContextKey string
 
 const ContextUserKey ContextKey = "UserID"
@@ -42,6 +50,13 @@ func AuthMiddleware(next http.HandlerFunc, cameraAllowed bool) http.HandlerFunc
 			slog.Error("Invalid Entity Type", "entity_type", claims.EntityType)
 			return
 		}
+		if claims.EntityType == jwtmsg.EntityTypeCamera && cameraValidator != nil {
+			if err := cameraValidator(claims.EntityID); err != nil {
+				http.Error(w, "Unauthorized Camera", http.StatusUnauthorized)
+				slog.Error("Rejected camera token", "camera_id", claims.EntityID, "error", err)
+				return
+			}
+		}
 		ctx := context.WithValue(r.Context(), ContextUserKey, claims.EntityID)
 		ctx = context.WithValue(ctx, ContextClaimKey, *claims)
 
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// trustedProxies are the reverse proxies whose X-Forwarded-For is believed
+var trustedProxies []*net.IPNet
+
+// SetTrustedProxies sets the reverse proxies, a comma separated list of IPs
+// or CIDR ranges, whose X-Forwarded-For header names the real client
+func SetTrustedProxies(list string) error {
+	var proxies []*net.IPNet
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return fmt.Errorf("invalid proxy address %q", entry)
+			}
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return fmt.Errorf("invalid proxy range %q: %w", entry, err)
+		}
+		proxies = append(proxies, network)
+	}
+	trustedProxies = proxies
+	return nil
+}
+
+func isTrustedProxy(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the address of the client. Behind trusted proxies that is
+// the last X-Forwarded-For entry not added by one of them, clients can put
+// anything in front of it.
+func clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if !isTrustedProxy(ip) {
+		return ip
+	}
+
+	var forwarded []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(header, ",")...)
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if hop == "" {
+			continue
+		}
+		ip = hop
+		if !isTrustedProxy(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// RateLimit allows each client IP at most limit requests per window, so
+// short codes can't be brute forced through the wrapped endpoint
+func RateLimit(next http.HandlerFunc, limit int, window time.Duration) http.HandlerFunc {
+	var mutex sync.Mutex
+	windows := make(map[string]*rateWindow)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip := clientIP(r)
+
+		now := time.Now()
+		mutex.Lock()
+		current, ok := windows[ip]
+		if !ok || now.Sub(current.start) >= window {
+			// Sweep stale entries while we hold the lock
+			for key, entry := range windows {
+				if now.Sub(entry.start) >= window {
+					delete(windows, key)
+				}
+			}
+			current = &rateWindow{start: now}
+			windows[ip] = current
+		}
+		current.count++
+		allowed := current.count <= limit
+		retryAfter := window - now.Sub(current.start)
+		mutex.Unlock()
+
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
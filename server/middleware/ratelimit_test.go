@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientIP(t *testing.T) {
+	if err := SetTrustedProxies("10.0.0.1, 192.168.0.0/16"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetTrustedProxies("")
+
+	tests := []struct {
+		remote    string
+		forwarded string
+		want      string
+	}{
+		{"203.0.113.7:1234", "", "203.0.113.7"},
+		// Only trusted proxies may name the client
+		{"203.0.113.7:1234", "198.51.100.1", "203.0.113.7"},
+		{"10.0.0.1:1234", "198.51.100.1", "198.51.100.1"},
+		// A client can't hide behind a made up first entry
+		{"10.0.0.1:1234", "1.2.3.4, 198.51.100.1", "198.51.100.1"},
+		// Chained proxies are skipped
+		{"10.0.0.1:1234", "198.51.100.1, 192.168.1.5", "198.51.100.1"},
+		{"10.0.0.1:1234", "", "10.0.0.1"},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = test.remote
+		if test.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", test.forwarded)
+		}
+		if got := clientIP(r); got != test.want {
+			t.Errorf("clientIP(%s, %q) = %s, want %s", test.remote, test.forwarded, got, test.want)
+		}
+	}
+}
+
+func TestRateLimitBehindProxy(t *testing.T) {
+	if err := SetTrustedProxies("10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetTrustedProxies("")
+
+	handler := RateLimit(func(w http.ResponseWriter, r *http.Request) {}, 1, time.Minute)
+	request := func(client string) int {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.RemoteAddr = "10.0.0.1:443"
+		r.Header.Set("X-Forwarded-For", client)
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w.Code
+	}
+
+	if code := request("198.51.100.1"); code != http.StatusOK {
+		t.Fatalf("first request: status %d", code)
+	}
+	if code := request("198.51.100.1"); code != http.StatusTooManyRequests {
+		t.Fatalf("second request: status %d, want 429", code)
+	}
+	// Other clients behind the same proxy have their own limit
+	if code := request("198.51.100.2"); code != http.StatusOK {
+		t.Fatalf("other client: status %d", code)
+	}
+}
@@ -13,6 +13,9 @@ type User struct {
 	Email    string `json:"email" gorm:"unique"`
 	Name     string `json:"name"`
 	Password string `json:"password"`
+	// RecordingKey is the base64 X25519 public key camera recordings are
+	// encrypted to, the private half never leaves the user
+	RecordingKey string `json:"recordingKey"`
 }
 
 func (base *User) BeforeCreate(tx *gorm.DB) (err error) {
@@ -28,4 +31,134 @@ type Camera struct {
 	LastSeen *time.Time    `json:"lastSeen"`
 	IsOnline bool          `json:"isOnline" gorm:"default:false"`
 	Config   pb.UserConfig `json:"config" gorm:"serializer:json"`
+
+	// RefreshTokenHash is the SHA-256 of the camera's current refresh token
+	RefreshTokenHash string `json:"-"`
+	// PreviousRefreshTokenHash is the token replaced at RefreshTokenRotatedAt,
+	// accepted for a short while in case the camera never got the new one
+	PreviousRefreshTokenHash string    `json:"-"`
+	RefreshTokenRotatedAt    time.Time `json:"-"`
+	// Revoked cameras can't refresh or connect until they are provisioned again
+	Revoked bool `json:"revoked" gorm:"default:false"`
+
+	// Agent version reported by the camera and the state of its last rollout
+	FirmwareVersion string          `json:"firmwareVersion"`
+	Arch            string          `json:"arch"`
+	TargetVersion   string          `json:"targetVersion"`
+	UpdateStatus    pb.UpdateStatus `json:"updateStatus"`
+	UpdateError     string          `json:"updateError"`
+}
+
+// PendingClaim is a short code a user hands to a camera instead of a full
+// CameraAdd token. The camera redeems it once when it registers.
+type PendingClaim struct {
+	Code         string    `json:"code" gorm:"primaryKey"` // Normalized, without the separator
+	UserID       string    `json:"userID" gorm:"index"`
+	FriendlyName string    `json:"friendlyName"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	Used         bool      `json:"used" gorm:"default:false"`
+}
+
+// CameraCommand records a remote command sent to a camera and its result
+type CameraCommand struct {
+	ID          string           `json:"id" gorm:"type:uuid;primaryKey"`
+	CameraID    string           `json:"cameraID" gorm:"index"`
+	UserID      string           `json:"userID"`
+	Type        pb.CommandType   `json:"type"`
+	Status      pb.CommandStatus `json:"status"`
+	Output      string           `json:"output"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	CompletedAt *time.Time       `json:"completedAt"`
+}
+
+// PendingDeregistration is kept for a deleted camera until it acknowledges
+// that it wiped itself, so a message lost on the way is delivered again the
+// next time it connects or refreshes
+type PendingDeregistration struct {
+	CameraID         string    `json:"cameraID" gorm:"type:uuid;primaryKey"`
+	Reason           string    `json:"reason"`
+	DeleteRecordings bool      `json:"deleteRecordings"`
+	CreatedAt        time.Time `json:"createdAt"`
+
+	// RefreshTokenHash is the camera's last refresh token, it authenticates
+	// the camera after its row is gone
+	RefreshTokenHash string `json:"-"`
+}
+
+// Message returns the deregistration message sent to the camera
+func (d *PendingDeregistration) Message() *pb.Message {
+	recordings := pb.RecordingRetention_RECORDING_RETENTION_KEEP
+	if d.DeleteRecordings {
+		recordings = pb.RecordingRetention_RECORDING_RETENTION_DELETE
+	}
+	return &pb.Message{
+		From: "server",
+		To:   d.CameraID,
+		DataType: &pb.Message_Deregister{
+			Deregister: &pb.Deregister{
+				Reason:     d.Reason,
+				Recordings: recordings,
+			},
+		},
+	}
+}
+
+// Release is a signed camera agent build hosted by the server
+type Release struct {
+	Version   string    `json:"version" gorm:"primaryKey"`
+	Arch      string    `json:"arch" gorm:"primaryKey"`
+	SHA256    string    `json:"sha256"`
+	Size      int64     `json:"size"`
+	Signature []byte    `json:"signature"`
+	Path      string    `json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TelemetrySample is one health report from a camera, kept for a rolling window
+type TelemetrySample struct {
+	ID              uint      `json:"-" gorm:"primaryKey"`
+	CameraID        string    `json:"-" gorm:"index:idx_telemetry_camera_time"`
+	Timestamp       time.Time `json:"timestamp" gorm:"index:idx_telemetry_camera_time"`
+	UptimeSeconds   int64     `json:"uptimeSeconds"`
+	LoadAverage     float64   `json:"loadAverage"`
+	CPUUsage        float64   `json:"cpuUsage"`
+	SocTemperature  float64   `json:"socTemperature"`
+	DiskUsedBytes   int64     `json:"diskUsedBytes"`
+	DiskTotalBytes  int64     `json:"diskTotalBytes"`
+	RecordingsBytes int64     `json:"recordingsBytes"`
+	StreamFPS       float64   `json:"streamFps"`
+	StreamBitrate   float64   `json:"streamBitrate"`
+	Viewers         int32     `json:"viewers"`
+	// Recording storage as seen by the camera's retention policy
+	OldestRecording       *time.Time `json:"oldestRecording"`
+	LockedBytes           int64      `json:"lockedBytes"`
+	RetentionDeletedBytes int64      `json:"retentionDeletedBytes"`
+}
+
+// DiagnosticBundle is a tar.gz of camera diagnostics uploaded for a diagnostics command
+type DiagnosticBundle struct {
+	ID        string    `json:"id" gorm:"type:uuid;primaryKey"` // ID of the command that asked for it
+	CameraID  string    `json:"cameraID" gorm:"index"`
+	Size      int64     `json:"size"`
+	Path      string    `json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BackupSegment is a recording segment a camera backed up to server storage,
+// recordings are listed from these while the camera is offline
+type BackupSegment struct {
+	ID        uint                `json:"-" gorm:"primaryKey"`
+	CameraID  string              `json:"cameraID" gorm:"uniqueIndex:idx_backup_segment;index:idx_backup_camera_time"`
+	Session   string              `json:"session" gorm:"uniqueIndex:idx_backup_segment"`
+	File      string              `json:"file" gorm:"uniqueIndex:idx_backup_segment"`
+	StartTime int64               `json:"startTime" gorm:"index:idx_backup_camera_time"` // Unix milliseconds
+	Duration  float64             `json:"duration"`                                      // Seconds
+	Size      int64               `json:"size"`
+	Trigger   pb.RecordingTrigger `json:"trigger"`
+	CreatedAt time.Time           `json:"createdAt"`
+}
+
+// End returns the Unix milliseconds where the segment ends
+func (s *BackupSegment) End() int64 {
+	return s.StartTime + int64(s.Duration*1000)
 }
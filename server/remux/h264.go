@@ -0,0 +1,206 @@
+package remux
+
+import (
+	"errors"
+	"fmt"
+)
+
+// H.264 NAL unit types
+const (
+	naluIDR = 5
+	naluSPS = 7
+	naluPPS = 8
+	naluAUD = 9
+)
+
+var errShortSPS = errors.New("sequence parameter set too short")
+
+// splitAnnexB splits an Annex B byte stream at its start codes
+func splitAnnexB(data []byte) [][]byte {
+	var nalus [][]byte
+	start := -1
+	add := func(end int) {
+		if start < 0 {
+			return
+		}
+		nalu := data[start:end]
+		// Trailing zeros belong to the next four byte start code
+		for len(nalu) > 0 && nalu[len(nalu)-1] == 0 {
+			nalu = nalu[:len(nalu)-1]
+		}
+		if len(nalu) > 0 {
+			nalus = append(nalus, nalu)
+		}
+	}
+
+	for i := 0; i+2 < len(data); {
+		if data[i] == 0 && data[i+1] == 0 && data[i+2] == 1 {
+			add(i)
+			i += 3
+			start = i
+			continue
+		}
+		i++
+	}
+	add(len(data))
+	return nalus
+}
+
+// bitReader reads the exp-Golomb coded fields of a parameter set. Reading
+// past the end sets err and returns zeros from then on.
+type bitReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (b *bitReader) bit() uint {
+	if b.pos >= len(b.data)*8 {
+		b.err = errShortSPS
+		return 0
+	}
+	bit := uint(b.data[b.pos/8]>>(7-b.pos%8)) & 1
+	b.pos++
+	return bit
+}
+
+func (b *bitReader) bits(n int) uint {
+	var value uint
+	for range n {
+		value = value<<1 | b.bit()
+	}
+	return value
+}
+
+func (b *bitReader) ue() uint {
+	zeros := 0
+	for b.bit() == 0 {
+		if b.err != nil {
+			return 0
+		}
+		if zeros++; zeros > 31 {
+			b.err = errors.New("exp-Golomb code too long")
+			return 0
+		}
+	}
+	return 1<<zeros - 1 + b.bits(zeros)
+}
+
+func (b *bitReader) se() int {
+	value := b.ue()
+	if value%2 == 1 {
+		return int(value+1) / 2
+	}
+	return -int(value / 2)
+}
+
+// unescapeRBSP removes the emulation prevention bytes of a NAL unit
+func unescapeRBSP(nalu []byte) []byte {
+	out := make([]byte, 0, len(nalu))
+	zeros := 0
+	for _, b := range nalu {
+		if zeros >= 2 && b == 3 {
+			zeros = 0
+			continue
+		}
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// parseSPS returns the display size a sequence parameter set describes
+func parseSPS(sps []byte) (width int, height int, err error) {
+	if len(sps) < 4 {
+		return 0, 0, errShortSPS
+	}
+	profile := sps[1]
+	r := &bitReader{data: unescapeRBSP(sps[4:])}
+
+	r.ue() // seq_parameter_set_id
+	chromaFormat := uint(1)
+	switch profile {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		chromaFormat = r.ue()
+		if chromaFormat == 3 {
+			r.bit() // separate_colour_plane_flag
+		}
+		r.ue()  // bit_depth_luma_minus8
+		r.ue()  // bit_depth_chroma_minus8
+		r.bit() // qpprime_y_zero_transform_bypass_flag
+		if r.bit() == 1 {
+			lists := 8
+			if chromaFormat == 3 {
+				lists = 12
+			}
+			for i := range lists {
+				if r.bit() == 0 {
+					continue
+				}
+				size := 16
+				if i >= 6 {
+					size = 64
+				}
+				last, next := 8, 8
+				for range size {
+					if next != 0 {
+						next = (last + r.se() + 256) % 256
+					}
+					if next != 0 {
+						last = next
+					}
+				}
+			}
+		}
+	}
+
+	r.ue() // log2_max_frame_num_minus4
+	switch r.ue() {
+	case 0:
+		r.ue() // log2_max_pic_order_cnt_lsb_minus4
+	case 1:
+		r.bit() // delta_pic_order_always_zero_flag
+		r.se()  // offset_for_non_ref_pic
+		r.se()  // offset_for_top_to_bottom_field
+		for range r.ue() {
+			r.se()
+		}
+	}
+	r.ue()  // max_num_ref_frames
+	r.bit() // gaps_in_frame_num_value_allowed_flag
+
+	widthInMbs := r.ue()
+	heightInMapUnits := r.ue()
+	frameMbsOnly := r.bit()
+	if frameMbsOnly == 0 {
+		r.bit() // mb_adaptive_frame_field_flag
+	}
+	r.bit() // direct_8x8_inference_flag
+
+	width = int(widthInMbs+1) * 16
+	height = int(2-frameMbsOnly) * int(heightInMapUnits+1) * 16
+
+	if r.bit() == 1 {
+		left, right, top, bottom := r.ue(), r.ue(), r.ue(), r.ue()
+		cropX, cropY := 1, int(2-frameMbsOnly)
+		switch chromaFormat {
+		case 1:
+			cropX, cropY = 2, 2*int(2-frameMbsOnly)
+		case 2:
+			cropX = 2
+		}
+		width -= cropX * int(left+right)
+		height -= cropY * int(top+bottom)
+	}
+	if r.err != nil {
+		return 0, 0, r.err
+	}
+	if width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("invalid frame size %dx%d", width, height)
+	}
+	return width, height, nil
+}
@@ -0,0 +1,296 @@
+package remux
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	// timescale of the video track, the same 90 kHz clock MPEG-TS uses
+	timescale = 90000
+	// defaultFrameDuration is assumed until two frames give a real one, 30 fps
+	defaultFrameDuration = timescale / 30
+	// maxFrameGap is the longest frame duration kept, longer gaps between
+	// recordings are closed up
+	maxFrameGap = 10 * timescale
+)
+
+var ErrNoFrames = errors.New("no frames to write")
+
+// sample is one frame in the MP4 sample tables
+type sample struct {
+	size     uint32
+	duration uint32
+	offset   int32 // Composition offset, PTS - DTS
+	key      bool
+}
+
+// Writer builds a progressive MP4 with a single H.264 track. Frame data goes
+// to a temporary file until Finish writes the header tables before it.
+type Writer struct {
+	mdat    *os.File
+	size    int64
+	samples []sample
+	sps     []byte
+	pps     []byte
+	lastDTS int64
+}
+
+// NewWriter creates a writer keeping frame data in mdat, an empty file the
+// caller removes after Finish
+func NewWriter(mdat *os.File) *Writer {
+	return &Writer{mdat: mdat}
+}
+
+// Frames returns how many frames were written
+func (w *Writer) Frames() int {
+	return len(w.samples)
+}
+
+// WriteFrame appends a frame. The first frame must be a key frame, frames
+// are expected in decoding order.
+func (w *Writer) WriteFrame(au AccessUnit) error {
+	if len(w.samples) == 0 && !au.Key() {
+		return errors.New("first frame is not a key frame")
+	}
+
+	var data []byte
+	for _, nalu := range au.NALUs {
+		switch nalu[0] & 0x1f {
+		case naluSPS:
+			if w.sps == nil {
+				w.sps = bytes.Clone(nalu)
+			}
+		case naluPPS:
+			if w.pps == nil {
+				w.pps = bytes.Clone(nalu)
+			}
+		}
+		// Parameter sets stay in band too, in case they change between recordings
+		data = binary.BigEndian.AppendUint32(data, uint32(len(nalu)))
+		data = append(data, nalu...)
+	}
+	if _, err := w.mdat.Write(data); err != nil {
+		return err
+	}
+	w.size += int64(len(data))
+
+	if n := len(w.samples); n > 0 {
+		// Timestamps are 33 bits and wrap around
+		delta := (au.DTS - w.lastDTS) & (1<<33 - 1)
+		if delta == 0 || delta > maxFrameGap {
+			delta = defaultFrameDuration
+			if n > 1 {
+				delta = int64(w.samples[n-2].duration)
+			}
+		}
+		w.samples[n-1].duration = uint32(delta)
+	}
+	w.lastDTS = au.DTS
+
+	offset := (au.PTS - au.DTS) & (1<<33 - 1)
+	if offset > maxFrameGap {
+		offset = 0 // PTS before DTS, broken timestamps
+	}
+	w.samples = append(w.samples, sample{
+		size:     uint32(len(data)),
+		duration: defaultFrameDuration,
+		offset:   int32(offset),
+		key:      au.Key(),
+	})
+	if n := len(w.samples); n > 1 {
+		// The last frame lasts as long as the one before it
+		w.samples[n-1].duration = w.samples[n-2].duration
+	}
+	return nil
+}
+
+// Finish writes the MP4 to out, header first so it plays while downloading
+func (w *Writer) Finish(out io.Writer) error {
+	if len(w.samples) == 0 {
+		return ErrNoFrames
+	}
+	if w.sps == nil || w.pps == nil {
+		return errors.New("stream has no parameter sets")
+	}
+	width, height, err := parseSPS(w.sps)
+	if err != nil {
+		return fmt.Errorf("failed to parse SPS: %w", err)
+	}
+
+	ftyp := box("ftyp", []byte("isom"), u32(0x200), []byte("isomiso2avc1mp41"))
+	// The moov size doesn't depend on the offset, co64 entries are fixed size
+	moov := w.moov(width, height, 0)
+	mdatHeaderSize := int64(16)
+	moov = w.moov(width, height, uint64(int64(len(ftyp)+len(moov))+mdatHeaderSize))
+
+	if _, err := out.Write(ftyp); err != nil {
+		return err
+	}
+	if _, err := out.Write(moov); err != nil {
+		return err
+	}
+	// 64 bit mdat header, large clips can pass 4 GiB
+	header := binary.BigEndian.AppendUint32(nil, 1)
+	header = append(header, "mdat"...)
+	header = binary.BigEndian.AppendUint64(header, uint64(mdatHeaderSize+w.size))
+	if _, err := out.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.mdat.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = io.Copy(out, w.mdat)
+	return err
+}
+
+func (w *Writer) moov(width, height int, chunkOffset uint64) []byte {
+	var duration uint64
+	for _, s := range w.samples {
+		duration += uint64(s.duration)
+	}
+	durationMs := duration * 1000 / timescale
+
+	mvhd := fullBox("mvhd", 0, 0,
+		u32(0), u32(0), // Creation and modification time
+		u32(1000), u32(uint32(durationMs)),
+		u32(0x00010000), u16(0x0100), make([]byte, 10), // Rate, volume, reserved
+		matrix(),
+		make([]byte, 24), // Pre-defined
+		u32(2),           // Next track ID
+	)
+
+	tkhd := fullBox("tkhd", 0, 0x3, // Enabled, in movie
+		u32(0), u32(0), u32(1), u32(0), u32(uint32(durationMs)),
+		make([]byte, 8), u16(0), u16(0), u16(0), u16(0), // Layer, group, volume
+		matrix(),
+		u32(uint32(width)<<16), u32(uint32(height)<<16),
+	)
+
+	mdhd := fullBox("mdhd", 0, 0,
+		u32(0), u32(0), u32(timescale), u32(uint32(duration)),
+		u16(0x55c4), u16(0), // Language "und"
+	)
+	hdlr := fullBox("hdlr", 0, 0, u32(0), []byte("vide"), make([]byte, 12), []byte("VideoHandler\x00"))
+	vmhd := fullBox("vmhd", 0, 1, make([]byte, 8))
+	dinf := box("dinf", fullBox("dref", 0, 0, u32(1), fullBox("url ", 0, 1)))
+
+	stbl := box("stbl", w.stsd(width, height), w.stts(), w.ctts(), w.stss(),
+		fullBox("stsc", 0, 0, u32(1), u32(1), u32(uint32(len(w.samples))), u32(1)),
+		w.stsz(),
+		fullBox("co64", 0, 0, u32(1), u64(chunkOffset)),
+	)
+
+	minf := box("minf", vmhd, dinf, stbl)
+	mdia := box("mdia", mdhd, hdlr, minf)
+	return box("moov", mvhd, box("trak", tkhd, mdia))
+}
+
+func (w *Writer) stsd(width, height int) []byte {
+	avcC := box("avcC",
+		[]byte{1, w.sps[1], w.sps[2], w.sps[3], 0xff, 0xe1}, // 4 byte lengths, one SPS
+		u16(uint16(len(w.sps))), w.sps,
+		[]byte{1}, u16(uint16(len(w.pps))), w.pps,
+	)
+	avc1 := box("avc1",
+		make([]byte, 6), u16(1), // Reserved, data reference index
+		make([]byte, 16), // Pre-defined and reserved
+		u16(uint16(width)), u16(uint16(height)),
+		u32(0x00480000), u32(0x00480000), u32(0), u16(1), // 72 dpi, one frame per sample
+		make([]byte, 32), // Compressor name
+		u16(0x0018), u16(0xffff),
+		avcC,
+	)
+	return fullBox("stsd", 0, 0, u32(1), avc1)
+}
+
+// stts run length encodes the frame durations
+func (w *Writer) stts() []byte {
+	var entries []byte
+	count := 0
+	for i, s := range w.samples {
+		count++
+		if i+1 == len(w.samples) || w.samples[i+1].duration != s.duration {
+			entries = append(entries, u32(uint32(count))...)
+			entries = append(entries, u32(s.duration)...)
+			count = 0
+		}
+	}
+	return fullBox("stts", 0, 0, u32(uint32(len(entries)/8)), entries)
+}
+
+// ctts run length encodes the composition offsets, nil without B-frames
+func (w *Writer) ctts() []byte {
+	var entries []byte
+	count, reordered := 0, false
+	for i, s := range w.samples {
+		count++
+		reordered = reordered || s.offset != 0
+		if i+1 == len(w.samples) || w.samples[i+1].offset != s.offset {
+			entries = append(entries, u32(uint32(count))...)
+			entries = append(entries, u32(uint32(s.offset))...)
+			count = 0
+		}
+	}
+	if !reordered {
+		return nil
+	}
+	return fullBox("ctts", 0, 0, u32(uint32(len(entries)/8)), entries)
+}
+
+// stss lists the key frames, numbered from 1
+func (w *Writer) stss() []byte {
+	var entries []byte
+	for i, s := range w.samples {
+		if s.key {
+			entries = append(entries, u32(uint32(i+1))...)
+		}
+	}
+	return fullBox("stss", 0, 0, u32(uint32(len(entries)/4)), entries)
+}
+
+func (w *Writer) stsz() []byte {
+	entries := make([]byte, 0, 4*len(w.samples))
+	for _, s := range w.samples {
+		entries = append(entries, u32(s.size)...)
+	}
+	return fullBox("stsz", 0, 0, u32(0), u32(uint32(len(w.samples))), entries)
+}
+
+// box encodes an MP4 box with its size and type
+func box(kind string, payload ...[]byte) []byte {
+	size := 8
+	for _, p := range payload {
+		size += len(p)
+	}
+	out := make([]byte, 0, size)
+	out = binary.BigEndian.AppendUint32(out, uint32(size))
+	out = append(out, kind...)
+	for _, p := range payload {
+		out = append(out, p...)
+	}
+	return out
+}
+
+// fullBox encodes a box that starts with a version and flags
+func fullBox(kind string, version byte, flags uint32, payload ...[]byte) []byte {
+	header := u32(uint32(version)<<24 | flags&0xffffff)
+	return box(kind, append([][]byte{header}, payload...)...)
+}
+
+// matrix is the identity transformation matrix
+func matrix() []byte {
+	var out []byte
+	for _, v := range []uint32{0x00010000, 0, 0, 0, 0x00010000, 0, 0, 0, 0x40000000} {
+		out = binary.BigEndian.AppendUint32(out, v)
+	}
+	return out
+}
+
+func u16(v uint16) []byte { return binary.BigEndian.AppendUint16(nil, v) }
+func u32(v uint32) []byte { return binary.BigEndian.AppendUint32(nil, v) }
+func u64(v uint64) []byte { return binary.BigEndian.AppendUint64(nil, v) }
@@ -0,0 +1,191 @@
+package remux
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// findBox returns the payload of the box at path, descending into containers
+func findBox(t *testing.T, data []byte, path ...string) []byte {
+	t.Helper()
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data))
+		kind := string(data[4:8])
+		header := uint64(8)
+		if size == 1 {
+			size = binary.BigEndian.Uint64(data[8:])
+			header = 16
+		}
+		if size < header || size > uint64(len(data)) {
+			t.Fatalf("box %s of %d bytes overflows its parent", kind, size)
+		}
+		if kind == path[0] {
+			if len(path) == 1 {
+				return data[header:size]
+			}
+			payload := data[header:size]
+			if kind == "stsd" {
+				payload = payload[8:] // Version, flags and entry count
+			}
+			return findBox(t, payload, path[1:]...)
+		}
+		data = data[size:]
+	}
+	t.Fatalf("no %s box", path[0])
+	return nil
+}
+
+// entries returns the 32 bit fields of a full box after its version and flags
+func entries(payload []byte) []uint32 {
+	var fields []uint32
+	for i := 4; i+4 <= len(payload); i += 4 {
+		fields = append(fields, binary.BigEndian.Uint32(payload[i:]))
+	}
+	return fields
+}
+
+func remuxFrames(t *testing.T, frames []AccessUnit) []byte {
+	t.Helper()
+	mdat, err := os.Create(filepath.Join(t.TempDir(), "mdat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mdat.Close()
+
+	w := NewWriter(mdat)
+	for _, frame := range frames {
+		if err := w.WriteFrame(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w.Frames() != len(frames) {
+		t.Fatalf("%d frames written, want %d", w.Frames(), len(frames))
+	}
+	var out bytes.Buffer
+	if err := w.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func TestWriterFixture(t *testing.T) {
+	frames := readFixtureFrames(t, "segment.ts")
+	mp4 := remuxFrames(t, frames)
+
+	if brand := string(findBox(t, mp4, "ftyp")[:4]); brand != "isom" {
+		t.Fatalf("major brand %q", brand)
+	}
+	stbl := []string{"moov", "trak", "mdia", "minf", "stbl"}
+
+	tkhd := findBox(t, mp4, "moov", "trak", "tkhd")
+	if width, height := binary.BigEndian.Uint32(tkhd[len(tkhd)-8:])>>16, binary.BigEndian.Uint32(tkhd[len(tkhd)-4:])>>16; width != 560 || height != 320 {
+		t.Fatalf("track is %dx%d, want 560x320", width, height)
+	}
+	avcC := findBox(t, mp4, append(stbl, "stsd", "avc1")...)
+	if !bytes.Contains(avcC, frames[0].NALUs[0]) || !bytes.Contains(avcC, frames[0].NALUs[1]) {
+		t.Fatal("avc1 lacks the SPS and PPS")
+	}
+
+	// Ten frames of 3000 ticks, one key frame, no reordering
+	if stts := entries(findBox(t, mp4, append(stbl, "stts")...)); len(stts) != 3 || stts[0] != 1 || stts[1] != 10 || stts[2] != 3000 {
+		t.Fatalf("stts %v, want one run of 10 x 3000", stts)
+	}
+	if stss := entries(findBox(t, mp4, append(stbl, "stss")...)); len(stss) != 2 || stss[1] != 1 {
+		t.Fatalf("stss %v, want frame 1 only", stss)
+	}
+	moov := findBox(t, mp4, "moov")
+	if bytes.Contains(moov, []byte("ctts")) {
+		t.Fatal("ctts written without reordered frames")
+	}
+
+	// The co64 offset points at the first frame inside the 64 bit mdat
+	co64 := findBox(t, mp4, append(stbl, "co64")...)
+	if binary.BigEndian.Uint32(co64[4:]) != 1 {
+		t.Fatal("co64 doesn't hold one chunk")
+	}
+	offset := binary.BigEndian.Uint64(co64[8:])
+	mdatStart := bytes.Index(mp4, []byte("mdat")) - 4
+	if binary.BigEndian.Uint32(mp4[mdatStart:]) != 1 {
+		t.Fatal("mdat doesn't use a 64 bit size")
+	}
+	if offset != uint64(mdatStart+16) {
+		t.Fatalf("chunk offset %d, mdat data starts at %d", offset, mdatStart+16)
+	}
+	first := mp4[offset:]
+	sps := frames[0].NALUs[0]
+	if int(binary.BigEndian.Uint32(first)) != len(sps) || !bytes.Equal(first[4:4+len(sps)], sps) {
+		t.Fatal("chunk offset doesn't point at the first frame")
+	}
+
+	// Sample sizes add up to the mdat, which ends the file
+	stsz := entries(findBox(t, mp4, append(stbl, "stsz")...))
+	if stsz[0] != 0 || stsz[1] != 10 {
+		t.Fatalf("stsz header %v", stsz[:2])
+	}
+	var total uint64
+	for _, size := range stsz[2:] {
+		total += uint64(size)
+	}
+	mdatSize := binary.BigEndian.Uint64(mp4[mdatStart+8:])
+	if mdatSize != total+16 || uint64(mdatStart)+mdatSize != uint64(len(mp4)) {
+		t.Fatalf("mdat of %d bytes for %d bytes of samples in a %d byte file", mdatSize, total, len(mp4))
+	}
+}
+
+func TestWriterWrap(t *testing.T) {
+	mp4 := remuxFrames(t, readFixtureFrames(t, "wrap.ts"))
+	stbl := []string{"moov", "trak", "mdia", "minf", "stbl"}
+
+	// DTS wrapping past 2^33 is a normal frame step, not a gap
+	if stts := entries(findBox(t, mp4, append(stbl, "stts")...)); len(stts) != 3 || stts[1] != 10 || stts[2] != 3000 {
+		t.Fatalf("stts %v, want one run of 10 x 3000", stts)
+	}
+	// PTS ahead of DTS, also across the wrap
+	if ctts := entries(findBox(t, mp4, append(stbl, "ctts")...)); len(ctts) != 3 || ctts[1] != 10 || ctts[2] != 6000 {
+		t.Fatalf("ctts %v, want one run of 10 x 6000", ctts)
+	}
+}
+
+func TestWriterTiming(t *testing.T) {
+	frames := readFixtureFrames(t, "segment.ts")
+	key, delta := frames[0], frames[1]
+	at := func(au AccessUnit, dts int64) AccessUnit {
+		au.DTS, au.PTS = dts, dts
+		return au
+	}
+
+	mdat, err := os.Create(filepath.Join(t.TempDir(), "mdat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mdat.Close()
+	w := NewWriter(mdat)
+	if err := w.WriteFrame(delta); err == nil {
+		t.Fatal("first frame accepted without being a key frame")
+	}
+	if err := w.Finish(&bytes.Buffer{}); err != ErrNoFrames {
+		t.Fatalf("Finish without frames: %v, want ErrNoFrames", err)
+	}
+
+	// 25 fps, then a minute's gap between recordings, then a repeated DTS
+	for _, frame := range []AccessUnit{at(key, 0), at(delta, 3600), at(delta, 7200), at(key, 7200+60*timescale), at(delta, 7200+60*timescale)} {
+		if err := w.WriteFrame(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var durations []uint32
+	for _, s := range w.samples {
+		durations = append(durations, s.duration)
+	}
+	want := []uint32{3600, 3600, 3600, 3600, 3600}
+	if !slices.Equal(durations, want) {
+		t.Fatalf("durations %v, want %v", durations, want)
+	}
+	if stss := entries(w.stss()[8:]); len(stss) != 3 || stss[1] != 1 || stss[2] != 4 {
+		t.Fatalf("stss %v, want frames 1 and 4", stss)
+	}
+}
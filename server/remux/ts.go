@@ -0,0 +1,188 @@
+// Package remux turns the H.264 MPEG-TS segments cameras record into a single
+// MP4 file without re-encoding
+package remux
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	tsPacketSize = 188
+	tsSyncByte   = 0x47
+	// streamTypeH264 is the PMT stream type of H.264 video
+	streamTypeH264 = 0x1b
+)
+
+var (
+	ErrNoVideo = errors.New("no H.264 stream in segment")
+	ErrCorrupt = errors.New("corrupt transport stream")
+)
+
+// AccessUnit is one H.264 frame, timestamps are 90 kHz ticks
+type AccessUnit struct {
+	PTS   int64
+	DTS   int64
+	NALUs [][]byte
+}
+
+// Key reports whether the frame is an IDR frame, decodable on its own
+func (au AccessUnit) Key() bool {
+	for _, nalu := range au.NALUs {
+		if len(nalu) > 0 && nalu[0]&0x1f == naluIDR {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadTS demuxes the frames of the first H.264 stream in an MPEG-TS segment
+func ReadTS(data []byte) ([]AccessUnit, error) {
+	pmtPID, videoPID := -1, -1
+	var units []AccessUnit
+	var pes []byte
+
+	flush := func() error {
+		if len(pes) == 0 {
+			return nil
+		}
+		au, err := parsePES(pes)
+		pes = nil
+		if err != nil {
+			return err
+		}
+		if len(au.NALUs) > 0 {
+			units = append(units, au)
+		}
+		return nil
+	}
+
+	for offset := 0; offset+tsPacketSize <= len(data); offset += tsPacketSize {
+		packet := data[offset : offset+tsPacketSize]
+		if packet[0] != tsSyncByte {
+			return nil, fmt.Errorf("%w: lost sync at byte %d", ErrCorrupt, offset)
+		}
+		unitStart := packet[1]&0x40 != 0
+		pid := int(packet[1]&0x1f)<<8 | int(packet[2])
+		control := packet[3] >> 4 & 0x3
+
+		payload := packet[4:]
+		if control&0x2 != 0 {
+			length := int(payload[0])
+			if 1+length > len(payload) {
+				return nil, fmt.Errorf("%w: adaptation field overflows packet", ErrCorrupt)
+			}
+			payload = payload[1+length:]
+		}
+		if control&0x1 == 0 || len(payload) == 0 {
+			continue
+		}
+
+		switch {
+		case pid == 0 && unitStart && pmtPID < 0:
+			pmtPID = parsePAT(payload)
+		case pid == pmtPID && unitStart && videoPID < 0:
+			videoPID = parsePMT(payload)
+		case pid == videoPID:
+			if unitStart {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+			// flush starts a new slice, frames keep referencing the old one
+			pes = append(pes, payload...)
+		}
+	}
+
+	if videoPID < 0 {
+		return nil, ErrNoVideo
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return units, nil
+}
+
+// psiSection returns the section a PSI payload carries after its pointer field
+func psiSection(payload []byte) []byte {
+	pointer := int(payload[0])
+	if 1+pointer+3 > len(payload) {
+		return nil
+	}
+	section := payload[1+pointer:]
+	length := int(section[1]&0x0f)<<8 | int(section[2])
+	// Drop the CRC, sections split across packets are cut at this one
+	end := min(3+length-4, len(section))
+	if end < 8 {
+		return nil
+	}
+	return section[:end]
+}
+
+// parsePAT returns the PMT PID of the first program
+func parsePAT(payload []byte) int {
+	section := psiSection(payload)
+	for i := 8; i+4 <= len(section); i += 4 {
+		program := int(section[i])<<8 | int(section[i+1])
+		if program != 0 {
+			return int(section[i+2]&0x1f)<<8 | int(section[i+3])
+		}
+	}
+	return -1
+}
+
+// parsePMT returns the PID of the first H.264 stream
+func parsePMT(payload []byte) int {
+	section := psiSection(payload)
+	if len(section) < 12 {
+		return -1
+	}
+	infoLength := int(section[10]&0x0f)<<8 | int(section[11])
+	for i := 12 + infoLength; i+5 <= len(section); {
+		streamType := section[i]
+		pid := int(section[i+1]&0x1f)<<8 | int(section[i+2])
+		esInfoLength := int(section[i+3]&0x0f)<<8 | int(section[i+4])
+		if streamType == streamTypeH264 {
+			return pid
+		}
+		i += 5 + esInfoLength
+	}
+	return -1
+}
+
+// parsePES reads the timestamps and NAL units of a video PES packet
+func parsePES(pes []byte) (AccessUnit, error) {
+	if len(pes) < 9 || pes[0] != 0 || pes[1] != 0 || pes[2] != 1 {
+		return AccessUnit{}, fmt.Errorf("%w: bad PES start code", ErrCorrupt)
+	}
+	flags := pes[7]
+	headerLength := int(pes[8])
+	if 9+headerLength > len(pes) {
+		return AccessUnit{}, fmt.Errorf("%w: PES header overflows packet", ErrCorrupt)
+	}
+	header := pes[9 : 9+headerLength]
+
+	var au AccessUnit
+	switch {
+	case flags&0xc0 == 0xc0 && len(header) >= 10:
+		au.PTS = readTimestamp(header[0:5])
+		au.DTS = readTimestamp(header[5:10])
+	case flags&0x80 != 0 && len(header) >= 5:
+		au.PTS = readTimestamp(header[0:5])
+		au.DTS = au.PTS
+	default:
+		return AccessUnit{}, fmt.Errorf("%w: PES packet without timestamp", ErrCorrupt)
+	}
+
+	for _, nalu := range splitAnnexB(pes[9+headerLength:]) {
+		if nalu[0]&0x1f != naluAUD {
+			au.NALUs = append(au.NALUs, nalu)
+		}
+	}
+	return au, nil
+}
+
+// readTimestamp decodes a 33 bit PES timestamp
+func readTimestamp(b []byte) int64 {
+	return int64(b[0]>>1&0x07)<<30 | int64(b[1])<<22 | int64(b[2]>>1)<<15 | int64(b[3])<<7 | int64(b[4]>>1)
+}
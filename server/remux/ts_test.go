@@ -0,0 +1,118 @@
+package remux
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// The fixtures hold the first ten frames of a 560x320, 30 fps baseline H.264
+// clip muxed the way cameras record: an access unit delimiter before every
+// frame, SPS and PPS before key frames and an AAC stream listed first in the
+// PMT. segment.ts has PTS only starting at 126000. wrap.ts has PTS and DTS,
+// PTS 6000 ahead, and DTS wraps past 2^33 at the fifth frame.
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func readFixtureFrames(t *testing.T, name string) []AccessUnit {
+	t.Helper()
+	frames, err := ReadTS(readFixture(t, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return frames
+}
+
+func naluTypes(au AccessUnit) []byte {
+	var types []byte
+	for _, nalu := range au.NALUs {
+		types = append(types, nalu[0]&0x1f)
+	}
+	return types
+}
+
+func TestReadTS(t *testing.T) {
+	frames := readFixtureFrames(t, "segment.ts")
+	if len(frames) != 10 {
+		t.Fatalf("%d frames, want 10", len(frames))
+	}
+
+	for i, frame := range frames {
+		want := int64(126000 + i*3000)
+		if frame.PTS != want || frame.DTS != want {
+			t.Errorf("frame %d: PTS %d DTS %d, want %d", i, frame.PTS, frame.DTS, want)
+		}
+		if frame.Key() != (i == 0) {
+			t.Errorf("frame %d: key %v", i, frame.Key())
+		}
+	}
+
+	// Delimiters are dropped, the slices are whole across packets
+	if types := string(naluTypes(frames[0])); types != "\x07\x08\x06\x05" {
+		t.Fatalf("key frame NAL unit types %v, want SPS, PPS, SEI, IDR", []byte(types))
+	}
+	if n := len(frames[0].NALUs[3]); n != 21526 {
+		t.Fatalf("IDR slice is %d bytes, want 21526", n)
+	}
+	if types := string(naluTypes(frames[1])); types != "\x01" || len(frames[1].NALUs[0]) != 2844 {
+		t.Fatalf("second frame NAL units %v of %d bytes", []byte(types), len(frames[1].NALUs[0]))
+	}
+
+	width, height, err := parseSPS(frames[0].NALUs[0])
+	if err != nil || width != 560 || height != 320 {
+		t.Fatalf("SPS %dx%d, %v, want 560x320", width, height, err)
+	}
+}
+
+func TestReadTSWrap(t *testing.T) {
+	frames := readFixtureFrames(t, "wrap.ts")
+	if len(frames) != 10 {
+		t.Fatalf("%d frames, want 10", len(frames))
+	}
+	for i, frame := range frames {
+		dts := (1<<33 - 12000 + int64(i)*3000) & (1<<33 - 1)
+		pts := (dts + 6000) & (1<<33 - 1)
+		if frame.DTS != dts || frame.PTS != pts {
+			t.Errorf("frame %d: PTS %d DTS %d, want %d %d", i, frame.PTS, frame.DTS, pts, dts)
+		}
+	}
+	if frames[4].DTS != 0 || frames[2].PTS != 0 {
+		t.Fatalf("timestamps don't wrap at 2^33: %d %d", frames[4].DTS, frames[2].PTS)
+	}
+}
+
+func TestReadTSErrors(t *testing.T) {
+	data := readFixture(t, "segment.ts")
+
+	// PAT only, the PMT naming the video stream never comes
+	if _, err := ReadTS(data[:tsPacketSize]); !errors.Is(err, ErrNoVideo) {
+		t.Errorf("no PMT: %v, want ErrNoVideo", err)
+	}
+
+	lost := append([]byte{}, data...)
+	lost[5*tsPacketSize] = 0
+	if _, err := ReadTS(lost); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("lost sync: %v, want ErrCorrupt", err)
+	}
+
+	// An adaptation field longer than the packet
+	overflow := append([]byte{}, data...)
+	packet := overflow[2*tsPacketSize:]
+	packet[3] |= 0x20
+	packet[4] = 200
+	if _, err := ReadTS(overflow); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("adaptation field overflow: %v, want ErrCorrupt", err)
+	}
+
+	// A trailing partial packet is ignored, a partial frame kept
+	frames, err := ReadTS(data[:len(data)-100])
+	if err != nil || len(frames) != 10 {
+		t.Errorf("truncated: %d frames, %v", len(frames), err)
+	}
+}
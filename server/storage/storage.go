@@ -0,0 +1,153 @@
+// Package storage keeps the recording files cameras back up to the server
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"messages/s3"
+)
+
+var ErrNotFound = errors.New("file not found")
+
+// Store holds files under slash separated keys, e.g. <camera>/<session>/<file>
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns ErrNotFound for a key that was never stored
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes the file at key or every file under it
+	Delete(ctx context.Context, key string) error
+}
+
+// cleanKey rejects keys that would leave the store's root
+func cleanKey(key string) (string, error) {
+	clean := path.Clean("/" + key)[1:]
+	if clean == "" || clean != strings.TrimSuffix(key, "/") {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	return clean, nil
+}
+
+// Local stores files in a directory on the server's filesystem
+type Local struct {
+	dir string
+}
+
+// NewLocal creates a store in dir, creating it when missing
+func NewLocal(dir string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &Local{dir: dir}, nil
+}
+
+func (l *Local) path(key string) (string, error) {
+	clean, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(clean)), nil
+}
+
+// Put writes the file through a temporary file so readers never see it half written
+func (l *Local) Put(ctx context.Context, key string, data []byte) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+		return err
+	}
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+func (l *Local) Get(ctx context.Context, key string) ([]byte, error) {
+	name, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(name)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return data, err
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(name)
+}
+
+// S3 stores files in a bucket of an S3-compatible service
+type S3 struct {
+	client *s3.Client
+}
+
+// NewS3 creates a store in the configured bucket
+func NewS3(config s3.Config) (*S3, error) {
+	client, err := s3.New(config)
+	if err != nil {
+		return nil, err
+	}
+	return &S3{client: client}, nil
+}
+
+// Check verifies the bucket is reachable with the configured credentials
+func (s *S3) Check(ctx context.Context) error {
+	return s.client.Check(ctx)
+}
+
+func (s *S3) Put(ctx context.Context, key string, data []byte) error {
+	clean, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	return s.client.Put(ctx, clean, data)
+}
+
+func (s *S3) Get(ctx context.Context, key string) ([]byte, error) {
+	clean, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.client.Get(ctx, clean)
+	if errors.Is(err, s3.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return data, err
+}
+
+// Delete removes the object at key and every object under key as a directory
+func (s *S3) Delete(ctx context.Context, key string) error {
+	clean, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	if err := s.client.Delete(ctx, clean); err != nil {
+		return err
+	}
+
+	objects, err := s.client.List(ctx, clean+"/")
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, object := range objects {
+		if err := s.client.Delete(ctx, object.Key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
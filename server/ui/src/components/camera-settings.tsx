@@ -42,6 +42,7 @@ export function CameraSettings({ id, children }: CameraSettingsProps) {
   const camera = cameras.find((c) => c.id === id);
   const [name, setName] = useState(camera?.name || "");
   const [isDeleting, setIsDeleting] = useState(false);
+  const [deleteRecordings, setDeleteRecordings] = useState(false);
   const [isUpdating, setIsUpdating] = useState(false);
   const [open, setOpen] = useState(false);
   // Recording config state
@@ -92,7 +93,7 @@ export function CameraSettings({ id, children }: CameraSettingsProps) {
   const handleDeleteCamera = async () => {
     setIsDeleting(true);
     try {
-      await apiDelete('/api/cameras/delete', { camera_uuid: id });
+      await apiDelete('/api/cameras/delete', { camera_uuid: id, delete_recordings: deleteRecordings });
       await refetchCameras();
       setOpen(false);
       // Navigate back to cameras list
@@ -399,8 +400,17 @@ export function CameraSettings({ id, children }: CameraSettingsProps) {
                     <AlertDialogDescription>
                       This will permanently delete the camera "{camera.name}" from your account.
                       This action cannot be undone.
+                      The camera will be reset and return to setup mode.
                     </AlertDialogDescription>
                   </AlertDialogHeader>
+                  <div className="flex items-center space-x-2">
+                    <Checkbox
+                      id="delete-recordings"
+                      checked={deleteRecordings}
+                      onCheckedChange={(checked: boolean) => setDeleteRecordings(!!checked)}
+                    />
+                    <Label htmlFor="delete-recordings">Also delete recordings stored on the camera</Label>
+                  </div>
                   <AlertDialogFooter>
                     <AlertDialogCancel>Cancel</AlertDialogCancel>
                     <AlertDialogAction 
@@ -20,6 +20,9 @@ export default function AddCamera() {
   const [prevCameras, setPrevCameras] = useState<Camera[]>()
   const [tab, setTab] = useState<'setup' | 'code'>('setup')
   const [code, setCode] = useState<string | null>(null)
+  const [wifiCode, setWifiCode] = useState<string | null>(null)
+  const [claimCode, setClaimCode] = useState<string | null>(null)
+  const [serverUrl, setServerUrl] = useState("")
   const [isLoading, setIsLoading] = useState(false)
   const [cameraName, setCameraName] = useState("")
   const [wifiNetwork, setWifiNetwork] = useState("")
@@ -27,6 +30,8 @@ export default function AddCamera() {
   const [needWifi, setNeedWifi] = useState(true)
   const [showPassword, setShowPassword] = useState(false)
   const [qrPopupOpen, setQrPopupOpen] = useState(false)
+  // The Wi-Fi code is a separate step, it is never on screen with the setup QR code
+  const [wifiCodeShown, setWifiCodeShown] = useState(false)
 
   useEffect(() => {
 
@@ -55,7 +60,22 @@ export default function AddCamera() {
 
     setIsLoading(true)
     try {
-      const data = await apiPost<{code:string}>('/api/cameras/generate', {
+      // Cameras that are already online only need a short claim code
+      if (!needWifi) {
+        const claim = await apiPost<{claimCode:string, qr:string, serverUrl:string, expiresAt:number}>('/api/cameras/claim', {
+          friendly_name: cameraName
+        });
+        setPrevCameras(cameras)
+        setCode(claim.qr)
+        setClaimCode(claim.claimCode)
+        setServerUrl(claim.serverUrl)
+        setWifiCode(null)
+        toast.success("Claim code generated successfully!")
+        setTab('code')
+        return
+      }
+
+      const data = await apiPost<{code:string, wifiCode?:string}>('/api/cameras/generate', {
         friendly_name: cameraName,
         wifi_network: needWifi ? wifiNetwork : null,
         wifi_password: needWifi ? wifiPassword : null
@@ -64,6 +84,9 @@ export default function AddCamera() {
 
     
       setCode(data.code)
+      setWifiCode(data.wifiCode || null)
+      setWifiCodeShown(false)
+      setClaimCode(null)
       toast.success("QR code generated successfully!")
 
       setTab('code')
@@ -147,7 +170,21 @@ export default function AddCamera() {
             </TabsContent>
             
             <TabsContent value="code">
-              {code && (
+              {code && wifiCode && wifiCodeShown && (
+                <div className="flex flex-col items-center gap-6">
+                  <div className="bg-white p-4 rounded-lg">
+                    <QRCode value={wifiCode} size={256} />
+                  </div>
+                  <p className="font-medium">Wi-Fi code: <span className="font-mono">{wifiCode}</span></p>
+                  <p className="text-sm text-muted-foreground text-center max-w-md">
+                    Your Wi-Fi password is encrypted with this code. Show it to the camera now, or enter it in the camera's setup page.
+                  </p>
+                  <Button variant="outline" onClick={() => setWifiCodeShown(false)}>
+                    Back to setup QR code
+                  </Button>
+                </div>
+              )}
+              {code && !wifiCodeShown && (
                 <div className="flex flex-col items-center gap-6">
                   <div className="relative">
                     <div className="bg-white p-4 rounded-lg">
@@ -166,6 +203,21 @@ export default function AddCamera() {
                   <p className="text-sm text-muted-foreground text-center max-w-md">
                     Scan this QR code with your camera device. The setup process should complete automatically.
                   </p>
+                  {claimCode && (
+                    <p className="text-sm text-muted-foreground text-center max-w-md">
+                      Or type the claim code <span className="font-mono font-medium">{claimCode}</span> and the server address <span className="font-mono font-medium">{serverUrl}</span> into the camera's setup page. The code can be used once and expires in 30 minutes.
+                    </p>
+                  )}
+                  {wifiCode && (
+                    <div className="flex flex-col items-center gap-2">
+                      <p className="text-sm text-muted-foreground text-center max-w-md">
+                        Your Wi-Fi password is encrypted. After the camera has scanned this QR code, show it the Wi-Fi code.
+                      </p>
+                      <Button variant="outline" onClick={() => setWifiCodeShown(true)}>
+                        Show Wi-Fi code
+                      </Button>
+                    </div>
+                  )}
                 </div>
               )}
             </TabsContent>
@@ -184,7 +236,7 @@ export default function AddCamera() {
             <div className="flex w-full gap-4">
               <Button
                 variant="outline"
-                onClick={() => setCode(null)}
+                onClick={() => { setCode(null); setWifiCode(null); setClaimCode(null); setWifiCodeShown(false) }}
                 className="flex-1"
               >
                 Start Over
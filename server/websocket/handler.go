@@ -33,6 +33,16 @@ type Connection struct {
 	Type     ConnectionType
 	UserID   string // The user who owns this connection
 	EntityID string // Camera UUID or user identifier
+
+	// writeMutex serializes writes, gorilla connections allow only one writer
+	writeMutex sync.Mutex
+}
+
+// write sends a binary message on the connection
+func (c *Connection) write(data []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	return c.Conn.WriteMessage(websocket.BinaryMessage, data)
 }
 
 var (
@@ -110,6 +120,18 @@ func HandleWebSocket(db *gorm.DB) http.HandlerFunc {
 			var camera models.Camera
 			result := db.Where("id = ?", id).First(&camera)
 			if result.Error != nil {
+				// Deleted while offline, deliver the deregistration now. It
+				// stays until the camera acknowledges it.
+				var deregistration models.PendingDeregistration
+				if db.Where("camera_id = ?", id).First(&deregistration).Error == nil {
+					if err := SendProtoMessage(conn, deregistration.Message()); err != nil {
+						slog.Error("Failed to send deregistration", "camera_id", id, "error", err)
+					} else {
+						slog.Info("Deregistration sent", "camera_id", id)
+					}
+					conn.Close()
+					return
+				}
 
 				SendProtoMessage(conn, &pb.Message{DataType: &pb.Message_Response{Response: &pb.Response{Success: false, Message: "Camera not found in database"}}})
 
@@ -119,6 +141,15 @@ func HandleWebSocket(db *gorm.DB) http.HandlerFunc {
 				return
 			}
 
+			if camera.Revoked {
+				SendProtoMessage(conn, &pb.Message{DataType: &pb.Message_Response{Response: &pb.Response{Success: false, Message: "Camera credentials revoked"}}})
+
+				slog.Error("Revoked camera tried to connect", "camera_id", id)
+				conn.Close()
+
+				return
+			}
+
 			// Update camera status to online
 			if err := updateCameraStatus(db, id, true); err != nil {
 				slog.Error("Failed to update camera online status", "camera_id", id, "error", err)
@@ -172,13 +203,16 @@ func HandleWebSocket(db *gorm.DB) http.HandlerFunc {
 }
 
 func handleMessages(conn *websocket.Conn, sourceConn *Connection) {
-	msg := &pb.Message{}
 	for {
+		// Replies are handed to other goroutines, never reuse a message
+		msg := &pb.Message{}
 		err := readProtoMessage(conn, msg)
 		if err != nil {
 			slog.Error("Error reading WebSocket message", "error", err)
 			return
 		}
+		// Never trust the sender id a client claims
+		msg.From = sourceConn.EntityID
 		if msg.To != "server" {
 			// Get target connection
 			connectionsMutex.Lock()
@@ -198,7 +232,6 @@ func handleMessages(conn *websocket.Conn, sourceConn *Connection) {
 					"from_owner", sourceConn.UserID, "to_owner", targetConn.UserID)
 				continue
 			}
-			msg.From = sourceConn.EntityID
 			if msg.GetWebrtc() != nil {
 				// Forward WebRTC messages to the specified recipient
 				if msg.To != "" && msg.To != "server" {
@@ -211,26 +244,49 @@ func handleMessages(conn *websocket.Conn, sourceConn *Connection) {
 				}
 			}
 		} else {
-			if msg.GetHlsResponse() != nil {
+			// Replies to pending requests go to whoever is waiting for them
+			if calls.Deliver(msg) {
+				continue
+			}
+			if msg.GetResponse() != nil {
+				response := msg.GetResponse()
+				if response.Success {
+					slog.Info("Response from client", "from", sourceConn.EntityID, "message", response.Message)
+				} else {
+					slog.Warn("Error response from client", "from", sourceConn.EntityID, "message", response.Message)
+				}
+			}
+			if msg.GetAgentStatus() != nil {
 				messageHandlerMutex.Lock()
-				handler := messageHandlers["hlsResponse"]
+				handler := messageHandlers["agentStatus"]
 				messageHandlerMutex.Unlock()
 
 				if handler != nil {
 					handler(msg)
 				} else {
-					slog.Error("No handler for HLS response")
+					slog.Error("No handler for agent status")
 				}
 			}
-			if msg.GetRecordResponse() != nil {
+			if msg.GetTelemetry() != nil {
 				messageHandlerMutex.Lock()
-				handler := messageHandlers["recordResponse"]
+				handler := messageHandlers["telemetry"]
 				messageHandlerMutex.Unlock()
 
 				if handler != nil {
 					handler(msg)
 				} else {
-					slog.Error("No handler for record response")
+					slog.Error("No handler for telemetry")
+				}
+			}
+			if msg.GetCommandResult() != nil {
+				messageHandlerMutex.Lock()
+				handler := messageHandlers["commandResult"]
+				messageHandlerMutex.Unlock()
+
+				if handler != nil {
+					handler(msg)
+				} else {
+					slog.Error("No handler for command result")
 				}
 			}
 
@@ -300,7 +356,39 @@ func SendMessageToClient(clientID string, message *pb.Message) error {
 	}
 
 	// Send the binary message
-	return client.Conn.WriteMessage(websocket.BinaryMessage, data)
+	return client.write(data)
+}
+
+// IsConnected reports whether the client currently has an open connection
+func IsConnected(clientID string) bool {
+	connectionsMutex.Lock()
+	defer connectionsMutex.Unlock()
+	_, exists := connections[clientID]
+	return exists
+}
+
+// DisconnectClient tells a client why it is being dropped and closes its connection
+func DisconnectClient(clientID string, reason string) {
+	connectionsMutex.Lock()
+	client, exists := connections[clientID]
+	connectionsMutex.Unlock()
+
+	if !exists {
+		return
+	}
+
+	data, err := proto.Marshal(&pb.Message{
+		From: "server",
+		To:   clientID,
+		DataType: &pb.Message_Response{
+			Response: &pb.Response{Success: false, Message: reason},
+		},
+	})
+	if err == nil {
+		client.write(data)
+	}
+	client.Conn.Close()
+	slog.Info("Client disconnected by server", "id", clientID, "reason", reason)
 }
 
 func SendRefreshToClient(clientID string) error {
@@ -0,0 +1,47 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	pb "messages/msgspb"
+	"messages/rpc"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// calls holds the requests the server sent to clients that wait for replies
+var calls = rpc.NewTable(sendRequest)
+
+// sendRequest is SendMessageToClient, except that an offline client is an error
+func sendRequest(msg *pb.Message) error {
+	connectionsMutex.Lock()
+	client, exists := connections[msg.To]
+	connectionsMutex.Unlock()
+
+	if !exists {
+		return fmt.Errorf("%w: %s is not connected", rpc.ErrUnavailable, msg.To)
+	}
+
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return client.write(data)
+}
+
+// Call sends a request to a client and waits for its reply, until ctx is
+// done or rpc.DefaultTimeout passes without a deadline. A failed request is
+// returned as an *rpc.Error.
+func Call(ctx context.Context, clientID string, msg *pb.Message) (*pb.Message, error) {
+	msg.From = "server"
+	msg.To = clientID
+	return calls.Call(ctx, msg)
+}
+
+// StartCall sends a request answered with a stream of replies, read from the
+// returned call until it is closed
+func StartCall(clientID string, msg *pb.Message, buffer int) (*rpc.Call, error) {
+	msg.From = "server"
+	msg.To = clientID
+	return calls.Start(msg, buffer)
+}